@@ -0,0 +1,35 @@
+// server は Lambda と同じハンドラーを net/http で動かす、ローカル開発用の HTTP サーバー。
+// 環境変数は Lambda と同じものを使う。
+//
+//	COGNITO_JWKS_URL=... COGNITO_CLIENT_ID=... COGNITO_ISSUER=... DYNAMODB_ENDPOINT=http://localhost:8000 go run ./cmd/server
+package main
+
+import (
+	"bonded/internal/app"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+	_ "time/tzdata" // Lambda と同じく埋め込んだタイムゾーンのデータベースを使う
+
+	"github.com/MicahParks/keyfunc"
+)
+
+func main() {
+	addr := flag.String("addr", ":3000", "listen address")
+	flag.Parse()
+
+	jwks, err := keyfunc.Get(os.Getenv("COGNITO_JWKS_URL"), keyfunc.Options{})
+	if err != nil {
+		log.Fatalf("Failed to get JWKS: %v", err)
+	}
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           app.HTTPHandler(app.HandlerRequest(jwks)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("Listening on %s", *addr)
+	log.Fatal(server.ListenAndServe())
+}
@@ -17,6 +17,9 @@ if [ $? -ne 0 ]; then
             AttributeName=CalendarID,AttributeType=S \
             AttributeName=SortKey,AttributeType=S \
             AttributeName=UserID,AttributeType=S \
+            AttributeName=StartKey,AttributeType=S \
+            AttributeName=PublicListing,AttributeType=S \
+            AttributeName=PublicSortKey,AttributeType=S \
         --key-schema \
             AttributeName=CalendarID,KeyType=HASH \
             AttributeName=SortKey,KeyType=RANGE \
@@ -35,6 +38,34 @@ if [ $? -ne 0 ]; then
                         \"ReadCapacityUnits\": 5,
                         \"WriteCapacityUnits\": 5
                     }
+                },
+                {
+                    \"IndexName\": \"CalendarID-StartKey-index\",
+                    \"KeySchema\": [
+                        {\"AttributeName\":\"CalendarID\",\"KeyType\":\"HASH\"},
+                        {\"AttributeName\":\"StartKey\",\"KeyType\":\"RANGE\"}
+                    ],
+                    \"Projection\":{
+                        \"ProjectionType\":\"ALL\"
+                    },
+                    \"ProvisionedThroughput\": {
+                        \"ReadCapacityUnits\": 5,
+                        \"WriteCapacityUnits\": 5
+                    }
+                },
+                {
+                    \"IndexName\": \"PublicListing-index\",
+                    \"KeySchema\": [
+                        {\"AttributeName\":\"PublicListing\",\"KeyType\":\"HASH\"},
+                        {\"AttributeName\":\"PublicSortKey\",\"KeyType\":\"RANGE\"}
+                    ],
+                    \"Projection\":{
+                        \"ProjectionType\":\"ALL\"
+                    },
+                    \"ProvisionedThroughput\": {
+                        \"ReadCapacityUnits\": 5,
+                        \"WriteCapacityUnits\": 5
+                    }
                 }
             ]" \
         --endpoint-url "$ENDPOINT_URL" \
@@ -56,6 +87,14 @@ if [ $? -ne 0 ]; then
     fi
 
     echo "Remote Table '$TABLE_NAME' created successfully."
+
+    # ゴミ箱のアイテムを ExpiresAt の日時に削除する TTL を有効にする
+    aws dynamodb update-time-to-live \
+        --table-name "$TABLE_NAME" \
+        --time-to-live-specification "Enabled=true, AttributeName=ExpiresAt" \
+        --endpoint-url "$ENDPOINT_URL" \
+        --region "$REGION" \
+        >> create_table.log 2>&1
 else
     echo "Remote Table '$TABLE_NAME' already exists. Skipping creation."
 fi
@@ -15,6 +15,9 @@ if [ $? -ne 0 ]; then
             AttributeName=CalendarID,AttributeType=S \
             AttributeName=SortKey,AttributeType=S \
             AttributeName=UserID,AttributeType=S \
+            AttributeName=StartKey,AttributeType=S \
+            AttributeName=PublicListing,AttributeType=S \
+            AttributeName=PublicSortKey,AttributeType=S \
         --key-schema \
             AttributeName=CalendarID,KeyType=HASH \
             AttributeName=SortKey,KeyType=RANGE \
@@ -33,6 +36,34 @@ if [ $? -ne 0 ]; then
                         \"ReadCapacityUnits\": 5,
                         \"WriteCapacityUnits\": 5
                     }
+                },
+                {
+                    \"IndexName\": \"CalendarID-StartKey-index\",
+                    \"KeySchema\": [
+                        {\"AttributeName\":\"CalendarID\",\"KeyType\":\"HASH\"},
+                        {\"AttributeName\":\"StartKey\",\"KeyType\":\"RANGE\"}
+                    ],
+                    \"Projection\":{
+                        \"ProjectionType\":\"ALL\"
+                    },
+                    \"ProvisionedThroughput\": {
+                        \"ReadCapacityUnits\": 5,
+                        \"WriteCapacityUnits\": 5
+                    }
+                },
+                {
+                    \"IndexName\": \"PublicListing-index\",
+                    \"KeySchema\": [
+                        {\"AttributeName\":\"PublicListing\",\"KeyType\":\"HASH\"},
+                        {\"AttributeName\":\"PublicSortKey\",\"KeyType\":\"RANGE\"}
+                    ],
+                    \"Projection\":{
+                        \"ProjectionType\":\"ALL\"
+                    },
+                    \"ProvisionedThroughput\": {
+                        \"ReadCapacityUnits\": 5,
+                        \"WriteCapacityUnits\": 5
+                    }
                 }
             ]" \
         --endpoint-url http://localhost:8000 \
@@ -55,20 +86,40 @@ if [ $? -ne 0 ]; then
 
     echo "Table '$TABLE_NAME' created successfully."
 
+    # ゴミ箱のアイテムを ExpiresAt の日時に削除する TTL を有効にする
+    aws dynamodb update-time-to-live \
+        --table-name "$TABLE_NAME" \
+        --time-to-live-specification "Enabled=true, AttributeName=ExpiresAt" \
+        --endpoint-url http://localhost:8000 \
+        --region ap-northeast-1 \
+        >> create_table.log 2>&1
+
     # データ挿入
     echo "Inserting data into '$TABLE_NAME'..."
 
     # カレンダー情報の挿入
     CALENDARS=("1" "2")
     for CALENDAR in "${CALENDARS[@]}"; do
+        # オーナー以外のメンバー数
+        if [ "$CALENDAR" == "1" ]; then
+            FOLLOWER_COUNT=0
+        else
+            FOLLOWER_COUNT=1
+        fi
+
         aws dynamodb put-item \
             --table-name "$TABLE_NAME" \
             --item "{
                 \"CalendarID\": {\"S\": \"$CALENDAR\"},
                 \"SortKey\": {\"S\": \"CALENDAR\"},
                 \"Name\": {\"S\": \"Test Calendar $CALENDAR\"},
+                \"TimeZone\": {\"S\": \"Asia/Tokyo\"},
                 \"IsPublic\": {\"BOOL\": true},
-                \"OwnerUserID\": {\"S\": \"user1\"}
+                \"OwnerUserID\": {\"S\": \"user1\"},
+                \"OwnerName\": {\"S\": \"user1の表示名\"},
+                \"FollowerCount\": {\"N\": \"$FOLLOWER_COUNT\"},
+                \"PublicListing\": {\"S\": \"PUBLIC\"},
+                \"PublicSortKey\": {\"S\": \"test calendar $CALENDAR#$CALENDAR\"}
             }" \
             --endpoint-url http://localhost:8000 \
             --region ap-northeast-1 \
@@ -108,7 +159,7 @@ if [ $? -ne 0 ]; then
                 >> insert_data.log 2>&1
         done
 
-        # イベント情報の挿入
+        # イベント情報の挿入（日時は UTC で保存する。JST の 10:00〜11:00）
         EVENTS=("event1" "event2" "event3")
         for EVENT in "${EVENTS[@]}"; do
             aws dynamodb put-item \
@@ -119,8 +170,10 @@ if [ $? -ne 0 ]; then
                     \"EventID\": {\"S\": \"$EVENT\"},
                     \"Title\": {\"S\": \"Test Event $EVENT\"},
                     \"Description\": {\"S\": \"This is test event $EVENT\"},
-                    \"StartTime\": {\"S\": \"2021-08-0${EVENT: -1}T00:00:00Z\"},
-                    \"EndTime\": {\"S\": \"2021-08-0${EVENT: -1}T01:00:00Z\"},
+                    \"StartTime\": {\"S\": \"2021-08-0${EVENT: -1}T01:00:00Z\"},
+                    \"EndTime\": {\"S\": \"2021-08-0${EVENT: -1}T02:00:00Z\"},
+                    \"TimeZone\": {\"S\": \"Asia/Tokyo\"},
+                    \"StartKey\": {\"S\": \"T#2021-08-0${EVENT: -1}T01:00:00Z#$EVENT\"},
                     \"Location\": {\"S\": \"場所$EVENT\"},
                     \"AllDay\": {\"BOOL\": false}
                 }" \
@@ -0,0 +1,36 @@
+// Package app は Lambda と ローカルの HTTP サーバーで共通の、依存関係の組み立てとルーティングをまとめる
+package app
+
+import (
+	"bonded/internal/handler"
+	"bonded/internal/infra/db"
+	"bonded/internal/middleware"
+	"bonded/internal/repository"
+	"bonded/internal/router"
+	"bonded/internal/usecase"
+	"context"
+	"os"
+
+	"github.com/MicahParks/keyfunc"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Handler は API Gateway のプロキシ統合のリクエストを処理する関数
+type Handler func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// HandlerRequest は環境変数の設定でリポジトリ・ユースケース・認証を組み立て、すべてのエンドポイントを処理する Handler を返す
+func HandlerRequest(jwks *keyfunc.JWKS) Handler {
+	clientID := os.Getenv("COGNITO_CLIENT_ID")
+	cognitoIssuer := os.Getenv("COGNITO_ISSUER")
+	dynamoClient := db.DynamoDBClientRequest()
+	calendarRepo := repository.CalendarRepositoryRequest(dynamoClient)
+	eventRepo := repository.EventRepositoryRequest(dynamoClient)
+	userRepo := repository.UserRepositoryRequest(dynamoClient)
+	caledarUsecase := usecase.CalendarUsecaseRequest(calendarRepo, eventRepo, userRepo)
+	h := handler.HandlerRequest(caledarUsecase)
+	r := router.New(routes(h)...)
+	authUsecase := usecase.NewAuthUsecase(jwks, clientID, cognitoIssuer)
+	middleware := middleware.NewAuthMiddleware(authUsecase, r.IsPublic)
+
+	return middleware.AuthMiddleware(r.Handle)
+}
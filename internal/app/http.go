@@ -0,0 +1,121 @@
+package app
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// maxBodyBytes は API Gateway が受け付けるペイロードの上限
+const maxBodyBytes = 10 << 20
+
+// HTTPHandler は Handler を net/http で動かす。パスパラメータは Lambda で動かす場合と同じくルーターがパスから取り出す
+func HTTPHandler(next Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request, err := proxyRequest(w, r)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, `{"message":"Request Too Long"}`, http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		response, err := next(r.Context(), request)
+		if err != nil {
+			// Lambda が関数のエラーを返した場合と同じく 502 にする
+			log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			io.WriteString(w, `{"message": "Internal server error"}`)
+			return
+		}
+		writeProxyResponse(w, response)
+	})
+}
+
+// proxyRequest は http.Request を API Gateway のプロキシ統合のリクエストに変換する
+func proxyRequest(w http.ResponseWriter, r *http.Request) (events.APIGatewayProxyRequest, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		return events.APIGatewayProxyRequest{}, err
+	}
+
+	request := events.APIGatewayProxyRequest{
+		Path:                            r.URL.Path,
+		HTTPMethod:                      r.Method,
+		Headers:                         map[string]string{},
+		MultiValueHeaders:               map[string][]string{},
+		QueryStringParameters:           map[string]string{},
+		MultiValueQueryStringParameters: map[string][]string{},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			HTTPMethod: r.Method,
+			Path:       r.URL.Path,
+			Identity:   events.APIGatewayRequestIdentity{SourceIP: sourceIP(r.RemoteAddr)},
+		},
+	}
+	for name, values := range r.Header {
+		request.Headers[name] = values[len(values)-1]
+		request.MultiValueHeaders[name] = values
+	}
+	if r.Host != "" {
+		request.Headers["Host"] = r.Host
+	}
+	for name, values := range r.URL.Query() {
+		request.QueryStringParameters[name] = values[len(values)-1]
+		request.MultiValueQueryStringParameters[name] = values
+	}
+	if utf8.Valid(body) {
+		request.Body = string(body)
+	} else {
+		request.Body = base64.StdEncoding.EncodeToString(body)
+		request.IsBase64Encoded = true
+	}
+
+	return request, nil
+}
+
+// writeProxyResponse は API Gateway のプロキシ統合のレスポンスを書き出す
+func writeProxyResponse(w http.ResponseWriter, response events.APIGatewayProxyResponse) {
+	for name, value := range response.Headers {
+		w.Header().Set(name, value)
+	}
+	for name, values := range response.MultiValueHeaders {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+
+	body := []byte(response.Body)
+	if response.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(response.Body)
+		if err != nil {
+			log.Printf("invalid base64 response body: %v", err)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body = decoded
+	}
+
+	statusCode := response.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
+
+func sourceIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestHTTPHandlerProxiesRequest(t *testing.T) {
+	var got events.APIGatewayProxyRequest
+	handler := HTTPHandler(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		got = request
+		return events.APIGatewayProxyResponse{
+			StatusCode:        http.StatusCreated,
+			Headers:           map[string]string{"Content-Type": "application/json"},
+			MultiValueHeaders: map[string][]string{"Set-Cookie": {"a=1", "b=2"}},
+			Body:              `{"ok":true}`,
+		}, nil
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/calendar/abc?limit=10&limit=20", strings.NewReader(`{"name":"Tea"}`))
+	r.RemoteAddr = "192.0.2.1:4567"
+	r.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if got.HTTPMethod != http.MethodPost || got.Path != "/calendar/abc" || got.Body != `{"name":"Tea"}` || got.IsBase64Encoded {
+		t.Errorf("request = %s %s %q base64=%v", got.HTTPMethod, got.Path, got.Body, got.IsBase64Encoded)
+	}
+	if got.Headers["Authorization"] != "Bearer token" || got.Headers["Host"] != "example.com" {
+		t.Errorf("headers = %v", got.Headers)
+	}
+	if got.QueryStringParameters["limit"] != "20" || len(got.MultiValueQueryStringParameters["limit"]) != 2 {
+		t.Errorf("query = %v %v", got.QueryStringParameters, got.MultiValueQueryStringParameters)
+	}
+	if got.RequestContext.Identity.SourceIP != "192.0.2.1" {
+		t.Errorf("source IP = %q", got.RequestContext.Identity.SourceIP)
+	}
+
+	res := w.Result()
+	if res.StatusCode != http.StatusCreated || res.Header.Get("Content-Type") != "application/json" || len(res.Header.Values("Set-Cookie")) != 2 {
+		t.Errorf("response = %d %v", res.StatusCode, res.Header)
+	}
+	if body, _ := io.ReadAll(res.Body); string(body) != `{"ok":true}` {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestHTTPHandlerBinaryBodies(t *testing.T) {
+	binary := []byte{0xff, 0x00, 0xfe}
+	var got events.APIGatewayProxyRequest
+	handler := HTTPHandler(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		got = request
+		return events.APIGatewayProxyResponse{Body: base64.StdEncoding.EncodeToString(binary), IsBase64Encoded: true}, nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/import", strings.NewReader(string(binary))))
+
+	if !got.IsBase64Encoded || got.Body != base64.StdEncoding.EncodeToString(binary) {
+		t.Errorf("request body = %q base64=%v", got.Body, got.IsBase64Encoded)
+	}
+	// ステータスコードが無ければ 200 にする
+	if w.Code != http.StatusOK || w.Body.String() != string(binary) {
+		t.Errorf("response = %d %q", w.Code, w.Body.Bytes())
+	}
+}
+
+func TestHTTPHandlerErrors(t *testing.T) {
+	called := false
+	failing := HTTPHandler(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		called = true
+		return events.APIGatewayProxyResponse{}, errors.New("boom")
+	})
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		called bool
+	}{
+		{"handler error", `{}`, http.StatusBadGateway, true},
+		{"body too large", strings.Repeat("a", maxBodyBytes+1), http.StatusRequestEntityTooLarge, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called = false
+			w := httptest.NewRecorder()
+			failing.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/calendar", strings.NewReader(tt.body)))
+			if w.Code != tt.status || called != tt.called {
+				t.Errorf("response = %d %q, handler called = %v", w.Code, w.Body.String(), called)
+			}
+		})
+	}
+}
+
+func TestHTTPHandlerInvalidResponseBody(t *testing.T) {
+	handler := HTTPHandler(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: "not base64!", IsBase64Encoded: true}, nil
+	})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/calendar", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"bonded/internal/handler"
+	"bonded/internal/router"
+)
+
+// routes は API のルート表。template.yaml の Events にも同じメソッドとパスを登録する
+func routes(h *handler.Handler) []router.Route {
+	return []router.Route{
+		{Method: "GET", Path: "/hello", Handler: h.HelloHandler, Public: true},
+		{Method: "GET", Path: "/calendar/{calendarId}", Handler: h.HandleGetCalendar, Public: true},
+		{Method: "GET", Path: "/calendar/{calendarId}/export.ics", Handler: h.HandleExportCalendar, Public: true},
+		{Method: "POST", Path: "/calendar/{calendarId}/import", Handler: h.HandleImportEvents},
+		{Method: "POST", Path: "/calendar/{calendarId}/feed", Handler: h.HandleCreateFeedToken},
+		{Method: "GET", Path: "/calendar/{calendarId}/feed", Handler: h.HandleGetFeedTokens},
+		{Method: "DELETE", Path: "/calendar/{calendarId}/feed/{tokenId}", Handler: h.HandleRevokeFeedToken},
+		// フィードは Bearer トークンの代わりにクエリのフィードトークンで認証する
+		{Method: "GET", Path: "/feed/{calendarId}/calendar.ics", Handler: h.HandleGetFeed, Public: true},
+		{Method: "GET", Path: "/calendar/list", Handler: h.HandleGetCalendars},
+		{Method: "PUT", Path: "/calendar/follow", Handler: h.HandleFollowCalendar},
+		{Method: "GET", Path: "/calendar/list/public", Handler: h.HandleGetPublicCalendars, Public: true},
+		{Method: "DELETE", Path: "/calendar/unfollow", Handler: h.HandleUnfollowCalendar},
+		{Method: "POST", Path: "/calendar/create", Handler: h.HandleCreateCalendar},
+		{Method: "PUT", Path: "/calendar/edit/{calendarId}", Handler: h.HandleEditCalendar},
+		{Method: "DELETE", Path: "/calendar/delete/{calendarId}", Handler: h.HandleDeleteCalendar},
+		{Method: "POST", Path: "/event/create/{calendarId}", Handler: h.HandleCreateEvent},
+		{Method: "PUT", Path: "/event/edit/{calendarId}", Handler: h.HandleEditEvent},
+		{Method: "DELETE", Path: "/event/delete", Handler: h.HandleDeleteEvent},
+		{Method: "GET", Path: "/event/{calendarId}/{eventId}", Handler: h.HandleGetEvent},
+		{Method: "POST", Path: "/event/{calendarId}/{eventId}/attendee", Handler: h.HandleInviteAttendees},
+		{Method: "PUT", Path: "/event/{calendarId}/{eventId}/rsvp", Handler: h.HandleRespondToEvent},
+		{Method: "GET", Path: "/event/list/{calendarId}", Handler: h.HandleGetEventList},
+		{Method: "GET", Path: "/trash/calendar", Handler: h.HandleGetTrashedCalendars},
+		{Method: "DELETE", Path: "/trash/calendar/{calendarId}", Handler: h.HandlePurgeCalendar},
+		{Method: "PUT", Path: "/trash/calendar/{calendarId}/restore", Handler: h.HandleRestoreCalendar},
+		{Method: "GET", Path: "/trash/calendar/{calendarId}/event", Handler: h.HandleGetTrashedEvents},
+		{Method: "PUT", Path: "/trash/calendar/{calendarId}/event/{eventId}/restore", Handler: h.HandleRestoreEvent},
+		{Method: "GET", Path: "/calendar/{calendarId}/invitation", Handler: h.HandleGetInvitations},
+		{Method: "DELETE", Path: "/calendar/{calendarId}/invitation/{userId}", Handler: h.HandleRevokeInvitation},
+		{Method: "PUT", Path: "/calendar/{calendarId}/member/{userId}", Handler: h.HandleChangeMemberAccessLevel},
+		{Method: "DELETE", Path: "/calendar/{calendarId}/member/{userId}", Handler: h.HandleRemoveMember},
+		{Method: "PUT", Path: "/calendar/{calendarId}/owner", Handler: h.HandleTransferOwnership},
+		{Method: "POST", Path: "/calendar/{calendarId}/share-link", Handler: h.HandleCreateShareLink},
+		{Method: "GET", Path: "/calendar/{calendarId}/share-link", Handler: h.HandleGetShareLinks},
+		{Method: "DELETE", Path: "/calendar/{calendarId}/share-link/{linkId}", Handler: h.HandleRevokeShareLink},
+		{Method: "PUT", Path: "/share-link/{calendarId}/redeem", Handler: h.HandleRedeemShareLink},
+		{Method: "GET", Path: "/me", Handler: h.HandleGetMe},
+		{Method: "PUT", Path: "/me", Handler: h.HandleEditMe},
+		{Method: "GET", Path: "/user/search", Handler: h.HandleSearchUsers},
+		{Method: "GET", Path: "/invitation", Handler: h.HandleGetReceivedInvitations},
+		{Method: "PUT", Path: "/invitation/{calendarId}/accept", Handler: h.HandleAcceptInvitation},
+		{Method: "PUT", Path: "/invitation/{calendarId}/decline", Handler: h.HandleDeclineInvitation},
+		{Method: "POST", Path: "/calendar/user/invite", Handler: h.HandleInviteUser},
+	}
+}
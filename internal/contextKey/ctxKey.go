@@ -3,3 +3,8 @@ package contextKey
 type ctxKey struct{}
 
 var JwtDataKey = ctxKey{}
+
+type idTokenKey struct{}
+
+// IDTokenKey は検証済みの Cognito の ID トークン（X-Id-Token ヘッダー）。送られていない場合はコンテキストにない
+var IDTokenKey = idTokenKey{}
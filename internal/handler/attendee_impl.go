@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"bonded/internal/models"
+)
+
+func (h *Handler) HandleInviteAttendees(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var requestBody models.InviteAttendees
+	err := decodeBody(request, &requestBody)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	version, ok, err := parseIfMatch(request.Headers)
+	if err != nil {
+		return badRequestResponse(err.Error())
+	}
+	if !ok {
+		return preconditionRequiredResponse()
+	}
+
+	calendarID := request.PathParameters["calendarId"]
+	eventID := request.PathParameters["eventId"]
+	event, err := h.EventUsecase.InviteAttendees(ctx, calendarID, eventID, requestBody.Attendees, version)
+	return attendeeResponse(event, err)
+}
+
+func (h *Handler) HandleRespondToEvent(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var rsvp models.RSVP
+	err := decodeBody(request, &rsvp)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	calendarID := request.PathParameters["calendarId"]
+	eventID := request.PathParameters["eventId"]
+	event, err := h.EventUsecase.RespondToEvent(ctx, calendarID, eventID, &rsvp)
+	return attendeeResponse(event, err)
+}
+
+// attendeeResponse は参加者を更新した結果を、更新後のイベントと ETag で返す
+func attendeeResponse(event *models.Event, err error) (events.APIGatewayProxyResponse, error) {
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(200, event, etagHeaders(event.Version))
+}
@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"bonded/internal/ical"
 	"bonded/internal/models"
+	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 )
@@ -13,327 +15,158 @@ func (h *Handler) HandleGetCalendar(ctx context.Context, request events.APIGatew
 	calendarID := request.PathParameters["calendarId"]
 	calendar, err := h.CalendarUsecase.FindCalendar(ctx, calendarID)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error finding calendar: " + err.Error(),
-		}, nil
+		return errorResponse(err)
 	}
+	return jsonResponse(200, calendar, etagHeaders(calendar.Version))
+}
 
-	if calendar == nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 404,
-			Body:       "Calendar not found",
-		}, nil
+func (h *Handler) HandleExportCalendar(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	calendarID := request.PathParameters["calendarId"]
+	calendar, err := h.CalendarUsecase.FindCalendar(ctx, calendarID)
+	if err != nil {
+		return errorResponse(err)
 	}
 
-	body, err := json.Marshal(calendar)
+	var body bytes.Buffer
+	err = ical.Encode(&body, calendar, time.Now())
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error marshalling response: " + err.Error(),
-		}, nil
+		return errorResponse(err)
 	}
-	return events.APIGatewayProxyResponse{
-		StatusCode: 200,
-		Headers: map[string]string{
-			"Access-Control-Allow-Origin":  "*",
-			"Access-Control-Allow-Headers": "Content-Type,Authorization,X-ID-Token",
-			"Access-Control-Allow-Methods": "GET,POST,PUT,DELETE,OPTIONS",
-		},
-		Body: string(body),
-	}, nil
+	return newResponse(200, map[string]string{
+		"Content-Type":        "text/calendar; charset=utf-8",
+		"Content-Disposition": `attachment; filename="` + calendarID + `.ics"`,
+	}, body.String()), nil
 }
 
 func (h *Handler) HandleGetCalendars(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	calendars, err := h.CalendarUsecase.FindCalendars(ctx)
+	page, err := parsePageRequest(request.QueryStringParameters)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error finding calendars: " + err.Error(),
-		}, nil
+		return badRequestResponse(err.Error())
 	}
-	body, err := json.Marshal(calendars)
+
+	calendars, err := h.CalendarUsecase.FindCalendars(ctx, page)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error marshalling response: " + err.Error(),
-		}, nil
+		return errorResponse(err)
 	}
-	return events.APIGatewayProxyResponse{
-		StatusCode: 200,
-		Headers: map[string]string{
-			"Access-Control-Allow-Origin":  "*",
-			"Access-Control-Allow-Headers": "Content-Type,Authorization,X-ID-Token",
-			"Access-Control-Allow-Methods": "GET,POST,PUT,DELETE,OPTIONS",
-		},
-		Body: string(body),
-	}, nil
+	return jsonResponse(200, calendars, nil)
 }
 
 func (h *Handler) HandleGetPublicCalendars(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	calendars, err := h.CalendarUsecase.FindPublicCalendars(ctx)
+	page, err := parsePageRequest(request.QueryStringParameters)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error finding public calendars: " + err.Error(),
-		}, nil
-	}
-	if calendars == nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 404,
-			Body:       "No public calendars found",
-		}, nil
+		return badRequestResponse(err.Error())
 	}
 
-	body, err := json.Marshal(calendars)
+	calendars, err := h.CalendarUsecase.FindPublicCalendars(ctx, page)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error marshalling response: " + err.Error(),
-		}, nil
+		return errorResponse(err)
 	}
-	return events.APIGatewayProxyResponse{
-		StatusCode: 200,
-		Headers: map[string]string{
-			"Access-Control-Allow-Origin":  "*",
-			"Access-Control-Allow-Headers": "Content-Type,Authorization,X-ID-Token",
-			"Access-Control-Allow-Methods": "GET,POST,PUT,DELETE,OPTIONS",
-		},
-		Body: string(body),
-	}, nil
+	return jsonResponse(200, calendars, nil)
 }
 
 func (h *Handler) HandleUnfollowCalendar(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	var requestBody struct {
-		CalendarID string `json:"calendarId"`
-	}
-	err := json.Unmarshal([]byte(request.Body), &requestBody)
+	var requestBody models.FollowCalendar
+	err := decodeBody(request, &requestBody)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 400,
-			Body:       "Invalid request payload: " + err.Error(),
-		}, nil
-	}
-	if requestBody.CalendarID == "" {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 400,
-			Body:       "Missing required fields: calendarId",
-		}, nil
+		return errorResponse(err)
 	}
 
 	calendar, err := h.CalendarUsecase.FindCalendar(ctx, requestBody.CalendarID)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error finding calendar: " + err.Error(),
-		}, nil
-	}
-	if calendar == nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 404,
-			Body:       "Calendar not found",
-		}, nil
+		return errorResponse(err)
 	}
 
 	err = h.CalendarUsecase.UnfollowCalendar(ctx, calendar)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error unfollowing calendar: " + err.Error(),
-		}, nil
+		return errorResponse(err)
 	}
-	return events.APIGatewayProxyResponse{
-		StatusCode: 200,
-		Headers: map[string]string{
-			"Access-Control-Allow-Origin":  "*",
-			"Access-Control-Allow-Headers": "Content-Type,Authorization,X-ID-Token",
-			"Access-Control-Allow-Methods": "GET,POST,PUT,DELETE,OPTIONS",
-		},
-		Body: `{"message":"Calendar unfollowed successfully."}`,
-	}, nil
+	return messageResponse(200, "Calendar unfollowed successfully.")
 }
 
 func (h *Handler) HandleCreateCalendar(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	var calendar models.CreateCalendar
-	err := json.Unmarshal([]byte(request.Body), &calendar)
+	err := decodeBody(request, &calendar)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 400,
-			Body:       "Invalid request payload: " + err.Error(),
-		}, nil
-	}
-
-	if calendar.Name == "" || calendar.IsPublic == nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 400,
-			Body:       "Missing required fields: name or isPublic",
-		}, nil
+		return errorResponse(err)
 	}
 
 	err = h.CalendarUsecase.CreateCalendar(ctx, &calendar)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error saving calendar: " + err.Error(),
-		}, nil
+		return errorResponse(err)
 	}
-	return events.APIGatewayProxyResponse{
-		StatusCode: 201,
-		Headers: map[string]string{
-			"Access-Control-Allow-Origin":  "*",
-			"Access-Control-Allow-Headers": "Content-Type,Authorization,X-ID-Token",
-			"Access-Control-Allow-Methods": "GET,POST,PUT,DELETE,OPTIONS",
-		},
-		Body: `{"message":"Calendar created successfully."}`,
-	}, nil
+	return messageResponse(201, "Calendar created successfully.")
 }
 
 func (h *Handler) HandleEditCalendar(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	var input models.Calendar
-	err := json.Unmarshal([]byte(request.Body), &input)
+	var input models.EditCalendar
+	err := decodeBody(request, &input)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 400,
-			Body:       "Invalid request payload" + fmt.Sprint(err),
-		}, nil
+		return errorResponse(err)
 	}
 	calendarId := request.PathParameters["calendarId"]
-	input.CalendarID = calendarId
+	version, ok, err := parseIfMatch(request.Headers)
+	if err != nil {
+		return badRequestResponse(err.Error())
+	}
+	if !ok {
+		return preconditionRequiredResponse()
+	}
 
-	calendar, err := h.CalendarUsecase.FindCalendar(ctx, input.CalendarID)
-	if err != nil || calendar == nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 404,
-			Body:       "Calendar not found",
-		}, nil
+	calendar, err := h.CalendarUsecase.FindCalendar(ctx, calendarId)
+	if err != nil {
+		return errorResponse(err)
 	}
 
-	err = h.CalendarUsecase.EditCalendar(ctx, calendar, &input)
+	updated, err := h.CalendarUsecase.EditCalendar(ctx, calendar, &input, version)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Failed to edit calendar",
-		}, nil
+		return errorResponse(err)
 	}
 
-	return events.APIGatewayProxyResponse{
-		StatusCode: 200,
-		Headers: map[string]string{
-			"Access-Control-Allow-Origin":  "*",
-			"Access-Control-Allow-Headers": "Content-Type,Authorization,X-ID-Token",
-			"Access-Control-Allow-Methods": "GET,POST,PUT,DELETE,OPTIONS",
-		},
-		Body: `{"message":"Calendar edited successfully."}`,
-	}, nil
+	message := map[string]string{"message": "Calendar edited successfully."}
+	return jsonResponse(200, message, etagHeaders(updated.Version))
 }
 
 func (h *Handler) HandleDeleteCalendar(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	calendarId := request.PathParameters["calendarId"]
-	err := h.CalendarUsecase.DeleteCalendar(ctx, calendarId)
-	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Failed to delete calendar",
-		}, nil
+	dryRun := false
+	if value := request.QueryStringParameters["dryRun"]; value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return badRequestResponse("dryRun must be true or false")
+		}
+		dryRun = parsed
 	}
-	return events.APIGatewayProxyResponse{
-		StatusCode: 200,
-		Headers: map[string]string{
-			"Access-Control-Allow-Origin":  "*",
-			"Access-Control-Allow-Headers": "Content-Type,Authorization,X-ID-Token",
-			"Access-Control-Allow-Methods": "GET,POST,PUT,DELETE,OPTIONS",
-		},
-		Body: `{"message":"Calendar deleted successfully."}`,
-	}, nil
-}
-
-func (h *Handler) HandleFollowCalendar(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	var requestBody struct {
-		CalendarID string `json:"calendarId"`
-	}
-	err := json.Unmarshal([]byte(request.Body), &requestBody)
+	// ゴミ箱に移す場合だけ、読み込んだ版のままかを確かめる
+	version, ok, err := parseIfMatch(request.Headers)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 400,
-			Body:       "Invalid request payload: " + err.Error(),
-		}, nil
-	}
-	if requestBody.CalendarID == "" {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 400,
-			Body:       "Missing required fields: calendarId",
-		}, nil
-	}
-
-	calendar, err := h.CalendarUsecase.FindCalendar(ctx, requestBody.CalendarID)
-	if err != nil || calendar == nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error finding calendar: " + err.Error(),
-		}, nil
+		return badRequestResponse(err.Error())
 	}
-	if !*calendar.IsPublic {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 403,
-			Body:       "Calendar is not public",
-		}, nil
+	if !ok && !dryRun {
+		return preconditionRequiredResponse()
 	}
 
-	err = h.CalendarUsecase.FollowCalendar(ctx, calendar)
+	report, err := h.CalendarUsecase.DeleteCalendar(ctx, calendarId, dryRun, version)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error following calendar: " + err.Error(),
-		}, nil
+		return errorResponse(err)
 	}
-	return events.APIGatewayProxyResponse{
-		StatusCode: 200,
-		Headers: map[string]string{
-			"Access-Control-Allow-Origin":  "*",
-			"Access-Control-Allow-Headers": "Content-Type,Authorization,X-ID-Token",
-			"Access-Control-Allow-Methods": "GET,POST,PUT,DELETE,OPTIONS",
-		},
-		Body: `{"message":"Calendar followed successfully."}`,
-	}, nil
+	return jsonResponse(200, report, nil)
 }
 
-func (h *Handler) HandleInviteUser(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	var requestBody struct {
-		InviteUserID string `json:"inviteUserId"`
-		CalendarID   string `json:"calendarId"`
-		AccessLevel  string `json:"accessLevel"`
-	}
-
-	err := json.Unmarshal([]byte(request.Body), &requestBody)
+func (h *Handler) HandleFollowCalendar(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var requestBody models.FollowCalendar
+	err := decodeBody(request, &requestBody)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 400,
-			Body:       "Invalid request payload: " + err.Error(),
-		}, nil
+		return errorResponse(err)
 	}
 
-	if requestBody.AccessLevel != "EDITOR" && requestBody.AccessLevel != "VIEWER" {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 400,
-			Body:       "Invalid access level. Must be either 'EDITOR' or 'VIEWER'",
-		}, nil
+	calendar, err := h.CalendarUsecase.FindCalendar(ctx, requestBody.CalendarID)
+	if err != nil {
+		return errorResponse(err)
 	}
 
-	err = h.CalendarUsecase.InviteUser(ctx, requestBody.CalendarID, requestBody.InviteUserID, requestBody.AccessLevel)
+	err = h.CalendarUsecase.FollowCalendar(ctx, calendar)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error inviting user: " + err.Error(),
-		}, nil
+		return errorResponse(err)
 	}
-
-	return events.APIGatewayProxyResponse{
-		StatusCode: 200,
-		Headers: map[string]string{
-			"Access-Control-Allow-Origin":  "*",
-			"Access-Control-Allow-Headers": "Content-Type,Authorization,X-ID-Token",
-			"Access-Control-Allow-Methods": "GET,POST,PUT,DELETE,OPTIONS",
-		},
-		Body: `{"message":"User invited successfully"}`,
-	}, nil
+	return messageResponse(200, "Calendar followed successfully.")
 }
@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 
@@ -10,143 +14,155 @@ import (
 )
 
 func (h *Handler) HandleCreateEvent(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	var event models.Event
-	err := json.Unmarshal([]byte(request.Body), &event)
+	var input models.CreateEvent
+	err := decodeBody(request, &input)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 400,
-			Body:       "Error unmarshalling request: " + err.Error(),
-		}, nil
+		return errorResponse(err)
 	}
 	calendarID := request.PathParameters["calendarId"]
 
 	calendar, err := h.CalendarUsecase.FindCalendar(ctx, calendarID)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error finding calendar: " + err.Error(),
-		}, nil
+		return errorResponse(err)
 	}
-	if calendar == nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 404,
-			Body:       "Calendar not found",
-		}, nil
+
+	err = h.EventUsecase.CreateEvent(ctx, calendar, input.Event())
+	if err != nil {
+		return errorResponse(err)
+	}
+	return messageResponse(201, "Event created successfully.")
+}
+
+func (h *Handler) HandleImportEvents(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	body := []byte(request.Body)
+	if request.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(request.Body)
+		if err != nil {
+			return badRequestResponse("body is not valid base64: " + err.Error())
+		}
+		body = decoded
+	}
+	if len(body) == 0 {
+		return badRequestResponse("an iCalendar (.ics) body is required")
 	}
 
-	err = h.EventUsecase.CreateEvent(ctx, calendar, &event)
+	calendarID := request.PathParameters["calendarId"]
+	calendar, err := h.CalendarUsecase.FindCalendar(ctx, calendarID)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error creating event: " + err.Error(),
-		}, nil
+		return errorResponse(err)
 	}
 
-	return events.APIGatewayProxyResponse{
-		StatusCode: 201,
-		Headers: map[string]string{
-			"Access-Control-Allow-Origin":  "*",
-			"Access-Control-Allow-Headers": "Content-Type,Authorization,X-ID-Token",
-			"Access-Control-Allow-Methods": "GET,POST,PUT,DELETE,OPTIONS",
-		},
-		Body: `{"message":"Event created successfully."}`,
-	}, nil
+	report, err := h.EventUsecase.ImportEvents(ctx, calendar, bytes.NewReader(body))
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(200, report, nil)
 }
 
 func (h *Handler) HandleEditEvent(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	var event models.Event
-	err := json.Unmarshal([]byte(request.Body), &event)
+	// 本文は JSON Merge Patch として扱い、含まれているフィールドだけを更新する
+	var requestBody models.EditEvent
+	err := decodeBody(request, &requestBody)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 400,
-			Body:       "Error unmarshalling request: " + err.Error(),
-		}, nil
+		return errorResponse(err)
 	}
 
-	calendarID := request.PathParameters["calendarId"]
-	updatedEvent, err := h.EventUsecase.EditEvent(ctx, calendarID, &event)
+	version, ok, err := parseIfMatch(request.Headers)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error editing event: " + err.Error(),
-		}, nil
+		return badRequestResponse(err.Error())
+	}
+	if !ok {
+		return preconditionRequiredResponse()
 	}
 
-	updatedEventJSON, err := json.Marshal(updatedEvent)
+	calendarID := request.PathParameters["calendarId"]
+	updatedEvent, err := h.EventUsecase.EditEvent(ctx, calendarID, &requestBody.EventPatch, requestBody.Scope, version)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error marshalling response: " + err.Error(),
-		}, nil
+		return errorResponse(err)
 	}
+	return jsonResponse(200, updatedEvent, etagHeaders(updatedEvent.Version))
+}
 
-	return events.APIGatewayProxyResponse{
-		StatusCode: 200,
-		Headers: map[string]string{
-			"Access-Control-Allow-Origin":  "*",
-			"Access-Control-Allow-Headers": "Content-Type,Authorization,X-ID-Token",
-			"Access-Control-Allow-Methods": "GET,POST,PUT,DELETE,OPTIONS",
-		},
-		Body: string(updatedEventJSON),
-	}, nil
+func (h *Handler) HandleGetEvent(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	calendarID := request.PathParameters["calendarId"]
+	eventID := request.PathParameters["eventId"]
+	event, err := h.EventUsecase.FindEvent(ctx, calendarID, eventID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(200, event, etagHeaders(event.Version))
 }
 
 func (h *Handler) HandleGetEventList(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	calendarID := request.PathParameters["calendarId"]
-	eventList, err := h.EventUsecase.FindEvents(ctx, calendarID)
-	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error finding calendar: " + err.Error(),
-		}, nil
-	}
-
-	body, err := json.Marshal(eventList)
-	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error marshalling response: " + err.Error(),
-		}, nil
-	}
-	return events.APIGatewayProxyResponse{
-		StatusCode: 200,
-		Headers: map[string]string{
-			"Access-Control-Allow-Origin":  "*",
-			"Access-Control-Allow-Headers": "Content-Type,Authorization,X-ID-Token",
-			"Access-Control-Allow-Methods": "GET,POST,PUT,DELETE,OPTIONS",
-		},
-		Body: string(body),
-	}, nil
+	window, err := parseTimeRange(request.QueryStringParameters)
+	if err != nil {
+		return badRequestResponse(err.Error())
+	}
+	page, err := parsePageRequest(request.QueryStringParameters)
+	if err != nil {
+		return badRequestResponse(err.Error())
+	}
+
+	eventList, err := h.EventUsecase.FindEvents(ctx, calendarID, window, page)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(200, eventList, nil)
 }
 
 func (h *Handler) HandleDeleteEvent(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	var requestBody struct {
-		EventID    string `json:"eventId"`
-		CalendarID string `json:"calendarId"`
+	var requestBody models.DeleteEvent
+	err := decodeBody(request, &requestBody)
+	if err != nil {
+		return errorResponse(err)
 	}
-	err := json.Unmarshal([]byte(request.Body), &requestBody)
+
+	version, ok, err := parseIfMatch(request.Headers)
+	if err != nil {
+		return badRequestResponse(err.Error())
+	}
+	if !ok {
+		return preconditionRequiredResponse()
+	}
+
+	err = h.EventUsecase.DeleteEvent(ctx, requestBody.CalendarID, requestBody.EventID, requestBody.RecurrenceID, requestBody.Scope, version)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 400,
-			Body:       "Invalid request payload: " + err.Error(),
-		}, nil
+		return errorResponse(err)
 	}
+	return messageResponse(200, "Event deleted successfully.")
+}
 
-	err = h.EventUsecase.DeleteEvent(ctx, requestBody.CalendarID, requestBody.EventID)
+// parseTimeRange はクエリパラメータ from/to から検索期間を組み立てる。どちらも未指定なら nil を返す
+func parseTimeRange(query map[string]string) (*models.TimeRange, error) {
+	from, to := query["from"], query["to"]
+	if from == "" && to == "" {
+		return nil, nil
+	}
+	if from == "" || to == "" {
+		return nil, errors.New("both from and to are required")
+	}
+
+	fromTime, err := parseQueryTime(from)
+	if err != nil {
+		return nil, err
+	}
+	toTime, err := parseQueryTime(to)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error deleting event: " + err.Error(),
-		}, nil
+		return nil, err
+	}
+	if !toTime.After(fromTime) {
+		return nil, errors.New("to must be after from")
 	}
+	return &models.TimeRange{From: fromTime, To: toTime}, nil
+}
 
-	return events.APIGatewayProxyResponse{
-		StatusCode: 200,
-		Headers: map[string]string{
-			"Access-Control-Allow-Origin":  "*",
-			"Access-Control-Allow-Headers": "Content-Type,Authorization,X-ID-Token",
-			"Access-Control-Allow-Methods": "GET,POST,PUT,DELETE,OPTIONS",
-		},
-		Body: `{"message":"Event deleted successfully."}`,
-	}, nil
+func parseQueryTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: must be RFC 3339 or YYYY-MM-DD", value)
 }
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"bonded/internal/ical"
+	"bytes"
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func (h *Handler) HandleCreateFeedToken(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	calendarID := request.PathParameters["calendarId"]
+	token, err := h.CalendarUsecase.CreateFeedToken(ctx, calendarID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	token.FeedURL, token.WebcalURL = feedURLs(request, calendarID, token.Token)
+	return jsonResponse(201, token, nil)
+}
+
+func (h *Handler) HandleGetFeedTokens(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	calendarID := request.PathParameters["calendarId"]
+	tokens, err := h.CalendarUsecase.FindFeedTokens(ctx, calendarID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(200, tokens, nil)
+}
+
+func (h *Handler) HandleRevokeFeedToken(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	calendarID := request.PathParameters["calendarId"]
+	tokenID := request.PathParameters["tokenId"]
+	err := h.CalendarUsecase.RevokeFeedToken(ctx, calendarID, tokenID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return messageResponse(200, "Feed token revoked successfully.")
+}
+
+// HandleGetFeed はカレンダーアプリが定期的に取得する読み取り専用の ICS フィードを返す。
+// Bearer トークンを送れないクライアントのため、非公開カレンダーはクエリの token で認証する。
+func (h *Handler) HandleGetFeed(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	calendarID := request.PathParameters["calendarId"]
+	calendar, err := h.CalendarUsecase.FindFeedCalendar(ctx, calendarID, request.QueryStringParameters["token"])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	var body bytes.Buffer
+	err = ical.Encode(&body, calendar, time.Now())
+	if err != nil {
+		return errorResponse(err)
+	}
+	return newResponse(200, map[string]string{
+		"Content-Type":  "text/calendar; charset=utf-8",
+		"Cache-Control": "private, max-age=300",
+	}, body.String()), nil
+}
+
+// feedURLs は購読用の https:// と webcal:// の URL を組み立てる
+func feedURLs(request events.APIGatewayProxyRequest, calendarID string, token string) (string, string) {
+	path := "/feed/" + calendarID + "/calendar.ics?token=" + url.QueryEscape(token)
+	host := request.Headers["Host"]
+	if host == "" {
+		return path, ""
+	}
+	// カスタムドメインでなければステージ名がパスに含まれる
+	if stage := request.RequestContext.Stage; stage != "" && strings.HasSuffix(host, ".amazonaws.com") {
+		path = "/" + stage + path
+	}
+	return "https://" + host + path, "webcal://" + host + path
+}
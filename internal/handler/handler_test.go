@@ -0,0 +1,218 @@
+package handler_test
+
+import (
+	"bonded/internal/contextKey"
+	"bonded/internal/handler"
+	"bonded/internal/models"
+	"bonded/internal/repository/memory"
+	"bonded/internal/usecase"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// problem はレスポンスの problem details のうちテストで確かめる項目
+type problem struct {
+	Status int                 `json:"status"`
+	Detail string              `json:"detail"`
+	Errors []map[string]string `json:"errors"`
+}
+
+func newHandler() *handler.Handler {
+	store := memory.NewStore()
+	return handler.HandlerRequest(usecase.CalendarUsecaseRequest(
+		memory.CalendarRepositoryRequest(store),
+		memory.EventRepositoryRequest(store),
+		memory.UserRepositoryRequest(store),
+	))
+}
+
+func signedIn(userID string) context.Context {
+	token := &jwt.Token{Claims: jwt.MapClaims{"sub": userID, "name": userID + " name"}}
+	return context.WithValue(context.Background(), contextKey.JwtDataKey, token)
+}
+
+// createCalendar はハンドラー経由でカレンダーを作成し、一覧から作成したカレンダーの概要を返す
+func createCalendar(t *testing.T, h *handler.Handler, userID string, body string) *models.CalendarSummary {
+	t.Helper()
+	ctx := signedIn(userID)
+	res, err := h.HandleCreateCalendar(ctx, events.APIGatewayProxyRequest{Body: body})
+	if err != nil || res.StatusCode != http.StatusCreated {
+		t.Fatalf("HandleCreateCalendar = %d %s, %v", res.StatusCode, res.Body, err)
+	}
+	res, err = h.HandleGetCalendars(ctx, events.APIGatewayProxyRequest{})
+	if err != nil || res.StatusCode != http.StatusOK {
+		t.Fatalf("HandleGetCalendars = %d %s, %v", res.StatusCode, res.Body, err)
+	}
+	var page models.Page[*models.CalendarSummary]
+	decode(t, res, &page)
+	if len(page.Items) != 1 {
+		t.Fatalf("calendars = %s", res.Body)
+	}
+	return page.Items[0]
+}
+
+func decode(t *testing.T, res events.APIGatewayProxyResponse, v interface{}) {
+	t.Helper()
+	err := json.Unmarshal([]byte(res.Body), v)
+	if err != nil {
+		t.Fatalf("decode %q: %v", res.Body, err)
+	}
+}
+
+// assertProblem はレスポンスが status の problem details であることを確かめる
+func assertProblem(t *testing.T, res events.APIGatewayProxyResponse, status int) problem {
+	t.Helper()
+	if res.StatusCode != status {
+		t.Fatalf("status = %d, want %d (%s)", res.StatusCode, status, res.Body)
+	}
+	if got := res.Headers["Content-Type"]; got != "application/problem+json" {
+		t.Errorf("Content-Type = %q, want application/problem+json", got)
+	}
+	var p problem
+	decode(t, res, &p)
+	if p.Status != status {
+		t.Errorf("problem status = %d, want %d", p.Status, status)
+	}
+	return p
+}
+
+func pathParameters(calendarID string) map[string]string {
+	return map[string]string{"calendarId": calendarID}
+}
+
+func TestCreateAndGetCalendar(t *testing.T) {
+	h := newHandler()
+	created := createCalendar(t, h, "alice", `{"name":"Team","isPublic":false,"timeZone":"Asia/Tokyo"}`)
+
+	res, err := h.HandleGetCalendar(signedIn("alice"), events.APIGatewayProxyRequest{PathParameters: pathParameters(created.CalendarID)})
+	if err != nil {
+		t.Fatalf("HandleGetCalendar: %v", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d (%s)", res.StatusCode, res.Body)
+	}
+	if res.Headers["ETag"] != `"1"` || res.Headers["Access-Control-Allow-Origin"] != "*" {
+		t.Errorf("headers = %v", res.Headers)
+	}
+	var calendar models.Calendar
+	decode(t, res, &calendar)
+	if calendar.Name != "Team" || calendar.OwnerUserID != "alice" || len(calendar.Users) != 1 {
+		t.Errorf("calendar = %+v", calendar)
+	}
+
+	res, err = h.HandleGetCalendar(signedIn("bob"), events.APIGatewayProxyRequest{PathParameters: pathParameters(created.CalendarID)})
+	if err != nil {
+		t.Fatalf("HandleGetCalendar: %v", err)
+	}
+	assertProblem(t, res, http.StatusForbidden)
+	res, err = h.HandleGetCalendar(signedIn("alice"), events.APIGatewayProxyRequest{PathParameters: pathParameters("missing")})
+	if err != nil {
+		t.Fatalf("HandleGetCalendar: %v", err)
+	}
+	assertProblem(t, res, http.StatusNotFound)
+}
+
+func TestCreateCalendarValidation(t *testing.T) {
+	h := newHandler()
+
+	res, err := h.HandleCreateCalendar(signedIn("alice"), events.APIGatewayProxyRequest{Body: `{"name":"  "}`})
+	if err != nil {
+		t.Fatalf("HandleCreateCalendar: %v", err)
+	}
+	p := assertProblem(t, res, http.StatusUnprocessableEntity)
+	fields := map[string]bool{}
+	for _, fieldError := range p.Errors {
+		fields[fieldError["field"]] = true
+	}
+	if !fields["name"] || !fields["isPublic"] {
+		t.Errorf("field errors = %v, want name and isPublic", p.Errors)
+	}
+
+	res, err = h.HandleCreateCalendar(signedIn("alice"), events.APIGatewayProxyRequest{Body: `{"name":`})
+	if err != nil {
+		t.Fatalf("HandleCreateCalendar: %v", err)
+	}
+	assertProblem(t, res, http.StatusBadRequest)
+}
+
+func TestEditCalendarRequiresIfMatch(t *testing.T) {
+	h := newHandler()
+	created := createCalendar(t, h, "alice", `{"name":"Team","isPublic":true}`)
+	alice := signedIn("alice")
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+		etag    string
+	}{
+		{"missing If-Match", nil, http.StatusPreconditionRequired, ""},
+		{"malformed If-Match", map[string]string{"If-Match": "1"}, http.StatusBadRequest, ""},
+		{"current version", map[string]string{"if-match": `"1"`}, http.StatusOK, `"2"`},
+		{"stale version", map[string]string{"If-Match": `"1"`}, http.StatusPreconditionFailed, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := h.HandleEditCalendar(alice, events.APIGatewayProxyRequest{
+				PathParameters: pathParameters(created.CalendarID),
+				Headers:        tt.headers,
+				Body:           `{"name":"Renamed"}`,
+			})
+			if err != nil {
+				t.Fatalf("HandleEditCalendar: %v", err)
+			}
+			if tt.status != http.StatusOK {
+				assertProblem(t, res, tt.status)
+				return
+			}
+			if res.StatusCode != tt.status || res.Headers["ETag"] != tt.etag {
+				t.Errorf("response = %d, ETag %q (%s)", res.StatusCode, res.Headers["ETag"], res.Body)
+			}
+		})
+	}
+}
+
+func TestRequestModelsRejectServerManagedFields(t *testing.T) {
+	h := newHandler()
+	created := createCalendar(t, h, "alice", `{"name":"Team","isPublic":false}`)
+	alice := signedIn("alice")
+
+	res, err := h.HandleEditCalendar(alice, events.APIGatewayProxyRequest{
+		PathParameters: pathParameters(created.CalendarID),
+		Headers:        map[string]string{"If-Match": `"1"`},
+		Body:           `{"name":"Mine","ownerUserId":"bob"}`,
+	})
+	if err != nil {
+		t.Fatalf("HandleEditCalendar: %v", err)
+	}
+	p := assertProblem(t, res, http.StatusUnprocessableEntity)
+	if len(p.Errors) != 1 || p.Errors[0]["field"] != "ownerUserId" {
+		t.Errorf("field errors = %v, want ownerUserId", p.Errors)
+	}
+
+	event := `"title":"Standup","startTime":"2024-04-01T10:00:00Z","endTime":"2024-04-01T11:00:00Z"`
+	res, err = h.HandleCreateEvent(alice, events.APIGatewayProxyRequest{
+		PathParameters: pathParameters(created.CalendarID),
+		Body:           `{` + event + `,"uid":"taken@example.com"}`,
+	})
+	if err != nil {
+		t.Fatalf("HandleCreateEvent: %v", err)
+	}
+	p = assertProblem(t, res, http.StatusUnprocessableEntity)
+	if len(p.Errors) != 1 || p.Errors[0]["field"] != "uid" {
+		t.Errorf("field errors = %v, want uid", p.Errors)
+	}
+
+	res, err = h.HandleCreateEvent(alice, events.APIGatewayProxyRequest{
+		PathParameters: pathParameters(created.CalendarID),
+		Body:           `{` + event + `}`,
+	})
+	if err != nil || res.StatusCode != http.StatusCreated {
+		t.Fatalf("HandleCreateEvent = %d %s, %v", res.StatusCode, res.Body, err)
+	}
+}
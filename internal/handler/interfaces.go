@@ -1,9 +1,14 @@
 package handler
 
 import (
+	"bonded/internal/models"
 	"bonded/internal/repository"
 	"bonded/internal/usecase"
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
 )
@@ -12,24 +17,60 @@ type Handler struct {
 	Repo            repository.CalendarRepository
 	CalendarUsecase usecase.CalendarUsecase
 	EventUsecase    usecase.EventUsecase
+	UserUsecase     usecase.UserUsecase
 }
 
 func HandlerRequest(usecase usecase.Usecase) *Handler {
 	return &Handler{
 		CalendarUsecase: usecase.Calendar(),
 		EventUsecase:    usecase.Event(),
+		UserUsecase:     usecase.User(),
 	}
 }
 
 func (h *Handler) HelloHandler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	greeting := usecase.GetGreeting(ctx, request.RequestContext.Identity.SourceIP)
-	return events.APIGatewayProxyResponse{
-		Body: greeting,
-		Headers: map[string]string{
-			"Access-Control-Allow-Origin":  "*",
-			"Access-Control-Allow-Headers": "Content-Type,Authorization,X-ID-Token",
-			"Access-Control-Allow-Methods": "GET,POST,PUT,DELETE,OPTIONS",
-		},
-		StatusCode: 200,
-	}, nil
+	return newResponse(200, nil, greeting), nil
+}
+
+// etag は版数を ETag の値にする
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseIfMatch は If-Match ヘッダーの ETag から版数を読み取る。ヘッダーがない場合は ok が false になる
+func parseIfMatch(headers map[string]string) (version int64, ok bool, err error) {
+	var value string
+	for name, v := range headers {
+		if strings.EqualFold(name, "If-Match") {
+			value, ok = strings.TrimSpace(v), true
+			break
+		}
+	}
+	if !ok {
+		return 0, false, nil
+	}
+
+	value = strings.TrimPrefix(value, "W/")
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return 0, true, errors.New("If-Match must be a single ETag returned by the API")
+	}
+	version, err = strconv.ParseInt(value[1:len(value)-1], 10, 64)
+	if err != nil || version < 0 {
+		return 0, true, errors.New("If-Match must be a single ETag returned by the API")
+	}
+	return version, true, nil
+}
+
+// parsePageRequest はクエリの cursor と limit を読み取る。limit の省略時は DefaultPageLimit を使う
+func parsePageRequest(query map[string]string) (models.PageRequest, error) {
+	page := models.PageRequest{Cursor: query["cursor"], Limit: models.DefaultPageLimit}
+	if value := query["limit"]; value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > models.MaxPageLimit {
+			return page, fmt.Errorf("limit must be between 1 and %d", models.MaxPageLimit)
+		}
+		page.Limit = limit
+	}
+	return page, nil
 }
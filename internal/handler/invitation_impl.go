@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"bonded/internal/models"
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func (h *Handler) HandleInviteUser(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var requestBody models.InviteUser
+	err := decodeBody(request, &requestBody)
+	if err != nil {
+		return errorResponse(err)
+	}
+	var invitation *models.Invitation
+	if requestBody.Email != "" {
+		invitation, err = h.CalendarUsecase.InviteUserByEmail(ctx, requestBody.CalendarID, requestBody.Email, requestBody.AccessLevel)
+	} else {
+		invitation, err = h.CalendarUsecase.InviteUser(ctx, requestBody.CalendarID, requestBody.InviteUserID, requestBody.AccessLevel)
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(201, invitation, nil)
+}
+
+func (h *Handler) HandleGetInvitations(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	calendarID := request.PathParameters["calendarId"]
+	invitations, err := h.CalendarUsecase.FindInvitations(ctx, calendarID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(200, invitations, nil)
+}
+
+func (h *Handler) HandleRevokeInvitation(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	calendarID := request.PathParameters["calendarId"]
+	userID := request.PathParameters["userId"]
+	err := h.CalendarUsecase.RevokeInvitation(ctx, calendarID, userID)
+	return invitationResponse(err, "Invitation revoked successfully.")
+}
+
+func (h *Handler) HandleGetReceivedInvitations(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	page, err := parsePageRequest(request.QueryStringParameters)
+	if err != nil {
+		return badRequestResponse(err.Error())
+	}
+
+	invitations, err := h.CalendarUsecase.FindReceivedInvitations(ctx, page)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(200, invitations, nil)
+}
+
+func (h *Handler) HandleAcceptInvitation(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	calendarID := request.PathParameters["calendarId"]
+	err := h.CalendarUsecase.AcceptInvitation(ctx, calendarID)
+	return invitationResponse(err, "Invitation accepted successfully.")
+}
+
+func (h *Handler) HandleDeclineInvitation(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	calendarID := request.PathParameters["calendarId"]
+	err := h.CalendarUsecase.DeclineInvitation(ctx, calendarID)
+	return invitationResponse(err, "Invitation declined successfully.")
+}
+
+// invitationResponse は招待の状態を変えた結果を返す
+func invitationResponse(err error, message string) (events.APIGatewayProxyResponse, error) {
+	if err != nil {
+		return errorResponse(err)
+	}
+	return messageResponse(200, message)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"bonded/internal/models"
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func (h *Handler) HandleChangeMemberAccessLevel(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var requestBody models.ChangeAccessLevel
+	err := decodeBody(request, &requestBody)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	calendarID := request.PathParameters["calendarId"]
+	userID := request.PathParameters["userId"]
+	err = h.CalendarUsecase.ChangeMemberAccessLevel(ctx, calendarID, userID, requestBody.AccessLevel)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return messageResponse(200, "Access level changed successfully.")
+}
+
+func (h *Handler) HandleRemoveMember(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	calendarID := request.PathParameters["calendarId"]
+	userID := request.PathParameters["userId"]
+	err := h.CalendarUsecase.RemoveMember(ctx, calendarID, userID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return messageResponse(200, "Member removed successfully.")
+}
+
+func (h *Handler) HandleTransferOwnership(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var requestBody models.TransferOwnership
+	err := decodeBody(request, &requestBody)
+	if err != nil {
+		return errorResponse(err)
+	}
+	version, ok, err := parseIfMatch(request.Headers)
+	if err != nil {
+		return badRequestResponse(err.Error())
+	}
+	if !ok {
+		return preconditionRequiredResponse()
+	}
+
+	calendarID := request.PathParameters["calendarId"]
+	calendar, err := h.CalendarUsecase.TransferOwnership(ctx, calendarID, requestBody.UserID, version)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(200, calendar, etagHeaders(calendar.Version))
+}
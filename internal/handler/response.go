@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"bonded/internal/router"
+	"bonded/internal/usecase"
+	"bonded/internal/validation"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// problem は RFC 7807 の problem details。フィールドごとのエラーを含まない場合は router.ProblemResponse と同じ形になる
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+
+	// Errors は入力値の検証で見つかったフィールドごとのエラー（422 の場合のみ）
+	Errors validation.Errors `json:"errors,omitempty"`
+}
+
+// newResponse は CORS のヘッダーに headers を加えたレスポンスを作る
+func newResponse(statusCode int, headers map[string]string, body string) events.APIGatewayProxyResponse {
+	merged := router.CORSHeaders()
+	for name, value := range headers {
+		merged[name] = value
+	}
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers:    merged,
+		Body:       body,
+	}
+}
+
+// jsonResponse は v を JSON にして返す。headers はレスポンスに追加するヘッダー
+func jsonResponse(statusCode int, v interface{}, headers map[string]string) (events.APIGatewayProxyResponse, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return errorResponse(err)
+	}
+	merged := map[string]string{"Content-Type": "application/json"}
+	for name, value := range headers {
+		merged[name] = value
+	}
+	return newResponse(statusCode, merged, string(body)), nil
+}
+
+// messageResponse は {"message": message} を返す
+func messageResponse(statusCode int, message string) (events.APIGatewayProxyResponse, error) {
+	return jsonResponse(statusCode, map[string]string{"message": message}, nil)
+}
+
+// etagHeaders は版数を ETag として返すためのヘッダー
+func etagHeaders(version int64) map[string]string {
+	return map[string]string{
+		"Access-Control-Expose-Headers": "ETag",
+		"ETag":                          etag(version),
+	}
+}
+
+// problemResponse は problem details の本文でエラーを返す
+func problemResponse(statusCode int, detail string) (events.APIGatewayProxyResponse, error) {
+	return router.ProblemResponse(statusCode, detail, nil), nil
+}
+
+// validationResponse は入力値の検証エラーをフィールドごとに返す
+func validationResponse(fieldErrors validation.Errors) (events.APIGatewayProxyResponse, error) {
+	return writeProblem(problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusUnprocessableEntity),
+		Status: http.StatusUnprocessableEntity,
+		Detail: "one or more fields are invalid",
+		Errors: fieldErrors,
+	})
+}
+
+func writeProblem(p problem) (events.APIGatewayProxyResponse, error) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return newResponse(p.Status, map[string]string{"Content-Type": "application/problem+json"}, string(body)), nil
+}
+
+// errorResponse はユースケースのエラーを対応するステータスに変換する。
+// 想定していないエラーはログに残し、内部の詳細を返さずに 500 にする
+func errorResponse(err error) (events.APIGatewayProxyResponse, error) {
+	var fieldErrors validation.Errors
+	var validationError *usecase.ValidationError
+	switch {
+	case errors.As(err, &fieldErrors):
+		return validationResponse(fieldErrors)
+	case errors.Is(err, validation.ErrMalformed):
+		return badRequestResponse(err.Error())
+	case errors.As(err, &validationError) && validationError.Field != "":
+		return validationResponse(validation.Errors{{Field: validationError.Field, Message: validationError.Detail}})
+	case errors.Is(err, usecase.ErrNotFound):
+		return problemResponse(http.StatusNotFound, err.Error())
+	case errors.Is(err, usecase.ErrForbidden):
+		return problemResponse(http.StatusForbidden, err.Error())
+	case errors.Is(err, usecase.ErrConflict):
+		return problemResponse(http.StatusConflict, err.Error())
+	case errors.Is(err, usecase.ErrInvalidInput):
+		return problemResponse(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, usecase.ErrPreconditionFailed):
+		return problemResponse(http.StatusPreconditionFailed, err.Error())
+	}
+	log.Printf("internal error: %v", err)
+	return problemResponse(http.StatusInternalServerError, "")
+}
+
+// badRequestResponse は本文やクエリを読み取れない場合に返す
+func badRequestResponse(message string) (events.APIGatewayProxyResponse, error) {
+	return problemResponse(http.StatusBadRequest, message)
+}
+
+func preconditionRequiredResponse() (events.APIGatewayProxyResponse, error) {
+	return problemResponse(http.StatusPreconditionRequired, "send the ETag of the resource in the If-Match header")
+}
+
+// decodeBody はリクエストの本文をモデルに読み込んで検証する。エラーは errorResponse でそのまま返せる
+func decodeBody(request events.APIGatewayProxyRequest, v validation.Validator) error {
+	return validation.Decode([]byte(request.Body), v)
+}
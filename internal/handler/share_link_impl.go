@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"bonded/internal/models"
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func (h *Handler) HandleCreateShareLink(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var requestBody models.CreateShareLink
+	err := decodeBody(request, &requestBody)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	calendarID := request.PathParameters["calendarId"]
+	link, err := h.CalendarUsecase.CreateShareLink(ctx, calendarID, requestBody.AccessLevel, requestBody.MaxUses, requestBody.ValidDays)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(201, link, nil)
+}
+
+func (h *Handler) HandleGetShareLinks(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	calendarID := request.PathParameters["calendarId"]
+	links, err := h.CalendarUsecase.FindShareLinks(ctx, calendarID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(200, links, nil)
+}
+
+func (h *Handler) HandleRevokeShareLink(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	calendarID := request.PathParameters["calendarId"]
+	linkID := request.PathParameters["linkId"]
+	err := h.CalendarUsecase.RevokeShareLink(ctx, calendarID, linkID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return messageResponse(200, "Share link revoked successfully.")
+}
+
+func (h *Handler) HandleRedeemShareLink(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var requestBody models.RedeemShareLink
+	err := decodeBody(request, &requestBody)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	calendarID := request.PathParameters["calendarId"]
+	link, err := h.CalendarUsecase.RedeemShareLink(ctx, calendarID, requestBody.Token)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(200, map[string]string{
+		"calendarId":  link.CalendarID,
+		"accessLevel": link.AccessLevel,
+	}, nil)
+}
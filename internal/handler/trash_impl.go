@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func (h *Handler) HandleGetTrashedCalendars(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	page, err := parsePageRequest(request.QueryStringParameters)
+	if err != nil {
+		return badRequestResponse(err.Error())
+	}
+
+	calendars, err := h.CalendarUsecase.FindTrashedCalendars(ctx, page)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(200, calendars, nil)
+}
+
+func (h *Handler) HandleRestoreCalendar(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	calendarID := request.PathParameters["calendarId"]
+	err := h.CalendarUsecase.RestoreCalendar(ctx, calendarID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return messageResponse(200, "Calendar restored successfully.")
+}
+
+func (h *Handler) HandlePurgeCalendar(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	calendarID := request.PathParameters["calendarId"]
+	report, err := h.CalendarUsecase.PurgeCalendar(ctx, calendarID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(200, report, nil)
+}
+
+func (h *Handler) HandleGetTrashedEvents(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	calendarID := request.PathParameters["calendarId"]
+	page, err := parsePageRequest(request.QueryStringParameters)
+	if err != nil {
+		return badRequestResponse(err.Error())
+	}
+
+	eventList, err := h.EventUsecase.FindTrashedEvents(ctx, calendarID, page)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(200, eventList, nil)
+}
+
+func (h *Handler) HandleRestoreEvent(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	calendarID := request.PathParameters["calendarId"]
+	eventID := request.PathParameters["eventId"]
+	err := h.EventUsecase.RestoreEvent(ctx, calendarID, eventID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return messageResponse(200, "Event restored successfully.")
+}
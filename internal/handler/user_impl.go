@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/usecase"
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func (h *Handler) HandleGetMe(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	profile, err := h.UserUsecase.FindMe(ctx)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(200, profile, nil)
+}
+
+func (h *Handler) HandleEditMe(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var input models.EditProfile
+	err := decodeBody(request, &input)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	profile, err := h.UserUsecase.EditMe(ctx, &input)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(200, profile, nil)
+}
+
+// HandleSearchUsers は招待するユーザーをメールアドレスの完全一致（email）か表示名の前方一致（name）で検索する
+func (h *Handler) HandleSearchUsers(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	email := request.QueryStringParameters["email"]
+	name := request.QueryStringParameters["name"]
+	if (email == "") == (name == "") {
+		return badRequestResponse("Specify either email or name")
+	}
+	page, err := parsePageRequest(request.QueryStringParameters)
+	if err != nil {
+		return badRequestResponse(err.Error())
+	}
+
+	var users *models.Page[*models.UserSummary]
+	if email != "" {
+		users = &models.Page[*models.UserSummary]{Items: []*models.UserSummary{}}
+		var user *models.UserSummary
+		user, err = h.UserUsecase.FindUserByEmail(ctx, email)
+		if err == nil {
+			users.Items = append(users.Items, user)
+		}
+		if errors.Is(err, usecase.ErrNotFound) {
+			err = nil
+		}
+	} else {
+		users, err = h.UserUsecase.SearchUsers(ctx, name, page)
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+	return jsonResponse(200, users, nil)
+}
@@ -0,0 +1,432 @@
+package ical
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/recurrence"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry は取り込んだ VEVENT 1件分の結果。解析に失敗した場合は Err が設定される
+type Entry struct {
+	UID   string
+	Event *models.Event
+	Err   error
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+type component struct {
+	name       string
+	properties []property
+}
+
+// Decode は VCALENDAR から VEVENT を読み取る。TZID のない日時は defaultLocation として扱う
+func Decode(r io.Reader, defaultLocation *time.Location) ([]*Entry, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		stack   []string
+		current *component
+		entries []*Entry
+		found   bool
+	)
+	for _, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			if current != nil {
+				current.properties = append(current.properties, property{name: "X-INVALID", value: err.Error()})
+			}
+			continue
+		}
+
+		switch prop.name {
+		case "BEGIN":
+			name := strings.ToUpper(prop.value)
+			stack = append(stack, name)
+			if name == "VCALENDAR" {
+				found = true
+			}
+			if name == "VEVENT" && len(stack) >= 2 && stack[len(stack)-2] == "VCALENDAR" {
+				current = &component{name: name}
+			}
+			continue
+		case "END":
+			name := strings.ToUpper(prop.value)
+			if len(stack) == 0 || stack[len(stack)-1] != name {
+				return nil, fmt.Errorf("unexpected END:%s", prop.value)
+			}
+			stack = stack[:len(stack)-1]
+			if name == "VEVENT" && current != nil {
+				entries = append(entries, decodeEvent(current, defaultLocation))
+				current = nil
+			}
+			continue
+		}
+
+		// カレンダー全体の既定のタイムゾーン（Google カレンダーなどが出力する）
+		if current == nil && len(stack) == 1 && prop.name == "X-WR-TIMEZONE" {
+			if loc, err := loadLocation(prop.value); err == nil {
+				defaultLocation = loc
+			}
+			continue
+		}
+
+		// VALARM など VEVENT 内の入れ子のコンポーネントは読み飛ばす
+		if current != nil && stack[len(stack)-1] == "VEVENT" {
+			current.properties = append(current.properties, prop)
+		}
+	}
+
+	if !found {
+		return nil, errors.New("VCALENDAR not found")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("missing END:%s", stack[len(stack)-1])
+	}
+	return entries, nil
+}
+
+// unfold は折り返された行を結合する
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseLine は "NAME;PARAM=VALUE:value" 形式のコンテンツ行を解析する
+func parseLine(line string) (property, error) {
+	prop := property{params: map[string]string{}}
+
+	inQuote := false
+	nameEnd, valueStart := -1, -1
+	for i, c := range line {
+		switch {
+		case c == '"':
+			inQuote = !inQuote
+		case c == ';' && !inQuote && nameEnd < 0:
+			nameEnd = i
+		case c == ':' && !inQuote:
+			valueStart = i
+		}
+		if valueStart >= 0 {
+			break
+		}
+	}
+	if valueStart < 0 {
+		return prop, fmt.Errorf("invalid content line %q", line)
+	}
+	if nameEnd < 0 {
+		nameEnd = valueStart
+	}
+
+	prop.name = strings.ToUpper(line[:nameEnd])
+	prop.value = line[valueStart+1:]
+	if nameEnd < valueStart {
+		for _, param := range splitParams(line[nameEnd+1 : valueStart]) {
+			key, val, _ := strings.Cut(param, "=")
+			prop.params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+		}
+	}
+	return prop, nil
+}
+
+func splitParams(s string) []string {
+	var params []string
+	inQuote := false
+	start := 0
+	for i, c := range s {
+		switch {
+		case c == '"':
+			inQuote = !inQuote
+		case c == ';' && !inQuote:
+			params = append(params, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(params, s[start:])
+}
+
+// minimumDuration は長さのない VEVENT（DTEND も DURATION もない、または DTSTART と同じ）に与える長さ
+const minimumDuration = time.Minute
+
+func decodeEvent(c *component, defaultLocation *time.Location) *Entry {
+	entry := &Entry{}
+	event := &models.Event{}
+
+	var (
+		start, end   time.Time
+		startIsDate  bool
+		hasEnd       bool
+		duration     time.Duration
+		hasDuration  bool
+		recurrenceID string
+		timeZone     string
+		err          error
+	)
+	for _, prop := range c.properties {
+		switch prop.name {
+		case "X-INVALID":
+			err = errors.New(prop.value)
+		case "UID":
+			entry.UID = prop.value
+		case "SUMMARY":
+			event.Title = unescapeText(prop.value)
+		case "DESCRIPTION":
+			event.Description = unescapeText(prop.value)
+		case "LOCATION":
+			event.Location = unescapeText(prop.value)
+		case "DTSTART":
+			start, startIsDate, err = parseDateTime(prop, defaultLocation)
+			timeZone = defaultLocation.String()
+			if tzid, ok := prop.params["TZID"]; ok && err == nil {
+				var loc *time.Location
+				loc, err = loadLocation(tzid)
+				if err == nil {
+					timeZone = loc.String()
+				}
+			}
+		case "DTEND":
+			end, _, err = parseDateTime(prop, defaultLocation)
+			hasEnd = true
+		case "DURATION":
+			duration, err = parseDuration(prop.value)
+			hasDuration = true
+		case "RRULE":
+			_, err = recurrence.Parse(prop.value)
+			event.RRule = prop.value
+		case "RDATE", "EXDATE":
+			var values []string
+			values, err = parseDateTimeList(prop, defaultLocation)
+			if prop.name == "RDATE" {
+				event.RDates = append(event.RDates, values...)
+			} else {
+				event.ExDates = append(event.ExDates, values...)
+			}
+		case "RECURRENCE-ID":
+			var t time.Time
+			var isDate bool
+			t, isDate, err = parseDateTime(prop, defaultLocation)
+			recurrenceID = formatTime(t, isDate)
+		}
+		if err != nil {
+			entry.Err = fmt.Errorf("%s: %w", prop.name, err)
+			return entry
+		}
+	}
+
+	if entry.UID == "" {
+		entry.Err = errors.New("UID is required")
+		return entry
+	}
+	if start.IsZero() {
+		entry.Err = errors.New("DTSTART is required")
+		return entry
+	}
+
+	switch {
+	case hasEnd:
+	case hasDuration:
+		end = start.Add(duration)
+	case startIsDate:
+		end = start.AddDate(0, 0, 1)
+	}
+	switch {
+	case end.IsZero(), end.Equal(start):
+		// RFC 5545 では終了のない日時のイベントは開始と同時に終わるが、
+		// カレンダーのイベントは長さを持つ必要があるため最小の長さを与える
+		if startIsDate {
+			end = start.AddDate(0, 0, 1)
+		} else {
+			end = start.Add(minimumDuration)
+		}
+	case end.Before(start):
+		entry.Err = errors.New("DTEND must not be before DTSTART")
+		return entry
+	}
+
+	event.UID = entry.UID
+	event.AllDay = startIsDate
+	event.StartTime = dateTime(start, startIsDate)
+	event.EndTime = dateTime(end, startIsDate)
+	if timeZone != "" && timeZone != "Local" {
+		event.TimeZone = timeZone
+	}
+	event.RecurrenceID = recurrenceID
+	entry.Event = event
+	return entry
+}
+
+// windowsZones は Outlook などが TZID に使う Windows のタイムゾーン名と IANA 名の対応
+var windowsZones = map[string]string{
+	"Tokyo Standard Time":            "Asia/Tokyo",
+	"Korea Standard Time":            "Asia/Seoul",
+	"China Standard Time":            "Asia/Shanghai",
+	"UTC":                            "UTC",
+	"GMT Standard Time":              "Europe/London",
+	"W. Europe Standard Time":        "Europe/Berlin",
+	"Romance Standard Time":          "Europe/Paris",
+	"Eastern Standard Time":          "America/New_York",
+	"Central Standard Time":          "America/Chicago",
+	"Mountain Standard Time":         "America/Denver",
+	"Pacific Standard Time":          "America/Los_Angeles",
+	"AUS Eastern Standard Time":      "Australia/Sydney",
+	"Singapore Standard Time":        "Asia/Singapore",
+	"India Standard Time":            "Asia/Kolkata",
+	"Taipei Standard Time":           "Asia/Taipei",
+	"Hawaiian Standard Time":         "Pacific/Honolulu",
+	"Alaskan Standard Time":          "America/Anchorage",
+	"E. South America Standard Time": "America/Sao_Paulo",
+}
+
+func loadLocation(tzid string) (*time.Location, error) {
+	if name, ok := windowsZones[tzid]; ok {
+		tzid = name
+	}
+	loc, err := time.LoadLocation(strings.TrimPrefix(tzid, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", tzid)
+	}
+	return loc, nil
+}
+
+// parseDateTime は DATE / DATE-TIME（UTC・TZID 付き・floating）を解析する
+func parseDateTime(prop property, defaultLocation *time.Location) (time.Time, bool, error) {
+	values, isDate, err := parseDateTimes(prop, defaultLocation)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if len(values) != 1 {
+		return time.Time{}, false, errors.New("expected a single value")
+	}
+	return values[0], isDate, nil
+}
+
+func parseDateTimes(prop property, defaultLocation *time.Location) ([]time.Time, bool, error) {
+	loc := defaultLocation
+	if tzid, ok := prop.params["TZID"]; ok {
+		var err error
+		loc, err = loadLocation(tzid)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	isDate := strings.EqualFold(prop.params["VALUE"], "DATE")
+	if v := strings.ToUpper(prop.params["VALUE"]); v != "" && v != "DATE" && v != "DATE-TIME" {
+		return nil, false, fmt.Errorf("unsupported VALUE=%s", v)
+	}
+
+	var times []time.Time
+	for _, value := range strings.Split(prop.value, ",") {
+		value = strings.TrimSpace(value)
+		var (
+			t   time.Time
+			err error
+		)
+		switch {
+		case isDate || len(value) == len(dateFormat):
+			isDate = true
+			t, err = time.Parse(dateFormat, value)
+		case strings.HasSuffix(value, "Z"):
+			t, err = time.Parse(dateTimeFormat, value)
+		default:
+			t, err = time.ParseInLocation("20060102T150405", value, loc)
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid date-time %q", value)
+		}
+		times = append(times, t)
+	}
+	return times, isDate, nil
+}
+
+func parseDateTimeList(prop property, defaultLocation *time.Location) ([]string, error) {
+	times, isDate, err := parseDateTimes(prop, defaultLocation)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, 0, len(times))
+	for _, t := range times {
+		values = append(values, formatTime(t, isDate))
+	}
+	return values, nil
+}
+
+func dateTime(t time.Time, isDate bool) models.DateTime {
+	if isDate {
+		return models.DateTime{Time: t, DateOnly: true}
+	}
+	return models.DateTime{Time: t}
+}
+
+func formatTime(t time.Time, isDate bool) string {
+	if isDate {
+		return t.Format("2006-01-02")
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration は RFC 5545 の DURATION（例: PT1H30M, P1D, P2W）を解析する
+func parseDuration(value string) (time.Duration, error) {
+	m := durationPattern.FindStringSubmatch(value)
+	if m == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+2])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		d += time.Duration(n) * unit
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+var textUnescaper = strings.NewReplacer(
+	`\\`, `\`,
+	`\;`, `;`,
+	`\,`, `,`,
+	`\n`, "\n",
+	`\N`, "\n",
+)
+
+func unescapeText(value string) string {
+	return textUnescaper.Replace(value)
+}
@@ -0,0 +1,72 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeOne(t *testing.T, vevent string) *Entry {
+	t.Helper()
+	body := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nUID:event-1\r\nSUMMARY:Meeting\r\n" + vevent + "END:VEVENT\r\nEND:VCALENDAR\r\n"
+	entries, err := Decode(strings.NewReader(body), time.UTC)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(entries))
+	}
+	return entries[0]
+}
+
+func TestDecodeEventEnd(t *testing.T) {
+	start := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		vevent  string
+		allDay  bool
+		end     time.Time
+		wantErr bool
+	}{
+		{"DTEND", "DTSTART:20240401T100000Z\r\nDTEND:20240401T113000Z\r\n", false, start.Add(90 * time.Minute), false},
+		{"DURATION", "DTSTART:20240401T100000Z\r\nDURATION:PT2H\r\n", false, start.Add(2 * time.Hour), false},
+		{"timed without end", "DTSTART:20240401T100000Z\r\n", false, start.Add(minimumDuration), false},
+		{"DTEND equal to DTSTART", "DTSTART:20240401T100000Z\r\nDTEND:20240401T100000Z\r\n", false, start.Add(minimumDuration), false},
+		{"zero DURATION", "DTSTART:20240401T100000Z\r\nDURATION:PT0S\r\n", false, start.Add(minimumDuration), false},
+		{"date without end", "DTSTART;VALUE=DATE:20240401\r\n", true, time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC), false},
+		{"DTEND before DTSTART", "DTSTART:20240401T100000Z\r\nDTEND:20240401T090000Z\r\n", false, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := decodeOne(t, tt.vevent)
+			if tt.wantErr {
+				if entry.Err == nil {
+					t.Fatalf("event = %+v, want an error", entry.Event)
+				}
+				return
+			}
+			if entry.Err != nil {
+				t.Fatalf("Err = %v", entry.Err)
+			}
+			event := entry.Event
+			if event.AllDay != tt.allDay || !event.EndTime.Equal(tt.end) {
+				t.Errorf("allDay = %v, end = %s, want %v, %s", event.AllDay, event.EndTime.Time, tt.allDay, tt.end)
+			}
+			if !event.EndTime.After(event.StartTime.Time) {
+				t.Errorf("end %s is not after start %s", event.EndTime.Time, event.StartTime.Time)
+			}
+		})
+	}
+}
+
+func TestDecodeRequiresUIDAndStart(t *testing.T) {
+	body := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART:20240401T100000Z\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:no-start\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	entries, err := Decode(strings.NewReader(body), time.UTC)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Err == nil || entries[1].Err == nil {
+		t.Fatalf("entries = %+v, want two errors", entries)
+	}
+}
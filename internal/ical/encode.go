@@ -0,0 +1,283 @@
+package ical
+
+import (
+	"bonded/internal/models"
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	productID       = "-//Teamsasa//ShareCalendar//JA"
+	uidDomain       = "sharecalendar"
+	dateFormat      = "20060102"
+	dateTimeFormat  = "20060102T150405Z"
+	localTimeFormat = "20060102T150405"
+	maxLineOctets   = 75
+)
+
+// Encode はカレンダーとそのイベントを RFC 5545 の VCALENDAR として書き出す
+func Encode(w io.Writer, calendar *models.Calendar, now time.Time) error {
+	e := &encoder{w: bufio.NewWriter(w)}
+
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", productID)
+	e.line("CALSCALE", "GREGORIAN")
+	e.line("METHOD", "PUBLISH")
+	e.line("X-WR-CALNAME", escapeText(calendar.Name))
+	if calendar.TimeZone != "" {
+		e.line("X-WR-TIMEZONE", calendar.TimeZone)
+	}
+
+	// 個別の発生は繰り返し元と同じ UID・タイムゾーン・参加者で出力する
+	events := flattenOverrides(calendar.Events)
+	masters := make(map[string]*models.Event, len(events))
+	for i := range events {
+		event := &events[i]
+		if event.RecurringEventID == "" {
+			masters[event.EventID] = event
+		}
+	}
+
+	// TZID で参照するタイムゾーンの定義を VEVENT より前に書き出す
+	for _, zone := range usedZones(events) {
+		e.timeZone(zone.loc, zone.year)
+	}
+
+	dtstamp := now.UTC().Format(dateTimeFormat)
+	for i := range events {
+		err := e.event(&events[i], masters, dtstamp)
+		if err != nil {
+			return err
+		}
+	}
+
+	e.line("END", "VCALENDAR")
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// flattenOverrides は繰り返し元の Overrides にまとめた個別の発生を、繰り返し元のすぐ後ろの VEVENT として並べる
+func flattenOverrides(events []models.Event) []models.Event {
+	flat := make([]models.Event, 0, len(events))
+	for _, event := range events {
+		overrides := event.Overrides
+		event.Overrides = nil
+		flat = append(flat, event)
+		flat = append(flat, overrides...)
+	}
+	return flat
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *encoder) event(event *models.Event, masters map[string]*models.Event, dtstamp string) error {
+	if event.StartTime.IsZero() || event.EndTime.IsZero() {
+		return fmt.Errorf("event %s: startTime and endTime are required", event.EventID)
+	}
+
+	eventUID := uid(event)
+	attendees := event.Attendees
+	master, hasMaster := masters[event.RecurringEventID]
+	if event.RecurringEventID != "" {
+		if hasMaster {
+			eventUID = uid(master)
+			attendees = master.Attendees
+		} else {
+			eventUID = event.RecurringEventID + "@" + uidDomain
+		}
+	}
+
+	loc := location(event)
+	e.line("BEGIN", "VEVENT")
+	e.line("UID", eventUID)
+	e.line("DTSTAMP", dtstamp)
+	if event.AllDay {
+		start := event.StartTime.At(zoneOf(event))
+		end := event.EndTime.At(zoneOf(event))
+		e.line("DTSTART;VALUE=DATE", start.Format(dateFormat))
+		e.line("DTEND;VALUE=DATE", allDayEnd(start, end).Format(dateFormat))
+	} else {
+		e.timeProperty("DTSTART", event.StartTime.Time, false, loc)
+		e.timeProperty("DTEND", event.EndTime.Time, false, loc)
+	}
+	if event.RecurrenceID != "" {
+		recurrenceID, err := parseTime(event.RecurrenceID)
+		if err != nil {
+			return fmt.Errorf("event %s: %w", event.EventID, err)
+		}
+		// RECURRENCE-ID は繰り返し元の DTSTART と同じ形式にする
+		masterLoc := loc
+		if hasMaster {
+			masterLoc = location(master)
+		}
+		e.timeProperty("RECURRENCE-ID", recurrenceID, event.AllDay, masterLoc)
+	}
+	e.line("SUMMARY", escapeText(event.Title))
+	if event.Description != "" {
+		e.line("DESCRIPTION", escapeText(event.Description))
+	}
+	if event.Location != "" {
+		e.line("LOCATION", escapeText(event.Location))
+	}
+	for _, attendee := range attendees {
+		e.attendee(&attendee)
+	}
+	if event.RRule != "" {
+		e.line("RRULE", event.RRule)
+	}
+	for _, value := range event.RDates {
+		t, err := parseTime(value)
+		if err != nil {
+			return fmt.Errorf("event %s: %w", event.EventID, err)
+		}
+		e.timeProperty("RDATE", t, event.AllDay, loc)
+	}
+	for _, value := range event.ExDates {
+		t, err := parseTime(value)
+		if err != nil {
+			return fmt.Errorf("event %s: %w", event.EventID, err)
+		}
+		e.timeProperty("EXDATE", t, event.AllDay, loc)
+	}
+	e.line("END", "VEVENT")
+	return nil
+}
+
+// attendee は参加者を ATTENDEE として書き出す。メールアドレスのないメンバーはユーザーIDの URN で表す
+func (e *encoder) attendee(attendee *models.Attendee) {
+	name := "ATTENDEE"
+	if attendee.DisplayName != "" {
+		name += ";CN=" + paramValue(attendee.DisplayName)
+	}
+	status := attendee.Status
+	if status == "" {
+		status = models.AttendeeStatusNeedsAction
+	}
+	name += ";PARTSTAT=" + status
+
+	address := "mailto:" + attendee.Email
+	if attendee.Email == "" {
+		address = "urn:" + uidDomain + ":user:" + attendee.UserID
+	}
+	e.line(name, address)
+}
+
+// uid はインポート元の UID があればそれを、なければイベントIDから UID を組み立てる
+func uid(event *models.Event) string {
+	if event.UID != "" {
+		return event.UID
+	}
+	return event.EventID + "@" + uidDomain
+}
+
+// timeProperty は日時のプロパティを書き出す。loc が nil なら UTC、それ以外は TZID 付きのローカル時刻で表す
+func (e *encoder) timeProperty(name string, t time.Time, allDay bool, loc *time.Location) {
+	if allDay {
+		e.line(name+";VALUE=DATE", t.Format(dateFormat))
+		return
+	}
+	if loc == nil {
+		e.line(name, t.UTC().Format(dateTimeFormat))
+		return
+	}
+	e.line(name+";TZID="+loc.String(), t.In(loc).Format(localTimeFormat))
+}
+
+// line はコンテンツ行を 75 オクテットで折り返して CRLF 区切りで書き出す
+func (e *encoder) line(name string, value string) {
+	if e.err != nil {
+		return
+	}
+	content := name + ":" + value
+	limit := maxLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		_, e.err = e.w.WriteString(content[:cut] + "\r\n ")
+		if e.err != nil {
+			return
+		}
+		content = content[cut:]
+		// 継続行は先頭の空白を含めて 75 オクテットに収める
+		limit = maxLineOctets - 1
+	}
+	_, e.err = e.w.WriteString(content + "\r\n")
+}
+
+// allDayEnd は終日イベントの DTEND（翌日を指す排他的な日付）を求める
+func allDayEnd(start, end time.Time) time.Time {
+	endDate := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	if hour, min, sec := end.Clock(); hour != 0 || min != 0 || sec != 0 {
+		endDate = endDate.AddDate(0, 0, 1)
+	}
+	startDate := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	if !endDate.After(startDate) {
+		endDate = startDate.AddDate(0, 0, 1)
+	}
+	return endDate
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`;`, `\;`,
+	`,`, `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+func escapeText(value string) string {
+	return textEscaper.Replace(value)
+}
+
+// paramValue はプロパティのパラメータ値を書き出せる形にする。
+// パラメータ値には DQUOTE を含められないため取り除き、区切り文字を含む場合は DQUOTE で囲む
+func paramValue(value string) string {
+	value = strings.NewReplacer(`"`, "", "\r", "", "\n", " ").Replace(value)
+	if strings.ContainsAny(value, ";:,") {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+// zoneOf はイベントのタイムゾーンを返す。未設定や解決できない場合は DefaultTimeZone とする
+func zoneOf(event *models.Event) *time.Location {
+	loc, err := models.LoadTimeZone(event.TimeZone)
+	if err != nil {
+		loc, _ = models.LoadTimeZone("")
+	}
+	return loc
+}
+
+// location は時刻付きのイベントを TZID 付きで出力する場合のタイムゾーンを返す。UTC で出力する場合は nil
+func location(event *models.Event) *time.Location {
+	if event.AllDay || event.TimeZone == "" {
+		return nil
+	}
+	loc, err := models.LoadTimeZone(event.TimeZone)
+	if err != nil || loc.String() == "UTC" {
+		return nil
+	}
+	return loc
+}
+
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
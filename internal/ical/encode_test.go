@@ -0,0 +1,159 @@
+package ical
+
+import (
+	"bonded/internal/models"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func encode(t *testing.T, calendar *models.Calendar) string {
+	t.Helper()
+	var buf bytes.Buffer
+	err := Encode(&buf, calendar, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	return buf.String()
+}
+
+func assertLines(t *testing.T, body string, want ...string) {
+	t.Helper()
+	lines, err := unfold(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("unfold: %v", err)
+	}
+	for _, line := range want {
+		found := false
+		for _, got := range lines {
+			if got == line {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("missing line %q in\n%s", line, body)
+		}
+	}
+}
+
+func TestEncodeEvents(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	start := time.Date(2024, 4, 1, 10, 0, 0, 0, tokyo)
+	calendar := &models.Calendar{
+		Name:     "Team; Tokyo, Japan",
+		TimeZone: "Asia/Tokyo",
+		Events: []models.Event{
+			{
+				EventID:   "standup",
+				Title:     "Standup",
+				StartTime: models.DateTime{Time: start},
+				EndTime:   models.DateTime{Time: start.Add(15 * time.Minute)},
+				TimeZone:  "Asia/Tokyo",
+				RRule:     "FREQ=DAILY;COUNT=5",
+				ExDates:   []string{"2024-04-03T01:00:00Z"},
+				Attendees: []models.Attendee{
+					{UserID: "bob", DisplayName: "Bob, Jr.", Status: models.AttendeeStatusAccepted},
+					{Email: "carol@example.com"},
+				},
+				Overrides: []models.Event{{
+					EventID:          "standup_20240402T010000Z",
+					Title:            "Standup (moved)",
+					StartTime:        models.DateTime{Time: start.Add(24*time.Hour + time.Hour)},
+					EndTime:          models.DateTime{Time: start.Add(24*time.Hour + time.Hour + 15*time.Minute)},
+					TimeZone:         "Asia/Tokyo",
+					RecurringEventID: "standup",
+					RecurrenceID:     "2024-04-02T01:00:00Z",
+				}},
+			},
+			{
+				EventID:     "holiday",
+				UID:         "holiday@example.com",
+				Title:       "Holiday",
+				Description: "line one\nline two",
+				StartTime:   models.DateTime{Time: time.Date(2024, 4, 29, 0, 0, 0, 0, tokyo)},
+				EndTime:     models.DateTime{Time: time.Date(2024, 4, 30, 0, 0, 0, 0, tokyo)},
+				TimeZone:    "Asia/Tokyo",
+				AllDay:      true,
+			},
+		},
+	}
+
+	body := encode(t, calendar)
+	assertLines(t, body,
+		`X-WR-CALNAME:Team\; Tokyo\, Japan`,
+		"X-WR-TIMEZONE:Asia/Tokyo",
+		"BEGIN:VTIMEZONE",
+		"TZID:Asia/Tokyo",
+		"DTSTAMP:20240501T000000Z",
+		"UID:standup@sharecalendar",
+		"DTSTART;TZID=Asia/Tokyo:20240401T100000",
+		"DTEND;TZID=Asia/Tokyo:20240401T101500",
+		"RRULE:FREQ=DAILY;COUNT=5",
+		"EXDATE;TZID=Asia/Tokyo:20240403T100000",
+		`ATTENDEE;CN="Bob, Jr.";PARTSTAT=ACCEPTED:urn:sharecalendar:user:bob`,
+		"ATTENDEE;PARTSTAT=NEEDS-ACTION:mailto:carol@example.com",
+		"RECURRENCE-ID;TZID=Asia/Tokyo:20240402T100000",
+		"SUMMARY:Standup (moved)",
+		"UID:holiday@example.com",
+		"DTSTART;VALUE=DATE:20240429",
+		"DTEND;VALUE=DATE:20240430",
+		`DESCRIPTION:line one\nline two`,
+	)
+	// 個別の発生は繰り返し元と同じ UID で出力する
+	if got := strings.Count(body, "UID:standup@sharecalendar\r\n"); got != 2 {
+		t.Errorf("standup UID appears %d times, want 2", got)
+	}
+	if strings.Index(body, "BEGIN:VTIMEZONE") > strings.Index(body, "BEGIN:VEVENT") {
+		t.Error("VTIMEZONE is written after VEVENT")
+	}
+}
+
+func TestEncodeFoldsLongLines(t *testing.T) {
+	start := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+	title := strings.Repeat("会議", 40)
+	body := encode(t, &models.Calendar{Name: "Team", Events: []models.Event{{
+		EventID:   "long",
+		Title:     title,
+		StartTime: models.DateTime{Time: start},
+		EndTime:   models.DateTime{Time: start.Add(time.Hour)},
+	}}})
+
+	for _, line := range strings.Split(body, "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line is %d octets: %q", len(line), line)
+		}
+	}
+	assertLines(t, body, "SUMMARY:"+title, "DTSTART:20240401T100000Z")
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	start := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+	event := models.Event{
+		EventID:     "weekly",
+		Title:       "Review, weekly",
+		Description: "Bring notes; slides",
+		Location:    "Room 1",
+		StartTime:   models.DateTime{Time: start},
+		EndTime:     models.DateTime{Time: start.Add(time.Hour)},
+		RRule:       "FREQ=WEEKLY;BYDAY=MO",
+		ExDates:     []string{"2024-04-08T10:00:00Z"},
+	}
+	body := encode(t, &models.Calendar{Name: "Team", Events: []models.Event{event}})
+
+	entries, err := Decode(strings.NewReader(body), time.UTC)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Err != nil {
+		t.Fatalf("entries = %+v", entries)
+	}
+	got := entries[0].Event
+	if entries[0].UID != "weekly@sharecalendar" || got.Title != event.Title || got.Description != event.Description || got.Location != event.Location {
+		t.Errorf("event = %+v", got)
+	}
+	if !got.StartTime.Equal(start) || !got.EndTime.Equal(start.Add(time.Hour)) || got.RRule != event.RRule || len(got.ExDates) != 1 {
+		t.Errorf("event = %+v", got)
+	}
+}
@@ -0,0 +1,113 @@
+package ical
+
+import (
+	"bonded/internal/models"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var weekdays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+type zoneUsage struct {
+	loc  *time.Location
+	year int
+}
+
+// usedZones は TZID で参照されるタイムゾーンと、VTIMEZONE の基準にする年を返す。
+// 年の初めのイベントも定義の範囲に含めるため、最も古いイベントの前年を基準にする
+func usedZones(events []models.Event) []zoneUsage {
+	byName := map[string]*zoneUsage{}
+	for i := range events {
+		loc := location(&events[i])
+		if loc == nil {
+			continue
+		}
+		year := events[i].StartTime.In(loc).Year() - 1
+		if zone, ok := byName[loc.String()]; ok {
+			if year < zone.year {
+				zone.year = year
+			}
+			continue
+		}
+		byName[loc.String()] = &zoneUsage{loc: loc, year: year}
+	}
+
+	zones := make([]zoneUsage, 0, len(byName))
+	for _, zone := range byName {
+		zones = append(zones, *zone)
+	}
+	sort.Slice(zones, func(i, j int) bool {
+		return zones[i].loc.String() < zones[j].loc.String()
+	})
+	return zones
+}
+
+// timeZone は VTIMEZONE を書き出す。基準年の切り替わりを毎年繰り返す規則として表す
+func (e *encoder) timeZone(loc *time.Location, year int) {
+	e.line("BEGIN", "VTIMEZONE")
+	e.line("TZID", loc.String())
+
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	transitions := 0
+	for t := start; ; {
+		_, end := t.ZoneBounds()
+		if end.IsZero() || end.Year() > year {
+			break
+		}
+		e.observance(end)
+		transitions++
+		t = end
+	}
+
+	// 夏時間のないタイムゾーンは基準年のオフセットだけを定義する
+	if transitions == 0 {
+		name, offset := start.Zone()
+		e.line("BEGIN", "STANDARD")
+		e.line("DTSTART", "19700101T000000")
+		e.line("TZOFFSETFROM", formatOffset(offset))
+		e.line("TZOFFSETTO", formatOffset(offset))
+		e.line("TZNAME", name)
+		e.line("END", "STANDARD")
+	}
+
+	e.line("END", "VTIMEZONE")
+}
+
+// observance は transition で始まる標準時・夏時間を書き出す
+func (e *encoder) observance(transition time.Time) {
+	_, fromOffset := transition.Add(-time.Second).Zone()
+	name, toOffset := transition.Zone()
+	kind := "STANDARD"
+	if transition.IsDST() {
+		kind = "DAYLIGHT"
+	}
+
+	// DTSTART は切り替わる直前のオフセットでの壁時計で表す
+	local := transition.In(time.FixedZone("", fromOffset))
+	ordinal := (local.Day()-1)/7 + 1
+	if local.Day()+7 > daysIn(local.Year(), local.Month()) {
+		ordinal = -1
+	}
+
+	e.line("BEGIN", kind)
+	e.line("DTSTART", local.Format(localTimeFormat))
+	e.line("RRULE", fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", int(local.Month()), ordinal, weekdays[local.Weekday()]))
+	e.line("TZOFFSETFROM", formatOffset(fromOffset))
+	e.line("TZOFFSETTO", formatOffset(toOffset))
+	e.line("TZNAME", name)
+	e.line("END", kind)
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds/60%60)
+}
@@ -2,42 +2,48 @@ package middleware
 
 import (
 	"bonded/internal/contextKey"
+	"bonded/internal/router"
 	"bonded/internal/usecase"
 	"context"
-	"regexp"
+	"errors"
+	"net/http"
 	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang-jwt/jwt/v4"
 )
 
+// idTokenHeader は Cognito の ID トークンを送るヘッダー。アクセストークンには email が含まれないため、クライアントが合わせて送る
+const idTokenHeader = "X-Id-Token"
+
 type IAuthMiddleware interface {
 	AuthMiddleware(next func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)) func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
 }
 
 type authMiddleware struct {
 	authUsecase usecase.IAuthUsecase
-	publicPaths map[string]*regexp.Regexp
+	isPublic    func(method string, path string) bool
 }
 
-func NewAuthMiddleware(authUsecase usecase.IAuthUsecase) IAuthMiddleware {
-	publicPaths := map[string]*regexp.Regexp{
-		"/hello":                regexp.MustCompile(`^/hello$`),
-		"/calendar/list/public": regexp.MustCompile(`^/calendar/list/public$`),
-		"/calendar/":            regexp.MustCompile(`^/calendar/[0-9a-fA-F-]+$`),
-	}
-
+// NewAuthMiddleware は認証のミドルウェアを作る。isPublic が true を返すリクエストは認証なしで受け付ける（ルート表の Public から決める）
+func NewAuthMiddleware(authUsecase usecase.IAuthUsecase, isPublic func(method string, path string) bool) IAuthMiddleware {
 	return &authMiddleware{
 		authUsecase: authUsecase,
-		publicPaths: publicPaths,
+		isPublic:    isPublic,
 	}
 }
 
 func (am *authMiddleware) AuthMiddleware(next func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)) func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-		for _, re := range am.publicPaths {
-			if re.MatchString(request.Path) {
-				return next(ctx, request)
+		if am.isPublic(request.HTTPMethod, request.Path) {
+			// 公開パスでもトークンがあればメンバーとして扱えるように検証結果を渡す
+			if jwtData, err := am.authenticate(request); err == nil {
+				ctx = context.WithValue(ctx, contextKey.JwtDataKey, jwtData)
+				if idToken, err := am.identify(request, jwtData); err == nil && idToken != nil {
+					ctx = context.WithValue(ctx, contextKey.IDTokenKey, idToken)
+				}
 			}
+			return next(ctx, request)
 		}
 
 		authHeader, ok := request.Headers["Authorization"]
@@ -49,26 +55,39 @@ func (am *authMiddleware) AuthMiddleware(next func(ctx context.Context, request
 		if err != nil {
 			return unauthorizedResponse(err.Error())
 		}
-
-		// idTokenHeader, ok := request.Headers["X-Id-Token"]
-		// if !ok || !strings.HasPrefix(idTokenHeader, "Bearer ") {
-		// 	return unauthorizedResponse("Missing or invalid ID token header")
-		// }
-		// idToken := strings.TrimPrefix(idTokenHeader, "Bearer ")
-		// jwtData, err := am.authUsecase.ValidateJWT(idToken)
-		// if err != nil {
-		// 	return unauthorizedResponse(err.Error())
-		// }
-
 		ctx = context.WithValue(ctx, contextKey.JwtDataKey, jwtData)
 
+		// ID トークンは任意。送られた場合は検証し、プロフィールのメールアドレスなどに使う
+		idToken, err := am.identify(request, jwtData)
+		if err != nil {
+			return unauthorizedResponse(err.Error())
+		}
+		if idToken != nil {
+			ctx = context.WithValue(ctx, contextKey.IDTokenKey, idToken)
+		}
+
 		return next(ctx, request)
 	}
 }
 
+// identify は X-Id-Token ヘッダーの ID トークンを検証する。ヘッダーがない場合は nil を返す
+func (am *authMiddleware) identify(request events.APIGatewayProxyRequest, accessToken *jwt.Token) (*jwt.Token, error) {
+	for name, value := range request.Headers {
+		if strings.EqualFold(name, idTokenHeader) {
+			return am.authUsecase.ValidateIDToken(strings.TrimPrefix(value, "Bearer "), accessToken)
+		}
+	}
+	return nil, nil
+}
+
+func (am *authMiddleware) authenticate(request events.APIGatewayProxyRequest) (*jwt.Token, error) {
+	authHeader, ok := request.Headers["Authorization"]
+	if !ok || !strings.HasPrefix(authHeader, "Bearer ") {
+		return nil, errors.New("missing or invalid Authorization header")
+	}
+	return am.authUsecase.ValidateJWT(strings.TrimPrefix(authHeader, "Bearer "))
+}
+
 func unauthorizedResponse(message string) (events.APIGatewayProxyResponse, error) {
-	return events.APIGatewayProxyResponse{
-		StatusCode: 401,
-		Body:       message,
-	}, nil
+	return router.ProblemResponse(http.StatusUnauthorized, message, nil), nil
 }
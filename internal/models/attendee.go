@@ -0,0 +1,74 @@
+package models
+
+import (
+	"bonded/internal/validation"
+	"fmt"
+	"strings"
+)
+
+// MaxCommentLength は出欠に添えるコメントの最大文字数
+const MaxCommentLength = 500
+
+// 参加者の出欠（iCalendar の PARTSTAT と同じ値）
+const (
+	AttendeeStatusNeedsAction = "NEEDS-ACTION" // 未回答
+	AttendeeStatusAccepted    = "ACCEPTED"     // 参加
+	AttendeeStatusDeclined    = "DECLINED"     // 不参加
+	AttendeeStatusTentative   = "TENTATIVE"    // 未定
+)
+
+// Attendee はイベントの参加者。カレンダーのメンバーは UserID で、外部の参加者はメールアドレスで参照する
+type Attendee struct {
+	UserID      string `json:"userId,omitempty" dynamodbav:"UserID,omitempty"`           // メンバーのユーザーID
+	Email       string `json:"email,omitempty" dynamodbav:"Email,omitempty"`             // 外部の参加者のメールアドレス
+	DisplayName string `json:"displayName,omitempty" dynamodbav:"DisplayName,omitempty"` // 表示名
+	Status      string `json:"status" dynamodbav:"Status"`                               // 出欠（NEEDS-ACTION/ACCEPTED/DECLINED/TENTATIVE）
+	Comment     string `json:"comment,omitempty" dynamodbav:"Comment,omitempty"`         // 出欠に添えるコメント
+}
+
+func (a *Attendee) Validate() error {
+	return validation.Validate(
+		validation.When(a.UserID == "" && a.Email == "", validation.Fail("userId", "userId or email is required")),
+		validation.When(a.UserID != "" && a.Email != "", validation.Fail("email", "must not be specified together with userId")),
+		validation.Field("email", a.Email, validation.Email),
+		validation.Field("displayName", a.DisplayName, validation.MaxLength(MaxDisplayNameLength)),
+		validation.Field("status", a.Status, validation.OneOf(AttendeeStatusNeedsAction, AttendeeStatusAccepted, AttendeeStatusDeclined, AttendeeStatusTentative)),
+		validation.Field("comment", a.Comment, validation.MaxLength(MaxCommentLength)),
+	)
+}
+
+// SameAs は2人の参加者が同じ人を指しているかを返す。メールアドレスは大文字・小文字を区別しない
+func (a *Attendee) SameAs(other *Attendee) bool {
+	if a.UserID != "" || other.UserID != "" {
+		return a.UserID == other.UserID
+	}
+	return strings.EqualFold(a.Email, other.Email)
+}
+
+// RSVP は参加者の出欠の回答
+type RSVP struct {
+	Status  string `json:"status"`
+	Comment string `json:"comment,omitempty"`
+}
+
+func (r *RSVP) Validate() error {
+	return validation.Validate(
+		validation.Field("status", r.Status, validation.Required[string](), validation.OneOf(AttendeeStatusAccepted, AttendeeStatusDeclined, AttendeeStatusTentative)),
+		validation.Field("comment", r.Comment, validation.MaxLength(MaxCommentLength)),
+	)
+}
+
+// InviteAttendees はイベントへの参加者の招待
+type InviteAttendees struct {
+	Attendees []Attendee `json:"attendees"`
+}
+
+func (i *InviteAttendees) Validate() error {
+	checks := []validation.Check{
+		validation.Field("attendees", i.Attendees, validation.NotEmpty[Attendee](), validation.MaxItems[Attendee](MaxAttendees)),
+	}
+	for n := range i.Attendees {
+		checks = append(checks, validation.Nested(fmt.Sprintf("attendees[%d]", n), &i.Attendees[n]))
+	}
+	return validation.Validate(checks...)
+}
@@ -1,22 +1,72 @@
 package models
 
+import (
+	"bonded/internal/validation"
+	"time"
+)
+
+// MaxCalendarNameLength はカレンダー名の最大文字数
+const MaxCalendarNameLength = 100
+
 type Calendar struct {
-	CalendarID  string  `json:"calendarId,omitempty" dynamodbav:"CalendarID"`   // カレンダーのID
-	SortKey     string  `json:"sortKey,omitempty" dynamodbav:"SortKey"`         // ソートキー
-	Name        string  `json:"name" dynamodbav:"Name"`                         // カレンダー名
-	IsPublic    *bool   `json:"isPublic" dynamodbav:"IsPublic"`                 // 公開フラグ
-	OwnerUserID string  `json:"ownerUserId,omitempty" dynamodbav:"OwnerUserID"` // オーナーのユーザーID
-	Users       []User  `json:"users,omitempty" dynamodbav:"Users"`             // 共有ユーザーのIDリスト
-	Events      []Event `json:"events,omitempty"`                               // カレンダー内のイベント
+	CalendarID    string     `json:"calendarId,omitempty" dynamodbav:"CalendarID"`                  // カレンダーのID
+	SortKey       string     `json:"sortKey,omitempty" dynamodbav:"SortKey"`                        // ソートキー
+	Name          string     `json:"name" dynamodbav:"Name"`                                        // カレンダー名
+	IsPublic      *bool      `json:"isPublic" dynamodbav:"IsPublic"`                                // 公開フラグ
+	OwnerUserID   string     `json:"ownerUserId,omitempty" dynamodbav:"OwnerUserID"`                // オーナーのユーザーID
+	TimeZone      string     `json:"timeZone,omitempty" dynamodbav:"TimeZone,omitempty"`            // 既定の IANA タイムゾーン名
+	OwnerName     string     `json:"ownerName,omitempty" dynamodbav:"OwnerName,omitempty"`          // オーナーのユーザー名
+	Version       int64      `json:"version" dynamodbav:"Version,omitempty"`                        // 更新のたびに増える版数（ETag）
+	FollowerCount int        `json:"followerCount" dynamodbav:"FollowerCount"`                      // オーナー以外のメンバー数
+	DeletedAt     *time.Time `json:"deletedAt,omitempty" dynamodbav:"DeletedAt,omitempty"`          // ゴミ箱に移した日時
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty" dynamodbav:"ExpiresAt,omitempty,unixtime"` // ゴミ箱から完全に削除される日時（TTL）
+	Users         []User     `json:"users,omitempty" dynamodbav:"Users"`                            // 共有ユーザーのIDリスト
+	Events        []Event    `json:"events,omitempty"`                                              // カレンダー内のイベント
 }
 
 type CreateCalendar struct {
-	CalendarID  string  `json:"calendarId,omitempty" dynamodbav:"CalendarID"`   // カレンダーのID
-	SortKey     string  `json:"sortKey,omitempty" dynamodbav:"SortKey"`         // ソートキー
-	Name        string  `json:"name" dynamodbav:"Name"`                         // カレンダー名
-	IsPublic    *bool   `json:"isPublic" dynamodbav:"IsPublic"`                 // 公開フラグ
-	OwnerUserID string  `json:"ownerUserId,omitempty" dynamodbav:"OwnerUserID"` // オーナーのユーザーID
-	OwnerName   string  `json:"ownerName,omitempty" dynamodbav:"OwnerName"`     // オーナーのユーザー名
-	Users       []User  `json:"users,omitempty" dynamodbav:"Users"`             // 共有ユーザーのIDリスト
-	Events      []Event `json:"events,omitempty"`                               // カレンダー内のイベント
+	CalendarID  string  `json:"calendarId,omitempty" dynamodbav:"CalendarID"`       // カレンダーのID
+	SortKey     string  `json:"sortKey,omitempty" dynamodbav:"SortKey"`             // ソートキー
+	Name        string  `json:"name" dynamodbav:"Name"`                             // カレンダー名
+	IsPublic    *bool   `json:"isPublic" dynamodbav:"IsPublic"`                     // 公開フラグ
+	OwnerUserID string  `json:"ownerUserId,omitempty" dynamodbav:"OwnerUserID"`     // オーナーのユーザーID
+	TimeZone    string  `json:"timeZone,omitempty" dynamodbav:"TimeZone,omitempty"` // 既定の IANA タイムゾーン名
+	OwnerName   string  `json:"ownerName,omitempty" dynamodbav:"OwnerName"`         // オーナーのユーザー名
+	Users       []User  `json:"users,omitempty" dynamodbav:"Users"`                 // 共有ユーザーのIDリスト
+	Events      []Event `json:"events,omitempty"`                                   // カレンダー内のイベント
+}
+
+func (c *CreateCalendar) Validate() error {
+	return validation.Validate(
+		validation.Field("name", c.Name, validation.Required[string](), validation.NotBlank, validation.MaxLength(MaxCalendarNameLength)),
+		validation.Field("isPublic", c.IsPublic, validation.Required[*bool]()),
+		validation.Field("timeZone", c.TimeZone, validation.MaxLength(MaxTimeZoneLength)),
+		validation.Field("ownerName", c.OwnerName, validation.MaxLength(MaxDisplayNameLength)),
+	)
+}
+
+// EditCalendar はカレンダーの編集。空のフィールドは変更しない。オーナーやメンバーなどサーバーが管理するフィールドは受け付けない
+type EditCalendar struct {
+	Name     string `json:"name,omitempty"`     // カレンダー名
+	IsPublic *bool  `json:"isPublic,omitempty"` // 公開フラグ
+	TimeZone string `json:"timeZone,omitempty"` // 既定の IANA タイムゾーン名
+}
+
+func (e *EditCalendar) Validate() error {
+	return validation.Validate(
+		validation.When(e.Name != "", validation.Field("name", e.Name, validation.NotBlank)),
+		validation.Field("name", e.Name, validation.MaxLength(MaxCalendarNameLength)),
+		validation.Field("timeZone", e.TimeZone, validation.MaxLength(MaxTimeZoneLength)),
+	)
+}
+
+// FollowCalendar はフォロー・フォロー解除するカレンダーの指定
+type FollowCalendar struct {
+	CalendarID string `json:"calendarId"`
+}
+
+func (f *FollowCalendar) Validate() error {
+	return validation.Validate(
+		validation.Field("calendarId", f.CalendarID, validation.Required[string]()),
+	)
 }
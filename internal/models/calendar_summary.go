@@ -0,0 +1,14 @@
+package models
+
+// CalendarSummary はカレンダーの一覧に表示する軽量な情報（イベントやメンバーは含まない）
+type CalendarSummary struct {
+	CalendarID    string `json:"calendarId" dynamodbav:"CalendarID"`                 // カレンダーのID
+	Name          string `json:"name" dynamodbav:"Name"`                             // カレンダー名
+	IsPublic      *bool  `json:"isPublic,omitempty" dynamodbav:"IsPublic"`           // 公開フラグ
+	OwnerUserID   string `json:"ownerUserId" dynamodbav:"OwnerUserID"`               // オーナーのユーザーID
+	OwnerName     string `json:"ownerName" dynamodbav:"OwnerName"`                   // オーナーのユーザー名
+	FollowerCount int    `json:"followerCount" dynamodbav:"FollowerCount"`           // オーナー以外のメンバー数
+	TimeZone      string `json:"timeZone,omitempty" dynamodbav:"TimeZone,omitempty"` // 既定の IANA タイムゾーン名
+	Version       int64  `json:"version,omitempty" dynamodbav:"Version,omitempty"`   // 更新のたびに増える版数（ETag）
+	AccessLevel   string `json:"accessLevel,omitempty" dynamodbav:"-"`               // 一覧を取得したユーザーの権限（所属するカレンダーの一覧のみ）
+}
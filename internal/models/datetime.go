@@ -0,0 +1,118 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// DefaultTimeZone はタイムゾーンが指定されていないカレンダー・イベントで使う IANA タイムゾーン
+const DefaultTimeZone = "Asia/Tokyo"
+
+// MaxTimeZoneLength は IANA タイムゾーン名の最大文字数
+const MaxTimeZoneLength = 64
+
+// DateLayout は終日イベントの日付の形式
+const DateLayout = "2006-01-02"
+
+// DateTime はイベントの日時。時刻付きの日時は RFC 3339 の瞬間として、
+// 終日イベントの日付はタイムゾーンを持たない floating な日付（YYYY-MM-DD）として扱う。
+// 日付のみの場合 Time は UTC の 0 時を指す。
+type DateTime struct {
+	time.Time
+	DateOnly bool
+}
+
+// ParseDateTime は RFC 3339 の日時または YYYY-MM-DD の日付を解析する
+func ParseDateTime(value string) (DateTime, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return DateTime{Time: t}, nil
+	}
+	if t, err := time.Parse(DateLayout, value); err == nil {
+		return DateTime{Time: t, DateOnly: true}, nil
+	}
+	return DateTime{}, fmt.Errorf("invalid date-time %q", value)
+}
+
+// String は日付のみなら YYYY-MM-DD を、それ以外は保持しているオフセットでの RFC 3339 を返す
+func (d DateTime) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	if d.DateOnly {
+		return d.Time.Format(DateLayout)
+	}
+	return d.Time.Format(time.RFC3339)
+}
+
+// At は日時を loc での瞬間として返す。日付のみの場合は loc での 0 時とする
+func (d DateTime) At(loc *time.Location) time.Time {
+	if d.DateOnly {
+		return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, loc)
+	}
+	return d.Time.In(loc)
+}
+
+func (d DateTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *DateTime) UnmarshalJSON(data []byte) error {
+	var value string
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		*d = DateTime{}
+		return nil
+	}
+	parsed, err := ParseDateTime(value)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalDynamoDBAttributeValue は文字列の比較で並べ替えられるよう、時刻付きの日時を UTC で保存する
+func (d DateTime) MarshalDynamoDBAttributeValue(av *dynamodb.AttributeValue) error {
+	if d.IsZero() {
+		av.NULL = aws.Bool(true)
+		return nil
+	}
+	value := d.String()
+	if !d.DateOnly {
+		value = d.Time.UTC().Format(time.RFC3339)
+	}
+	av.S = aws.String(value)
+	return nil
+}
+
+func (d *DateTime) UnmarshalDynamoDBAttributeValue(av *dynamodb.AttributeValue) error {
+	if av.S == nil || *av.S == "" {
+		*d = DateTime{}
+		return nil
+	}
+	parsed, err := ParseDateTime(*av.S)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// LoadTimeZone は IANA タイムゾーン名を解決する。空の場合は DefaultTimeZone を使う
+func LoadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		name = DefaultTimeZone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
@@ -0,0 +1,14 @@
+package models
+
+// DeletionReport はカレンダーの削除で消したアイテム（dryRun の場合は消す予定のアイテム）の件数
+type DeletionReport struct {
+	CalendarID  string `json:"calendarId"`
+	DryRun      bool   `json:"dryRun"`      // true の場合は何も削除していない
+	Events      int    `json:"events"`      // EVENT# アイテム（繰り返しイベントの変更分を含む）
+	Members     int    `json:"members"`     // USER# アイテム
+	Relations   int    `json:"relations"`   // CAL# アイテム
+	FeedTokens  int    `json:"feedTokens"`  // FEED# アイテム
+	Invitations int    `json:"invitations"` // INVITE# アイテム
+	ShareLinks  int    `json:"shareLinks"`  // LINK# アイテム
+	Total       int    `json:"total"`       // CALENDAR アイテムを含むすべてのアイテム
+}
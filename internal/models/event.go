@@ -1,11 +1,122 @@
 package models
 
+import (
+	"bonded/internal/validation"
+	"fmt"
+	"time"
+)
+
+// イベントのフィールドの上限
+const (
+	MaxEventTitleLength       = 200   // イベント名の最大文字数
+	MaxEventDescriptionLength = 10000 // 詳細の最大文字数
+	MaxEventLocationLength    = 500   // 場所の最大文字数
+	MaxRRuleLength            = 1000  // 繰り返しルールの最大文字数
+	MaxRecurrenceDates        = 1000  // 追加・除外する発生日時の最大数
+	MaxAttendees              = 100   // 参加者の最大数
+)
+
 type Event struct {
-	EventID     string `json:"eventId" dynamodbav:"EventID"`         // イベントID
-	Title       string `json:"title" dynamodbav:"Title"`             // イベント名
-	Description string `json:"description" dynamodbav:"Description"` // 詳細
-	StartTime   string `json:"startTime" dynamodbav:"StartTime"`     // 開始時間
-	EndTime     string `json:"endTime" dynamodbav:"EndTime"`         // 終了時間
-	Location    string `json:"location" dynamodbav:"Location"`       // 場所
-	AllDay      bool   `json:"allDay" dynamodbav:"AllDay"`           // 終日フラグ
+	EventID          string     `json:"eventId" dynamodbav:"EventID"`                                       // イベントID
+	Title            string     `json:"title" dynamodbav:"Title"`                                           // イベント名
+	Description      string     `json:"description" dynamodbav:"Description"`                               // 詳細
+	StartTime        DateTime   `json:"startTime" dynamodbav:"StartTime"`                                   // 開始時間
+	EndTime          DateTime   `json:"endTime" dynamodbav:"EndTime"`                                       // 終了時間（終日イベントは翌日を指す排他的な日付）
+	TimeZone         string     `json:"timeZone,omitempty" dynamodbav:"TimeZone,omitempty"`                 // IANA タイムゾーン名（省略時はカレンダーのタイムゾーン）
+	Location         string     `json:"location" dynamodbav:"Location"`                                     // 場所
+	AllDay           bool       `json:"allDay" dynamodbav:"AllDay"`                                         // 終日フラグ
+	UID              string     `json:"uid,omitempty" dynamodbav:"UID,omitempty"`                           // iCalendar の UID（インポートしたイベントのみ）
+	RRule            string     `json:"rrule,omitempty" dynamodbav:"RRule,omitempty"`                       // 繰り返しルール（RFC 5545 RRULE）
+	RDates           []string   `json:"rdates,omitempty" dynamodbav:"RDates,omitempty"`                     // 追加の発生日時
+	ExDates          []string   `json:"exdates,omitempty" dynamodbav:"ExDates,omitempty"`                   // 除外する発生日時
+	RecurringEventID string     `json:"recurringEventId,omitempty" dynamodbav:"RecurringEventID,omitempty"` // 繰り返し元のイベントID（個別の発生のみ）
+	RecurrenceID     string     `json:"recurrenceId,omitempty" dynamodbav:"RecurrenceID,omitempty"`         // 繰り返し元での本来の開始時間（個別の発生のみ）
+	Attendees        []Attendee `json:"attendees,omitempty" dynamodbav:"Attendees,omitempty"`               // 参加者（個別の発生は繰り返し元の参加者）
+	Version          int64      `json:"version" dynamodbav:"Version,omitempty"`                             // 更新のたびに増える版数（ETag）。個別の発生は繰り返し元の版数
+	DeletedAt        *time.Time `json:"deletedAt,omitempty" dynamodbav:"DeletedAt,omitempty"`               // ゴミ箱に移した日時
+	ExpiresAt        *time.Time `json:"expiresAt,omitempty" dynamodbav:"ExpiresAt,omitempty,unixtime"`      // ゴミ箱から完全に削除される日時（TTL）
+	Overrides        []Event    `json:"overrides,omitempty" dynamodbav:"-"`                                 // 個別に変更した発生（一覧・カレンダーの取得で繰り返し元にまとめて返す）
+}
+
+// CreateEvent はイベントの作成。イベントID・UID・版数などサーバーが管理するフィールドは受け付けない
+type CreateEvent struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	StartTime   DateTime   `json:"startTime"`
+	EndTime     DateTime   `json:"endTime"`
+	TimeZone    string     `json:"timeZone,omitempty"`
+	Location    string     `json:"location"`
+	AllDay      bool       `json:"allDay"`
+	RRule       string     `json:"rrule,omitempty"`
+	RDates      []string   `json:"rdates,omitempty"`
+	ExDates     []string   `json:"exdates,omitempty"`
+	Attendees   []Attendee `json:"attendees,omitempty"`
+}
+
+func (c *CreateEvent) Validate() error {
+	checks := []validation.Check{
+		validation.Field("title", c.Title, validation.Required[string](), validation.NotBlank, validation.MaxLength(MaxEventTitleLength)),
+		validation.Field("description", c.Description, validation.MaxLength(MaxEventDescriptionLength)),
+		validation.Field("location", c.Location, validation.MaxLength(MaxEventLocationLength)),
+		validation.Field("startTime", c.StartTime.Time, validation.Required[time.Time]()),
+		validation.Field("endTime", c.EndTime.Time, validation.Required[time.Time](), validation.After("startTime", c.StartTime.Time)),
+		validation.Field("timeZone", c.TimeZone, validation.MaxLength(MaxTimeZoneLength)),
+		validation.Field("rrule", c.RRule, validation.MaxLength(MaxRRuleLength)),
+		validation.Field("rdates", c.RDates, validation.MaxItems[string](MaxRecurrenceDates)),
+		validation.Field("exdates", c.ExDates, validation.MaxItems[string](MaxRecurrenceDates)),
+		validation.Field("attendees", c.Attendees, validation.MaxItems[Attendee](MaxAttendees)),
+	}
+	for i := range c.Attendees {
+		checks = append(checks, validation.Nested(fmt.Sprintf("attendees[%d]", i), &c.Attendees[i]))
+	}
+	return validation.Validate(checks...)
+}
+
+// Event は作成するイベントを返す
+func (c *CreateEvent) Event() *Event {
+	return &Event{
+		Title:       c.Title,
+		Description: c.Description,
+		StartTime:   c.StartTime,
+		EndTime:     c.EndTime,
+		TimeZone:    c.TimeZone,
+		Location:    c.Location,
+		AllDay:      c.AllDay,
+		RRule:       c.RRule,
+		RDates:      c.RDates,
+		ExDates:     c.ExDates,
+		Attendees:   c.Attendees,
+	}
+}
+
+// 繰り返しイベントの編集・削除範囲
+const (
+	RecurrenceScopeThis             = "THIS"               // この発生のみ
+	RecurrenceScopeThisAndFollowing = "THIS_AND_FOLLOWING" // この発生以降
+	RecurrenceScopeAll              = "ALL"                // すべての発生
+)
+
+// RecurrenceScopes は指定できる編集・削除範囲
+var RecurrenceScopes = []string{RecurrenceScopeThis, RecurrenceScopeThisAndFollowing, RecurrenceScopeAll}
+
+// DeleteEvent はイベントの削除。繰り返しイベントは RecurrenceID と Scope で削除する範囲を指定する
+type DeleteEvent struct {
+	EventID      string `json:"eventId"`
+	CalendarID   string `json:"calendarId"`
+	RecurrenceID string `json:"recurrenceId"`
+	Scope        string `json:"scope"`
+}
+
+func (d *DeleteEvent) Validate() error {
+	return validation.Validate(
+		validation.Field("eventId", d.EventID, validation.Required[string]()),
+		validation.Field("calendarId", d.CalendarID, validation.Required[string]()),
+		validation.Field("scope", d.Scope, validation.OneOf(RecurrenceScopes...)),
+	)
+}
+
+// TimeRange はイベントを検索する期間（From 以上 To 未満）
+type TimeRange struct {
+	From time.Time
+	To   time.Time
 }
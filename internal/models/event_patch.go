@@ -0,0 +1,161 @@
+package models
+
+import (
+	"bonded/internal/validation"
+	"encoding/json"
+	"time"
+)
+
+// PatchField は JSON Merge Patch（RFC 7396）の1フィールド。
+// リクエストにキーがあれば Present が true になり、値が null の場合は Null が true で Value はゼロ値になる
+type PatchField[T any] struct {
+	Value   T
+	Present bool
+	Null    bool
+}
+
+// SetField は値を指定したフィールドを返す
+func SetField[T any](value T) PatchField[T] {
+	return PatchField[T]{Value: value, Present: true}
+}
+
+// Apply はフィールドがリクエストに含まれていれば dst に値（null の場合はゼロ値）を設定する
+func (f PatchField[T]) Apply(dst *T) {
+	if f.Present {
+		*dst = f.Value
+	}
+}
+
+// UnmarshalJSON はキーが存在する場合だけ呼ばれるため、ここで Present を記録する
+func (f *PatchField[T]) UnmarshalJSON(data []byte) error {
+	f.Present = true
+	if string(data) == "null" {
+		var zero T
+		f.Value = zero
+		f.Null = true
+		return nil
+	}
+	f.Null = false
+	return json.Unmarshal(data, &f.Value)
+}
+
+// EventField は部分的な更新の対象になるイベントのフィールド（JSON のキー名）
+type EventField string
+
+const (
+	EventFieldTitle       EventField = "title"
+	EventFieldDescription EventField = "description"
+	EventFieldStartTime   EventField = "startTime"
+	EventFieldEndTime     EventField = "endTime"
+	EventFieldTimeZone    EventField = "timeZone"
+	EventFieldLocation    EventField = "location"
+	EventFieldAllDay      EventField = "allDay"
+	EventFieldRRule       EventField = "rrule"
+	EventFieldRDates      EventField = "rdates"
+	EventFieldExDates     EventField = "exdates"
+)
+
+// EventPatch はイベントの部分的な更新。EventID と RecurrenceID は更新対象を指し、それ以外はリクエストに含まれたフィールドだけを更新する
+type EventPatch struct {
+	EventID      string               `json:"eventId"`
+	RecurrenceID string               `json:"recurrenceId,omitempty"`
+	Title        PatchField[string]   `json:"title"`
+	Description  PatchField[string]   `json:"description"`
+	StartTime    PatchField[DateTime] `json:"startTime"`
+	EndTime      PatchField[DateTime] `json:"endTime"`
+	TimeZone     PatchField[string]   `json:"timeZone"`
+	Location     PatchField[string]   `json:"location"`
+	AllDay       PatchField[bool]     `json:"allDay"`
+	RRule        PatchField[string]   `json:"rrule"`
+	RDates       PatchField[[]string] `json:"rdates"`
+	ExDates      PatchField[[]string] `json:"exdates"`
+}
+
+func (p *EventPatch) Validate() error {
+	return validation.Validate(p.checks()...)
+}
+
+// checks はパッチに含まれているフィールドだけを検証する。null にできないフィールドは値の指定を求める
+func (p *EventPatch) checks() []validation.Check {
+	return []validation.Check{
+		validation.Field("eventId", p.EventID, validation.Required[string]()),
+		validation.When(p.Title.Present, validation.Field("title", p.Title.Value, validation.Required[string](), validation.NotBlank, validation.MaxLength(MaxEventTitleLength))),
+		validation.When(p.Description.Present, validation.Field("description", p.Description.Value, validation.MaxLength(MaxEventDescriptionLength))),
+		validation.When(p.Location.Present, validation.Field("location", p.Location.Value, validation.MaxLength(MaxEventLocationLength))),
+		validation.When(p.StartTime.Present, validation.Field("startTime", p.StartTime.Value.Time, validation.Required[time.Time]())),
+		validation.When(p.EndTime.Present, validation.Field("endTime", p.EndTime.Value.Time, validation.Required[time.Time](), validation.After("startTime", p.StartTime.Value.Time))),
+		validation.When(p.AllDay.Null, validation.Fail("allDay", "must not be null")),
+		validation.When(p.TimeZone.Present, validation.Field("timeZone", p.TimeZone.Value, validation.MaxLength(MaxTimeZoneLength))),
+		validation.When(p.RRule.Present, validation.Field("rrule", p.RRule.Value, validation.MaxLength(MaxRRuleLength))),
+		validation.When(p.RDates.Present, validation.Field("rdates", p.RDates.Value, validation.MaxItems[string](MaxRecurrenceDates))),
+		validation.When(p.ExDates.Present, validation.Field("exdates", p.ExDates.Value, validation.MaxItems[string](MaxRecurrenceDates))),
+	}
+}
+
+// EditEvent はイベントの編集。繰り返しイベントは RecurrenceID と Scope で編集する範囲を指定する
+type EditEvent struct {
+	EventPatch
+	Scope string `json:"scope"`
+}
+
+func (e *EditEvent) Validate() error {
+	checks := append(e.EventPatch.checks(), validation.Field("scope", e.Scope, validation.OneOf(RecurrenceScopes...)))
+	return validation.Validate(checks...)
+}
+
+// EventPatchOf はイベントのすべてのフィールドを置き換えるパッチを返す（iCalendar のインポートなど）
+func EventPatchOf(event *Event) *EventPatch {
+	return &EventPatch{
+		EventID:      event.EventID,
+		RecurrenceID: event.RecurrenceID,
+		Title:        SetField(event.Title),
+		Description:  SetField(event.Description),
+		StartTime:    SetField(event.StartTime),
+		EndTime:      SetField(event.EndTime),
+		TimeZone:     SetField(event.TimeZone),
+		Location:     SetField(event.Location),
+		AllDay:       SetField(event.AllDay),
+		RRule:        SetField(event.RRule),
+		RDates:       SetField(event.RDates),
+		ExDates:      SetField(event.ExDates),
+	}
+}
+
+// Fields はパッチに含まれているフィールドを返す
+func (p *EventPatch) Fields() []EventField {
+	var fields []EventField
+	for _, f := range []struct {
+		field   EventField
+		present bool
+	}{
+		{EventFieldTitle, p.Title.Present},
+		{EventFieldDescription, p.Description.Present},
+		{EventFieldStartTime, p.StartTime.Present},
+		{EventFieldEndTime, p.EndTime.Present},
+		{EventFieldTimeZone, p.TimeZone.Present},
+		{EventFieldLocation, p.Location.Present},
+		{EventFieldAllDay, p.AllDay.Present},
+		{EventFieldRRule, p.RRule.Present},
+		{EventFieldRDates, p.RDates.Present},
+		{EventFieldExDates, p.ExDates.Present},
+	} {
+		if f.present {
+			fields = append(fields, f.field)
+		}
+	}
+	return fields
+}
+
+// Apply はパッチをイベントに適用する。null を指定したフィールドはゼロ値になる
+func (p *EventPatch) Apply(event *Event) {
+	p.Title.Apply(&event.Title)
+	p.Description.Apply(&event.Description)
+	p.StartTime.Apply(&event.StartTime)
+	p.EndTime.Apply(&event.EndTime)
+	p.TimeZone.Apply(&event.TimeZone)
+	p.Location.Apply(&event.Location)
+	p.AllDay.Apply(&event.AllDay)
+	p.RRule.Apply(&event.RRule)
+	p.RDates.Apply(&event.RDates)
+	p.ExDates.Apply(&event.ExDates)
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type FeedToken struct {
+	TokenID    string    `json:"tokenId" dynamodbav:"TokenID"`       // トークンのID（失効させる際に使う）
+	CalendarID string    `json:"calendarId" dynamodbav:"CalendarID"` // カレンダーのID
+	UserID     string    `json:"userId" dynamodbav:"MemberUserID"`   // 発行したメンバーのユーザーID（UserID-index に載せないため別名で保存）
+	TokenHash  string    `json:"-" dynamodbav:"TokenHash"`           // トークンの SHA-256 ハッシュ
+	CreatedAt  time.Time `json:"createdAt" dynamodbav:"CreatedAt"`   // 発行日時
+	Token      string    `json:"token,omitempty" dynamodbav:"-"`     // トークン本体（発行時のみ返す）
+	FeedURL    string    `json:"feedUrl,omitempty" dynamodbav:"-"`   // 購読用の URL（発行時のみ返す）
+	WebcalURL  string    `json:"webcalUrl,omitempty" dynamodbav:"-"` // webcal:// 形式の購読用 URL（発行時のみ返す）
+}
@@ -0,0 +1,24 @@
+package models
+
+// インポート結果のステータス
+const (
+	ImportStatusCreated = "CREATED"
+	ImportStatusUpdated = "UPDATED"
+	ImportStatusFailed  = "FAILED"
+)
+
+type ImportResult struct {
+	Index   int    `json:"index"`             // ファイル内での VEVENT の順番（0始まり）
+	UID     string `json:"uid,omitempty"`     // iCalendar の UID
+	EventID string `json:"eventId,omitempty"` // 作成・更新されたイベントのID
+	Title   string `json:"title,omitempty"`   // イベント名
+	Status  string `json:"status"`            // CREATED/UPDATED/FAILED
+	Error   string `json:"error,omitempty"`   // 失敗した理由
+}
+
+type ImportReport struct {
+	Created int            `json:"created"` // 作成した件数
+	Updated int            `json:"updated"` // 更新した件数
+	Failed  int            `json:"failed"`  // 失敗した件数
+	Results []ImportResult `json:"results"` // イベントごとの結果
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"bonded/internal/validation"
+	"time"
+)
+
+// 招待の状態
+const (
+	InvitationStatusPending  = "PENDING"  // 回答待ち
+	InvitationStatusAccepted = "ACCEPTED" // 承諾（メンバーになった）
+	InvitationStatusDeclined = "DECLINED" // 辞退
+	InvitationStatusRevoked  = "REVOKED"  // オーナーが取り消した
+	InvitationStatusExpired  = "EXPIRED"  // 回答がないまま期限が過ぎた（保存はせず、読み出すときに判定する）
+)
+
+// InvitationValidDays は招待に回答できる日数
+const InvitationValidDays = 14
+
+// Invitation はカレンダーへの招待。招待されたユーザーが承諾したときに初めてメンバーになる
+type Invitation struct {
+	CalendarID   string     `json:"calendarId" dynamodbav:"CalendarID"`                       // カレンダーのID
+	CalendarName string     `json:"calendarName" dynamodbav:"CalendarName"`                   // 招待した時点のカレンダー名
+	UserID       string     `json:"userId" dynamodbav:"UserID"`                               // 招待されたユーザーのID
+	DisplayName  string     `json:"displayName" dynamodbav:"DisplayName"`                     // 招待されたユーザーの表示名
+	AccessLevel  string     `json:"accessLevel" dynamodbav:"AccessLevel"`                     // 承諾したときの権限（EDITOR/VIEWER）
+	InvitedBy    string     `json:"invitedBy" dynamodbav:"InvitedBy"`                         // 招待したユーザーのID
+	Status       string     `json:"status" dynamodbav:"Status"`                               // 状態（PENDING/ACCEPTED/DECLINED/REVOKED/EXPIRED）
+	CreatedAt    time.Time  `json:"createdAt" dynamodbav:"CreatedAt"`                         // 招待した日時
+	ValidUntil   time.Time  `json:"validUntil" dynamodbav:"ValidUntil,unixtime"`              // 回答の期限（TTL の ExpiresAt とは別の属性）
+	RespondedAt  *time.Time `json:"respondedAt,omitempty" dynamodbav:"RespondedAt,omitempty"` // 承諾・辞退・取り消しの日時
+}
+
+// NewInvitationValidUntil は now に招待した場合の回答の期限を返す
+func NewInvitationValidUntil(now time.Time) time.Time {
+	return now.AddDate(0, 0, InvitationValidDays)
+}
+
+// ResolveStatus は回答待ちのまま期限を過ぎた招待の状態を EXPIRED にする
+func (i *Invitation) ResolveStatus(now time.Time) {
+	if i.Status == InvitationStatusPending && !now.Before(i.ValidUntil) {
+		i.Status = InvitationStatusExpired
+	}
+}
+
+// InviteUser はカレンダーへの招待。招待するユーザーは InviteUserID かメールアドレスのどちらかで指定する
+type InviteUser struct {
+	InviteUserID string `json:"inviteUserId"`
+	Email        string `json:"email"`
+	CalendarID   string `json:"calendarId"`
+	AccessLevel  string `json:"accessLevel"`
+}
+
+func (i *InviteUser) Validate() error {
+	return validation.Validate(
+		validation.Field("calendarId", i.CalendarID, validation.Required[string]()),
+		validation.Field("accessLevel", i.AccessLevel, validation.Required[string](), validation.OneOf(AccessLevelEditor, AccessLevelViewer)),
+		validation.When(i.InviteUserID == "" && i.Email == "", validation.Fail("inviteUserId", "inviteUserId or email is required")),
+		validation.When(i.InviteUserID != "" && i.Email != "", validation.Fail("email", "must not be specified together with inviteUserId")),
+		validation.Field("email", i.Email, validation.Email),
+	)
+}
@@ -0,0 +1,18 @@
+package models
+
+const (
+	DefaultPageLimit = 50  // 一覧 API の既定の件数
+	MaxPageLimit     = 100 // 一覧 API で指定できる最大の件数
+)
+
+// PageRequest は一覧 API のページ指定。Cursor は前のページの NextCursor をそのまま渡す
+type PageRequest struct {
+	Cursor string
+	Limit  int
+}
+
+// Page は一覧 API のレスポンス。NextCursor が空なら最後のページ
+type Page[T any] struct {
+	Items      []T    `json:"items"`                // このページの項目
+	NextCursor string `json:"nextCursor,omitempty"` // 次のページのカーソル
+}
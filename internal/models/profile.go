@@ -0,0 +1,59 @@
+package models
+
+import (
+	"bonded/internal/validation"
+	"regexp"
+	"time"
+)
+
+// DefaultLocale はロケールが分からないユーザーに使うロケール
+const DefaultLocale = "ja-JP"
+
+// MaxAvatarURLLength はアバター画像の URL の最大文字数
+const MaxAvatarURLLength = 2048
+
+// LocalePattern は BCP 47 の言語タグ（ja、ja-JP、zh-Hant-TW など）の形
+var LocalePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// Profile はユーザーのプロフィール。初めてサインインしたときに Cognito のクレームから作成する
+type Profile struct {
+	UserID      string    `json:"userId" dynamodbav:"ProfileUserID"`                    // ユーザーID（Cognito の sub。UserID-index に載せないため別名で保存）
+	DisplayName string    `json:"displayName" dynamodbav:"DisplayName"`                 // 表示名（メンバーシップの DisplayName にも反映する）
+	Email       string    `json:"email,omitempty" dynamodbav:"Email,omitempty"`         // メールアドレス（Cognito から取得し、変更できない）
+	TimeZone    string    `json:"timeZone" dynamodbav:"TimeZone"`                       // 既定の IANA タイムゾーン名（カレンダー作成時の既定値）
+	Locale      string    `json:"locale" dynamodbav:"Locale"`                           // ロケール（BCP 47 の言語タグ）
+	AvatarURL   string    `json:"avatarUrl,omitempty" dynamodbav:"AvatarURL,omitempty"` // アバター画像の URL
+	CreatedAt   time.Time `json:"createdAt" dynamodbav:"CreatedAt"`                     // 作成日時
+	UpdatedAt   time.Time `json:"updatedAt" dynamodbav:"UpdatedAt"`                     // 更新日時
+}
+
+// EditProfile はプロフィールの変更。nil のフィールドは変更しない
+type EditProfile struct {
+	DisplayName *string `json:"displayName"`
+	TimeZone    *string `json:"timeZone"`
+	Locale      *string `json:"locale"`
+	AvatarURL   *string `json:"avatarUrl"` // 空文字列を指定するとアバターを削除する
+}
+
+func (e *EditProfile) Validate() error {
+	return validation.Validate(
+		validation.When(e.DisplayName != nil, validation.Field("displayName", deref(e.DisplayName), validation.NotBlank, validation.MaxLength(MaxDisplayNameLength))),
+		validation.When(e.TimeZone != nil, validation.Field("timeZone", deref(e.TimeZone), validation.NotBlank, validation.MaxLength(MaxTimeZoneLength))),
+		validation.When(e.Locale != nil, validation.Field("locale", deref(e.Locale), validation.Required[string](), validation.Match(LocalePattern, "a BCP 47 language tag"))),
+		validation.When(e.AvatarURL != nil, validation.Field("avatarUrl", deref(e.AvatarURL), validation.MaxLength(MaxAvatarURLLength), validation.HTTPSURL)),
+	)
+}
+
+// User はメンバーシップを組み立てるためにプロフィールをユーザーに変換する
+func (p *Profile) User() *User {
+	return &User{UserID: p.UserID, DisplayName: p.DisplayName}
+}
+
+// deref は nil の場合にゼロ値を返す
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"bonded/internal/validation"
+	"time"
+)
+
+// 共有リンクの有効期間と利用回数の上限
+const (
+	ShareLinkDefaultValidDays = 7    // 有効日数を指定しなかった場合の日数
+	ShareLinkMaxValidDays     = 30   // 指定できる有効日数の上限
+	ShareLinkMaxUses          = 1000 // 指定できる利用回数の上限
+)
+
+// ShareLink はカレンダーに参加するための共有リンク。トークンを知っているユーザーは誰でも AccessLevel のメンバーになれる
+type ShareLink struct {
+	LinkID      string    `json:"linkId" dynamodbav:"LinkID"`                  // リンクのID（取り消す際に使う）
+	CalendarID  string    `json:"calendarId" dynamodbav:"CalendarID"`          // カレンダーのID
+	AccessLevel string    `json:"accessLevel" dynamodbav:"AccessLevel"`        // 参加したときの権限（EDITOR/VIEWER）
+	CreatedBy   string    `json:"createdBy" dynamodbav:"CreatedBy"`            // 発行したユーザーのID（UserID-index に載せないため UserID は使わない）
+	TokenHash   string    `json:"-" dynamodbav:"TokenHash"`                    // トークンの SHA-256 ハッシュ
+	CreatedAt   time.Time `json:"createdAt" dynamodbav:"CreatedAt"`            // 発行日時
+	ValidUntil  time.Time `json:"validUntil" dynamodbav:"ValidUntil,unixtime"` // 有効期限
+	MaxUses     int       `json:"maxUses" dynamodbav:"MaxUses"`                // 参加できる人数の上限
+	UseCount    int       `json:"useCount" dynamodbav:"UseCount"`              // このリンクで参加した人数
+	Token       string    `json:"token,omitempty" dynamodbav:"-"`              // トークン本体（発行時のみ返す）
+}
+
+// Active は now の時点でリンクが使えるかを返す
+func (l *ShareLink) Active(now time.Time) bool {
+	return now.Before(l.ValidUntil) && l.UseCount < l.MaxUses
+}
+
+// CreateShareLink は共有リンクの発行。ValidDays を省略した場合は ShareLinkDefaultValidDays 日有効にする
+type CreateShareLink struct {
+	AccessLevel string `json:"accessLevel"`
+	MaxUses     int    `json:"maxUses"`
+	ValidDays   int    `json:"validDays"`
+}
+
+func (c *CreateShareLink) Validate() error {
+	return validation.Validate(
+		validation.Field("accessLevel", c.AccessLevel, validation.Required[string](), validation.OneOf(AccessLevelEditor, AccessLevelViewer)),
+		validation.Field("maxUses", c.MaxUses, validation.Required[int](), validation.Range(1, ShareLinkMaxUses)),
+		validation.Field("validDays", c.ValidDays, validation.Range(1, ShareLinkMaxValidDays)),
+	)
+}
+
+// RedeemShareLink は共有リンクを使ったカレンダーへの参加
+type RedeemShareLink struct {
+	Token string `json:"token"`
+}
+
+func (r *RedeemShareLink) Validate() error {
+	return validation.Validate(
+		validation.Field("token", r.Token, validation.Required[string]()),
+	)
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// TrashRetentionDays はゴミ箱のカレンダー・イベントを DynamoDB の TTL で完全に削除するまでの日数
+const TrashRetentionDays = 30
+
+// TrashExpiry は deletedAt にゴミ箱へ移したアイテムが完全に削除される日時を返す
+func TrashExpiry(deletedAt time.Time) time.Time {
+	return deletedAt.AddDate(0, 0, TrashRetentionDays)
+}
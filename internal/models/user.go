@@ -1,7 +1,41 @@
 package models
 
+import "bonded/internal/validation"
+
+// メンバーの権限
+const (
+	AccessLevelOwner  = "OWNER"  // オーナー
+	AccessLevelEditor = "EDITOR" // 編集者
+	AccessLevelViewer = "VIEWER" // 閲覧者
+)
+
+// MaxDisplayNameLength は表示名の最大文字数
+const MaxDisplayNameLength = 50
+
 type User struct {
 	UserID      string `json:"userId" dynamodbav:"UserID"`           // ユーザーID
 	DisplayName string `json:"displayName" dynamodbav:"DisplayName"` // 表示名
 	AccessLevel string `json:"accessLevel" dynamodbav:"AccessLevel"` // 権限（OWNER/EDITOR/VIEWER）
 }
+
+// ChangeAccessLevel はメンバーの権限の変更
+type ChangeAccessLevel struct {
+	AccessLevel string `json:"accessLevel"`
+}
+
+func (c *ChangeAccessLevel) Validate() error {
+	return validation.Validate(
+		validation.Field("accessLevel", c.AccessLevel, validation.Required[string](), validation.OneOf(AccessLevelEditor, AccessLevelViewer)),
+	)
+}
+
+// TransferOwnership はオーナーの移譲
+type TransferOwnership struct {
+	UserID string `json:"userId"` // 新しいオーナーのユーザーID
+}
+
+func (t *TransferOwnership) Validate() error {
+	return validation.Validate(
+		validation.Field("userId", t.UserID, validation.Required[string]()),
+	)
+}
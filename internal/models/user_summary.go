@@ -0,0 +1,7 @@
+package models
+
+// UserSummary はユーザー検索の結果。メールアドレスなどは含めず、招待に必要な情報だけを返す
+type UserSummary struct {
+	UserID      string `json:"userId"`      // ユーザーID
+	DisplayName string `json:"displayName"` // 表示名
+}
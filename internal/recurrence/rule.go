@@ -0,0 +1,475 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency は RRULE の FREQ
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// WeekdayNum は BYDAY の要素（例: MO, 2TU, -1FR）
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int // 0 の場合は序数指定なし
+}
+
+// Rule は RFC 5545 の RRULE を表す
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	UntilDate  bool // UNTIL が DATE 形式で指定された場合 true
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+var weekdayNames = map[time.Weekday]string{
+	time.Sunday:    "SU",
+	time.Monday:    "MO",
+	time.Tuesday:   "TU",
+	time.Wednesday: "WE",
+	time.Thursday:  "TH",
+	time.Friday:    "FR",
+	time.Saturday:  "SA",
+}
+
+const (
+	untilDateTimeFormat = "20060102T150405Z"
+	untilDateFormat     = "20060102"
+)
+
+// Parse は RRULE の値（"RRULE:" プレフィックスは任意）を解析する
+func Parse(value string) (*Rule, error) {
+	value = strings.TrimSpace(value)
+	if len(value) >= len("RRULE:") && strings.EqualFold(value[:len("RRULE:")], "RRULE:") {
+		value = value[len("RRULE:"):]
+	}
+	if value == "" {
+		return nil, errors.New("empty RRULE")
+	}
+
+	rule := &Rule{Interval: 1, WeekStart: time.Monday}
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid RRULE part %q", part)
+		}
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			switch Frequency(strings.ToUpper(val)) {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = Frequency(strings.ToUpper(val))
+			default:
+				return nil, fmt.Errorf("unsupported FREQ %q", val)
+			}
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+			if err == nil && rule.Interval < 1 {
+				err = errors.New("must be positive")
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+			if err == nil && rule.Count < 1 {
+				err = errors.New("must be positive")
+			}
+		case "UNTIL":
+			err = rule.parseUntil(val)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseIntList(val, -31, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseIntList(val, 1, 12)
+			for _, m := range months {
+				rule.ByMonth = append(rule.ByMonth, time.Month(m))
+			}
+		case "WKST":
+			wd, ok := weekdayCodes[strings.ToUpper(val)]
+			if !ok {
+				err = errors.New("unknown weekday")
+			}
+			rule.WeekStart = wd
+		default:
+			return nil, fmt.Errorf("unsupported RRULE part %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid RRULE %s: %w", key, err)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, errors.New("RRULE requires FREQ")
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, errors.New("RRULE must not contain both COUNT and UNTIL")
+	}
+	if !rule.hasMonthDay() {
+		return nil, errors.New("RRULE never produces an occurrence (BYMONTHDAY does not exist in BYMONTH)")
+	}
+	return rule, nil
+}
+
+// monthLengths は各月の最大日数（うるう年を含む）
+var monthLengths = [...]int{time.January: 31, time.February: 29, time.March: 31, time.April: 30, time.May: 31, time.June: 30,
+	time.July: 31, time.August: 31, time.September: 30, time.October: 31, time.November: 30, time.December: 31}
+
+// hasMonthDay は BYMONTHDAY のいずれかが BYMONTH のいずれかの月に存在するかを返す。
+// 存在しない組み合わせ（例: BYMONTH=2;BYMONTHDAY=30）は発生を1つも作らない
+func (r *Rule) hasMonthDay() bool {
+	if len(r.ByMonthDay) == 0 || len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		for _, md := range r.ByMonthDay {
+			if md <= monthLengths[m] && -md <= monthLengths[m] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *Rule) parseUntil(val string) error {
+	if t, err := time.Parse(untilDateTimeFormat, val); err == nil {
+		r.Until = t
+		return nil
+	}
+	if t, err := time.Parse(untilDateFormat, val); err == nil {
+		r.Until = t
+		r.UntilDate = true
+		return nil
+	}
+	return fmt.Errorf("unsupported UNTIL value %q", val)
+}
+
+func parseByDay(val string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(val, ",") {
+		item = strings.ToUpper(strings.TrimSpace(item))
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid weekday %q", item)
+		}
+		wd, ok := weekdayCodes[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", item)
+		}
+		n := 0
+		if prefix := item[:len(item)-2]; prefix != "" {
+			var err error
+			n, err = strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("invalid weekday ordinal %q", item)
+			}
+		}
+		days = append(days, WeekdayNum{Weekday: wd, N: n})
+	}
+	return days, nil
+}
+
+func parseIntList(val string, min, max int) ([]int, error) {
+	var values []int
+	for _, item := range strings.Split(val, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || n == 0 || n < min || n > max {
+			return nil, fmt.Errorf("invalid value %q", item)
+		}
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+// String は RRULE の値を RFC 5545 形式で返す（"RRULE:" プレフィックスなし）
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		if r.UntilDate {
+			parts = append(parts, "UNTIL="+r.Until.Format(untilDateFormat))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilDateTimeFormat))
+		}
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, d := range r.ByDay {
+			if d.N != 0 {
+				days = append(days, strconv.Itoa(d.N)+weekdayNames[d.Weekday])
+			} else {
+				days = append(days, weekdayNames[d.Weekday])
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]int, 0, len(r.ByMonth))
+		for _, m := range r.ByMonth {
+			months = append(months, int(m))
+		}
+		parts = append(parts, "BYMONTH="+joinInts(months))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayNames[r.WeekStart])
+	}
+	return strings.Join(parts, ";")
+}
+
+func joinInts(values []int) string {
+	s := make([]string, 0, len(values))
+	for _, v := range values {
+		s = append(s, strconv.Itoa(v))
+	}
+	return strings.Join(s, ",")
+}
+
+// until は start のロケーションを考慮した UNTIL の上限（この時刻を含む）を返す
+func (r *Rule) until(loc *time.Location) time.Time {
+	if r.Until.IsZero() {
+		return time.Time{}
+	}
+	if r.UntilDate {
+		y, m, d := r.Until.Date()
+		return time.Date(y, m, d, 23, 59, 59, 0, loc)
+	}
+	return r.Until
+}
+
+// candidates は期間の起点 period に含まれる発生候補を昇順で返す
+func (r *Rule) candidates(start, period time.Time) []time.Time {
+	loc := start.Location()
+	hour, min, sec := start.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, min, sec, start.Nanosecond(), loc)
+	}
+
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		days = []time.Time{period}
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			days = []time.Time{period}
+			break
+		}
+		weekStart := r.periodStart(period)
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			if r.matchesWeekday(day) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		days = r.monthDays(period.Year(), period.Month(), start, at)
+	case Yearly:
+		if len(r.ByMonth) == 0 && len(r.ByMonthDay) == 0 && len(r.ByDay) > 0 {
+			days = r.yearWeekdays(period.Year(), at)
+			break
+		}
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{start.Month()}
+		}
+		for _, m := range months {
+			days = append(days, r.monthDays(period.Year(), m, start, at)...)
+		}
+	}
+
+	result := make([]time.Time, 0, len(days))
+	for _, day := range days {
+		if !r.matchesFilters(day) {
+			continue
+		}
+		result = append(result, at(day.Year(), day.Month(), day.Day()))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return result
+}
+
+func (r *Rule) monthDays(year int, month time.Month, start time.Time, at func(int, time.Month, int) time.Time) []time.Time {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	var days []time.Time
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, md := range r.ByMonthDay {
+			d := md
+			if md < 0 {
+				d = lastDay + md + 1
+			}
+			if d < 1 || d > lastDay {
+				continue
+			}
+			day := at(year, month, d)
+			if len(r.ByDay) == 0 || r.matchesWeekdayInMonth(day, lastDay) {
+				days = append(days, day)
+			}
+		}
+	case len(r.ByDay) > 0:
+		for d := 1; d <= lastDay; d++ {
+			day := at(year, month, d)
+			if r.matchesWeekdayInMonth(day, lastDay) {
+				days = append(days, day)
+			}
+		}
+	default:
+		if start.Day() <= lastDay {
+			days = append(days, at(year, month, start.Day()))
+		}
+	}
+	return days
+}
+
+func (r *Rule) yearWeekdays(year int, at func(int, time.Month, int) time.Time) []time.Time {
+	lastDay := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	var days []time.Time
+	for day := at(year, time.January, 1); day.Year() == year; day = day.AddDate(0, 0, 1) {
+		for _, wd := range r.ByDay {
+			if day.Weekday() != wd.Weekday {
+				continue
+			}
+			if wd.N == 0 {
+				days = append(days, day)
+				break
+			}
+			nth := (day.YearDay()-1)/7 + 1
+			nthFromEnd := -((lastDay-day.YearDay())/7 + 1)
+			if wd.N == nth || wd.N == nthFromEnd {
+				days = append(days, day)
+				break
+			}
+		}
+	}
+	return days
+}
+
+func (r *Rule) matchesWeekday(day time.Time) bool {
+	for _, wd := range r.ByDay {
+		if wd.Weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesWeekdayInMonth(day time.Time, lastDay int) bool {
+	for _, wd := range r.ByDay {
+		if wd.Weekday != day.Weekday() {
+			continue
+		}
+		if wd.N == 0 {
+			return true
+		}
+		nth := (day.Day()-1)/7 + 1
+		nthFromEnd := -((lastDay-day.Day())/7 + 1)
+		if wd.N == nth || wd.N == nthFromEnd {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesFilters は期間の展開に使われなかった BYxxx による絞り込みを行う
+func (r *Rule) matchesFilters(day time.Time) bool {
+	if len(r.ByMonth) > 0 && r.Freq != Yearly {
+		found := false
+		for _, m := range r.ByMonth {
+			if m == day.Month() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if r.Freq == Daily || r.Freq == Weekly {
+		if len(r.ByMonthDay) > 0 {
+			lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+			found := false
+			for _, md := range r.ByMonthDay {
+				if md == day.Day() || lastDay+md+1 == day.Day() {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		if r.Freq == Daily && len(r.ByDay) > 0 && !r.matchesWeekday(day) {
+			return false
+		}
+	}
+	return true
+}
+
+// nextPeriod は FREQ と INTERVAL に従って次の期間の起点を返す
+func (r *Rule) nextPeriod(period time.Time, n int) time.Time {
+	switch r.Freq {
+	case Daily:
+		return period.AddDate(0, 0, n*r.Interval)
+	case Weekly:
+		return period.AddDate(0, 0, 7*n*r.Interval)
+	case Monthly:
+		return time.Date(period.Year(), period.Month()+time.Month(n*r.Interval), 1, 0, 0, 0, 0, period.Location())
+	default:
+		return time.Date(period.Year()+n*r.Interval, time.January, 1, 0, 0, 0, 0, period.Location())
+	}
+}
+
+// periodStart は期間 period に含まれる発生候補のうち最も早い日の起点を返す（WEEKLY では週の始まり）
+func (r *Rule) periodStart(period time.Time) time.Time {
+	if r.Freq == Weekly {
+		return period.AddDate(0, 0, -int((7+period.Weekday()-r.WeekStart)%7))
+	}
+	return period
+}
+
+func (r *Rule) firstPeriod(start time.Time) time.Time {
+	loc := start.Location()
+	switch r.Freq {
+	case Monthly:
+		return time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, loc)
+	case Yearly:
+		return time.Date(start.Year(), time.January, 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	}
+}
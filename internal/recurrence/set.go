@@ -0,0 +1,143 @@
+package recurrence
+
+import (
+	"sort"
+	"time"
+)
+
+// maxPeriods は展開時に走査する期間数の上限（無限ループ防止）
+const maxPeriods = 100000
+
+// Set は DTSTART・RRULE・RDATE・EXDATE から成る発生日時の集合
+type Set struct {
+	Start   time.Time
+	Rule    *Rule
+	RDates  []time.Time
+	ExDates []time.Time
+}
+
+// Between は from 以上 to 未満に開始する発生日時を昇順で返す
+func (s *Set) Between(from, to time.Time) []time.Time {
+	var result []time.Time
+	s.iterate(to, func(t time.Time) bool {
+		if !t.Before(to) {
+			return false
+		}
+		if !t.Before(from) {
+			result = append(result, t)
+		}
+		return true
+	})
+	return result
+}
+
+// Contains は t が集合に含まれる発生日時かを返す
+func (s *Set) Contains(t time.Time) bool {
+	found := false
+	s.iterate(t, func(o time.Time) bool {
+		if o.Equal(t) {
+			found = true
+			return false
+		}
+		return o.Before(t)
+	})
+	return found
+}
+
+// CountBefore は t より前に開始する RRULE 由来の発生回数を返す（COUNT の分割に使う）
+func (s *Set) CountBefore(t time.Time) int {
+	count := 0
+	s.iterateRule(t, func(o time.Time) bool {
+		if !o.Before(t) {
+			return false
+		}
+		count++
+		return true
+	})
+	return count
+}
+
+// iterate は EXDATE を除いた発生日時を昇順に yield へ渡す。yield が false を返すか limit を過ぎると終了する
+func (s *Set) iterate(limit time.Time, yield func(time.Time) bool) {
+	rdates := make([]time.Time, len(s.RDates))
+	copy(rdates, s.RDates)
+	sort.Slice(rdates, func(i, j int) bool { return rdates[i].Before(rdates[j]) })
+
+	var last time.Time
+	emit := func(t time.Time) bool {
+		if !last.IsZero() && t.Equal(last) {
+			return true
+		}
+		last = t
+		if s.excluded(t) {
+			return true
+		}
+		return yield(t)
+	}
+
+	stopped := false
+	s.iterateRule(limit, func(t time.Time) bool {
+		for len(rdates) > 0 && !rdates[0].After(t) {
+			if !emit(rdates[0]) {
+				stopped = true
+				return false
+			}
+			rdates = rdates[1:]
+		}
+		if !emit(t) {
+			stopped = true
+			return false
+		}
+		return true
+	})
+	if stopped {
+		return
+	}
+	for _, t := range rdates {
+		if !emit(t) {
+			return
+		}
+	}
+}
+
+// iterateRule は DTSTART と RRULE による発生日時を昇順に yield へ渡す。
+// 発生のない期間が続いても limit を過ぎた期間は走査しない
+func (s *Set) iterateRule(limit time.Time, yield func(time.Time) bool) {
+	if !yield(s.Start) {
+		return
+	}
+	if s.Rule == nil {
+		return
+	}
+
+	until := s.Rule.until(s.Start.Location())
+	count := 1
+	period := s.Rule.firstPeriod(s.Start)
+	for i := 0; i < maxPeriods && !s.Rule.periodStart(period).After(limit); i++ {
+		for _, t := range s.Rule.candidates(s.Start, period) {
+			if !t.After(s.Start) {
+				continue
+			}
+			if !until.IsZero() && t.After(until) {
+				return
+			}
+			if s.Rule.Count > 0 && count >= s.Rule.Count {
+				return
+			}
+			count++
+			if !yield(t) {
+				return
+			}
+		}
+		period = s.Rule.nextPeriod(period, 1)
+	}
+}
+
+func (s *Set) excluded(t time.Time) bool {
+	for _, ex := range s.ExDates {
+		if ex.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 9, 0, 0, 0, time.UTC)
+}
+
+func mustParse(t *testing.T, value string) *Rule {
+	t.Helper()
+	rule, err := Parse(value)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", value, err)
+	}
+	return rule
+}
+
+func assertTimes(t *testing.T, got, want []time.Time) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("occurrences = %v, want %v", got, want)
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("occurrence %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSetBetween(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   time.Time
+		rrule   string
+		rdates  []time.Time
+		exdates []time.Time
+		from    time.Time
+		to      time.Time
+		want    []time.Time
+	}{
+		{
+			name:  "daily COUNT",
+			start: date(2024, 4, 1), rrule: "FREQ=DAILY;COUNT=3",
+			from: date(2024, 1, 1), to: date(2025, 1, 1),
+			want: []time.Time{date(2024, 4, 1), date(2024, 4, 2), date(2024, 4, 3)},
+		},
+		{
+			name:  "daily UNTIL date includes the last day",
+			start: date(2024, 4, 1), rrule: "FREQ=DAILY;INTERVAL=2;UNTIL=20240405",
+			from: date(2024, 1, 1), to: date(2025, 1, 1),
+			want: []time.Time{date(2024, 4, 1), date(2024, 4, 3), date(2024, 4, 5)},
+		},
+		{
+			name:  "daily UNTIL date-time",
+			start: date(2024, 4, 1), rrule: "FREQ=DAILY;UNTIL=20240403T085959Z",
+			from: date(2024, 1, 1), to: date(2025, 1, 1),
+			want: []time.Time{date(2024, 4, 1), date(2024, 4, 2)},
+		},
+		{
+			name:  "weekly BYDAY",
+			start: date(2024, 4, 1), rrule: "FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=5",
+			from: date(2024, 1, 1), to: date(2025, 1, 1),
+			want: []time.Time{date(2024, 4, 1), date(2024, 4, 3), date(2024, 4, 5), date(2024, 4, 8), date(2024, 4, 10)},
+		},
+		{
+			name:  "monthly BYMONTHDAY skips short months",
+			start: date(2024, 1, 31), rrule: "FREQ=MONTHLY;BYMONTHDAY=31;COUNT=3",
+			from: date(2024, 1, 1), to: date(2025, 1, 1),
+			want: []time.Time{date(2024, 1, 31), date(2024, 3, 31), date(2024, 5, 31)},
+		},
+		{
+			name:  "monthly negative BYMONTHDAY",
+			start: date(2024, 1, 31), rrule: "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3",
+			from: date(2024, 1, 1), to: date(2025, 1, 1),
+			want: []time.Time{date(2024, 1, 31), date(2024, 2, 29), date(2024, 3, 31)},
+		},
+		{
+			name:  "monthly ordinal BYDAY",
+			start: date(2024, 4, 9), rrule: "FREQ=MONTHLY;BYDAY=2TU,-1FR;COUNT=4",
+			from: date(2024, 1, 1), to: date(2025, 1, 1),
+			want: []time.Time{date(2024, 4, 9), date(2024, 4, 26), date(2024, 5, 14), date(2024, 5, 31)},
+		},
+		{
+			name:  "yearly BYMONTH",
+			start: date(2024, 3, 10), rrule: "FREQ=YEARLY;BYMONTH=3,9;COUNT=3",
+			from: date(2024, 1, 1), to: date(2030, 1, 1),
+			want: []time.Time{date(2024, 3, 10), date(2024, 9, 10), date(2025, 3, 10)},
+		},
+		{
+			name:  "yearly leap day",
+			start: date(2024, 2, 29), rrule: "FREQ=YEARLY",
+			from: date(2024, 1, 1), to: date(2033, 1, 1),
+			want: []time.Time{date(2024, 2, 29), date(2028, 2, 29), date(2032, 2, 29)},
+		},
+		{
+			name:  "EXDATE removes an occurrence and RDATE adds one",
+			start: date(2024, 4, 1), rrule: "FREQ=DAILY;COUNT=3",
+			rdates: []time.Time{date(2024, 4, 10)}, exdates: []time.Time{date(2024, 4, 2)},
+			from: date(2024, 1, 1), to: date(2025, 1, 1),
+			want: []time.Time{date(2024, 4, 1), date(2024, 4, 3), date(2024, 4, 10)},
+		},
+		{
+			name:  "window",
+			start: date(2024, 4, 1), rrule: "FREQ=DAILY",
+			from: date(2024, 4, 10), to: date(2024, 4, 12),
+			want: []time.Time{date(2024, 4, 10), date(2024, 4, 11)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := &Set{Start: tt.start, Rule: mustParse(t, tt.rrule), RDates: tt.rdates, ExDates: tt.exdates}
+			assertTimes(t, set.Between(tt.from, tt.to), tt.want)
+		})
+	}
+}
+
+func TestSetContainsAndCountBefore(t *testing.T) {
+	set := &Set{Start: date(2024, 4, 1), Rule: mustParse(t, "FREQ=WEEKLY;COUNT=4"), ExDates: []time.Time{date(2024, 4, 8)}}
+
+	if !set.Contains(date(2024, 4, 15)) || set.Contains(date(2024, 4, 8)) || set.Contains(date(2024, 4, 29)) {
+		t.Error("Contains does not follow COUNT and EXDATE")
+	}
+	// COUNT の分割では EXDATE で除いた発生も数える
+	if got := set.CountBefore(date(2024, 4, 15)); got != 2 {
+		t.Errorf("CountBefore = %d, want 2", got)
+	}
+}
+
+func TestSetStopsAtLimit(t *testing.T) {
+	// 2月に開始し毎年30日に繰り返すルールは発生を作らないが、期間を過ぎれば走査をやめる
+	set := &Set{Start: date(2024, 2, 10), Rule: mustParse(t, "FREQ=YEARLY;BYMONTHDAY=30")}
+	assertTimes(t, set.Between(date(2024, 1, 1), date(2030, 1, 1)), []time.Time{date(2024, 2, 10)})
+	if set.Contains(date(2026, 2, 30)) {
+		t.Error("Contains found a date the rule never produces")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "RRULE:FREQ=WEEKLY", want: "FREQ=WEEKLY"},
+		{value: "rrule:freq=monthly;interval=1;bymonthday=-1", want: "FREQ=MONTHLY;BYMONTHDAY=-1"},
+		{value: "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29", want: "FREQ=YEARLY;BYMONTHDAY=29;BYMONTH=2"},
+		{value: "FREQ=MONTHLY;BYMONTH=2;BYMONTHDAY=30", wantErr: true},
+		{value: "FREQ=DAILY;BYMONTH=4,6;BYMONTHDAY=-31", wantErr: true},
+		{value: "FREQ=DAILY;COUNT=2;UNTIL=20240401", wantErr: true},
+		{value: "FREQ=HOURLY", wantErr: true},
+		{value: "INTERVAL=2", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			rule, err := Parse(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse = %s, want an error", rule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := rule.String(); got != tt.want {
+				t.Errorf("String = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
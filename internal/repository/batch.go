@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// batchWriteLimit は BatchWriteItem 1回で書き込めるアイテムの上限
+const batchWriteLimit = 25
+
+// batchMaxAttempts は UnprocessedItems を再送する回数の上限
+const batchMaxAttempts = 8
+
+// batchDelete は keys のアイテムを batchWriteLimit 件ずつ削除する。
+// 処理されなかったアイテムは間隔を空けて再送する。削除は冪等なので、失敗した場合も最初からやり直せる
+func batchDelete(ctx context.Context, client *dynamodb.DynamoDB, tableName string, keys []item) error {
+	for start := 0; start < len(keys); start += batchWriteLimit {
+		chunk := keys[start:min(start+batchWriteLimit, len(keys))]
+		requests := make([]*dynamodb.WriteRequest, 0, len(chunk))
+		for _, key := range chunk {
+			requests = append(requests, &dynamodb.WriteRequest{
+				DeleteRequest: &dynamodb.DeleteRequest{Key: key},
+			})
+		}
+		err := batchWrite(ctx, client, map[string][]*dynamodb.WriteRequest{tableName: requests})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func batchWrite(ctx context.Context, client *dynamodb.DynamoDB, requests map[string][]*dynamodb.WriteRequest) error {
+	backoff := 50 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		result, err := client.BatchWriteItemWithContext(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: requests,
+		})
+		if err != nil {
+			return err
+		}
+		if len(result.UnprocessedItems) == 0 {
+			return nil
+		}
+		if attempt == batchMaxAttempts {
+			unprocessed := 0
+			for _, items := range result.UnprocessedItems {
+				unprocessed += len(items)
+			}
+			return fmt.Errorf("batch write: %d items still unprocessed after %d attempts", unprocessed, attempt)
+		}
+
+		requests = result.UnprocessedItems
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -11,29 +11,7 @@ import (
 )
 
 func (r *calendarRepository) Create(ctx context.Context, calendar *models.Calendar) error {
-	// 1. 関連アイテムの作成
-	relatedItem := map[string]*dynamodb.AttributeValue{
-		"CalendarID": {
-			S: aws.String(calendar.CalendarID),
-		},
-		"SortKey": {
-			S: aws.String(fmt.Sprintf("CAL#%s#%s", calendar.CalendarID, calendar.OwnerUserID)),
-		},
-		"UserID": {
-			S: aws.String(calendar.OwnerUserID),
-		},
-	}
-
-	relatedInput := &dynamodb.PutItemInput{
-		TableName: aws.String(r.tableName),
-		Item:      relatedItem,
-	}
-	_, err := r.dynamoDB.PutItemWithContext(ctx, relatedInput)
-	if err != nil {
-		return err
-	}
-
-	// 2. メインカレンダーアイテムの作成
+	// メインカレンダーアイテム
 	mainItem := map[string]*dynamodb.AttributeValue{
 		"SortKey": {
 			S: aws.String("CALENDAR"),
@@ -51,52 +29,38 @@ func (r *calendarRepository) Create(ctx context.Context, calendar *models.Calend
 			S: aws.String(calendar.Name),
 		},
 	}
-
-	mainInput := &dynamodb.PutItemInput{
-		TableName: aws.String(r.tableName),
-		Item:      mainItem,
+	if calendar.TimeZone != "" {
+		mainItem["TimeZone"] = &dynamodb.AttributeValue{S: aws.String(calendar.TimeZone)}
 	}
-
-	_, err = r.dynamoDB.PutItemWithContext(ctx, mainInput)
-	if err != nil {
-		return err
+	if calendar.OwnerName != "" {
+		mainItem["OwnerName"] = &dynamodb.AttributeValue{S: aws.String(calendar.OwnerName)}
 	}
-
-	// 3. ユーザーアイテムの作成
-	userItem := map[string]*dynamodb.AttributeValue{
-		"CalendarID": {
-			S: aws.String(calendar.CalendarID),
-		},
-		"UserID": {
-			S: aws.String(calendar.Users[0].UserID),
-		},
-		"DisplayName": {
-			S: aws.String(calendar.Users[0].DisplayName),
-		},
-		"SortKey": {
-			S: aws.String(fmt.Sprintf("USER#%s", calendar.Users[0].UserID)),
-		},
-		"AccessLevel": {
-			S: aws.String(calendar.Users[0].AccessLevel),
-		},
+	mainItem["FollowerCount"] = &dynamodb.AttributeValue{N: aws.String("0")}
+	mainItem["Version"] = &dynamodb.AttributeValue{N: aws.String("1")}
+	// 公開カレンダーは一覧用の GSI に載せる
+	if isPublic(calendar) {
+		mainItem["PublicListing"] = &dynamodb.AttributeValue{S: aws.String(publicListing)}
+		mainItem["PublicSortKey"] = &dynamodb.AttributeValue{S: aws.String(publicSortKey(calendar))}
 	}
 
-	userInput := &dynamodb.PutItemInput{
-		TableName: aws.String(r.tableName),
-		Item:      userItem,
-	}
-	_, err = r.dynamoDB.PutItemWithContext(ctx, userInput)
-	if err != nil {
-		return err
+	// カレンダー、関連アイテム、オーナーのユーザーアイテムをまとめて作成する
+	owner := calendar.Users[0]
+	items := []*dynamodb.TransactWriteItem{
+		{Put: r.newItem(mainItem)},
+		{Put: r.newItem(relatedItem(calendar.CalendarID, owner.UserID))},
+		{Put: r.newItem(memberItem(calendar.CalendarID, &owner, owner.AccessLevel))},
 	}
-
-	return nil
+	return transactWrite(ctx, r.dynamoDB, items, []error{
+		fmt.Errorf("%w: calendar %s already exists", ErrConflict, calendar.CalendarID),
+	})
 }
 
-func (r *calendarRepository) Edit(ctx context.Context, calendarID *models.Calendar, input *models.Calendar) error {
-	calendar, err := r.FindByCalendarID(ctx, calendarID.CalendarID)
+// Edit はカレンダーの属性を更新し、更新後の CALENDAR アイテムを返す。
+// 版数が version から変わっていた場合は ErrPreconditionFailed を返す
+func (r *calendarRepository) Edit(ctx context.Context, calendarID *models.Calendar, input *models.EditCalendar, version int64) (*models.Calendar, error) {
+	calendar, err := r.findCalendarItem(ctx, calendarID.CalendarID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if input.Name != "" {
@@ -105,80 +69,91 @@ func (r *calendarRepository) Edit(ctx context.Context, calendarID *models.Calend
 	if input.IsPublic != nil {
 		calendar.IsPublic = input.IsPublic
 	}
-	if input.OwnerUserID != "" {
-		calendar.OwnerUserID = input.OwnerUserID
+	if input.TimeZone != "" {
+		calendar.TimeZone = input.TimeZone
 	}
 
-	item, err := dynamodbattribute.MarshalMap(calendar)
-	if err != nil {
-		return err
+	// イベントやメンバーを CALENDAR アイテムに書き込まないよう、カレンダー自体の属性だけを更新する。
+	// オーナーはメンバーの権限と合わせて変える必要があるため TransferOwnership でだけ変更する
+	expression := "SET #name = :name, IsPublic = :isPublic, Version = :nextVersion"
+	attributeValues := versionValues(version, map[string]*dynamodb.AttributeValue{
+		":name":     {S: aws.String(calendar.Name)},
+		":isPublic": {BOOL: calendar.IsPublic},
+	})
+	if calendar.TimeZone != "" {
+		expression += ", TimeZone = :timeZone"
+		attributeValues[":timeZone"] = &dynamodb.AttributeValue{S: aws.String(calendar.TimeZone)}
 	}
-	item["SortKey"] = &dynamodb.AttributeValue{S: aws.String("CALENDAR")}
-	item["UserID"] = &dynamodb.AttributeValue{S: aws.String(calendar.OwnerUserID)}
-	item["IsPublic"] = &dynamodb.AttributeValue{BOOL: calendar.IsPublic}
-	updateInput := &dynamodb.PutItemInput{
-		TableName: aws.String(r.tableName),
-		Item:      item,
+	if isPublic(calendar) {
+		expression += ", PublicListing = :listing, PublicSortKey = :publicSortKey"
+		attributeValues[":listing"] = &dynamodb.AttributeValue{S: aws.String(publicListing)}
+		attributeValues[":publicSortKey"] = &dynamodb.AttributeValue{S: aws.String(publicSortKey(calendar))}
+	} else {
+		expression += " REMOVE PublicListing, PublicSortKey"
 	}
-	_, err = r.dynamoDB.PutItemWithContext(ctx, updateInput)
-	return err
-}
 
-func (r *calendarRepository) Delete(ctx context.Context, calendarID string) error {
-	input := &dynamodb.DeleteItemInput{
+	updateInput := &dynamodb.UpdateItemInput{
 		TableName: aws.String(r.tableName),
 		Key: map[string]*dynamodb.AttributeValue{
-			"CalendarID": {S: aws.String(calendarID)},
+			"CalendarID": {S: aws.String(calendar.CalendarID)},
 			"SortKey":    {S: aws.String("CALENDAR")},
 		},
+		UpdateExpression:          aws.String(expression),
+		ConditionExpression:       aws.String("attribute_exists(SortKey) AND " + notTrashed + " AND " + versionCondition(version)),
+		ExpressionAttributeNames:  map[string]*string{"#name": aws.String("Name")},
+		ExpressionAttributeValues: attributeValues,
+		ReturnValues:              aws.String(dynamodb.ReturnValueAllNew),
 	}
-	_, err := r.dynamoDB.DeleteItemWithContext(ctx, input)
-	return err
-}
-
-func (r *calendarRepository) FindByCalendarID(ctx context.Context, calendarID string) (*models.Calendar, error) {
-	// カレンダー情報を取得　（カレンダーとイベント、ユーザー情報を取得。カレンダー情報だけにするべき？）
-	input := &dynamodb.GetItemInput{
-		TableName: aws.String(r.tableName),
-		Key: map[string]*dynamodb.AttributeValue{
-			"CalendarID": {S: aws.String(calendarID)},
-			"SortKey":    {S: aws.String("CALENDAR")},
-		},
+	result, err := r.dynamoDB.UpdateItemWithContext(ctx, updateInput)
+	if isConditionalCheckFailed(err) {
+		return nil, fmt.Errorf("%w: calendar %s has been modified", ErrPreconditionFailed, calendar.CalendarID)
 	}
-	result, err := r.dynamoDB.GetItemWithContext(ctx, input)
 	if err != nil {
 		return nil, err
 	}
-	if result.Item == nil {
-		return nil, fmt.Errorf("calendar with CalendarID %s not found", calendarID)
+
+	var updated models.Calendar
+	err = dynamodbattribute.UnmarshalMap(result.Attributes, &updated)
+	if err != nil {
+		return nil, err
 	}
-	var calendar models.Calendar
-	err = dynamodbattribute.UnmarshalMap(result.Item, &calendar)
+	return &updated, nil
+}
+
+// FindByCalendarID はカレンダーとイベント、メンバーを取得する。ゴミ箱のカレンダーとイベントは含まない
+func (r *calendarRepository) FindByCalendarID(ctx context.Context, calendarID string) (*models.Calendar, error) {
+	// カレンダー情報を取得　（カレンダーとイベント、ユーザー情報を取得。カレンダー情報だけにするべき？）
+	calendar, err := r.findCalendarItem(ctx, calendarID)
 	if err != nil {
 		return nil, err
 	}
+	if calendar.DeletedAt != nil {
+		return nil, fmt.Errorf("%w: calendar %s is in the trash", ErrNotFound, calendarID)
+	}
 
 	// 関連するイベントを取得
 	eventInput := &dynamodb.QueryInput{
 		TableName:              aws.String(r.tableName),
 		KeyConditionExpression: aws.String("CalendarID = :cid AND begins_with(SortKey, :sk)"),
+		FilterExpression:       aws.String(notTrashed),
 		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
 			":cid": {S: aws.String(calendarID)},
 			":sk":  {S: aws.String("EVENT#")},
 		},
 	}
-	eventResult, err := r.dynamoDB.QueryWithContext(ctx, eventInput)
+	eventItems, err := queryAll(ctx, r.dynamoDB, eventInput)
 	if err != nil {
 		return nil, err
 	}
 
-	var events []models.Event
-	err = dynamodbattribute.UnmarshalListOfMaps(eventResult.Items, &events)
+	events, err := unmarshalEvents(eventItems)
 	if err != nil {
 		return nil, err
 	}
-
-	calendar.Events = events
+	// 個別の発生は繰り返し元にまとめ、同じ eventId のイベントが並ばないようにする
+	for _, event := range FoldOverrides(events) {
+		calendar.Events = append(calendar.Events, *event)
+	}
 
 	// 関連するユーザーを取得
 	userInput := &dynamodb.QueryInput{
@@ -189,243 +164,183 @@ func (r *calendarRepository) FindByCalendarID(ctx context.Context, calendarID st
 			":sk":  {S: aws.String("USER#")},
 		},
 	}
-	userResult, err := r.dynamoDB.QueryWithContext(ctx, userInput)
+	userItems, err := queryAll(ctx, r.dynamoDB, userInput)
 	if err != nil {
 		return nil, err
 	}
 	var users []models.User
-	err = dynamodbattribute.UnmarshalListOfMaps(userResult.Items, &users)
+	err = dynamodbattribute.UnmarshalListOfMaps(userItems, &users)
 	if err != nil {
 		return nil, err
 	}
 	calendar.Users = users
 
-	return &calendar, nil
+	return calendar, nil
 }
 
-func (r *calendarRepository) FindByUserID(ctx context.Context, userID string) ([]*models.Calendar, error) {
-	// GSIを使用してユーザーが所属するカレンダーを取得
+// FindByUserID はユーザーが所属するカレンダーの概要を page.Limit 件ずつ取得する
+func (r *calendarRepository) FindByUserID(ctx context.Context, userID string, page models.PageRequest) (*models.Page[*models.CalendarSummary], error) {
+	// GSIを使用してユーザーのメンバーシップ（USER# アイテム）を取得する。
+	// CAL# の関連アイテムも同じ GSI に載るため、カレンダーごとに1件になるよう USER# だけに絞る
 	input := &dynamodb.QueryInput{
 		TableName:              aws.String(r.tableName),
 		IndexName:              aws.String("UserID-index"),
 		KeyConditionExpression: aws.String("UserID = :uid"),
+		FilterExpression:       aws.String("begins_with(SortKey, :sk)"),
 		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
 			":uid": {S: aws.String(userID)},
+			":sk":  {S: aws.String("USER#")},
 		},
 	}
-	result, err := r.dynamoDB.QueryWithContext(ctx, input)
+	items, cursor, err := queryPage(ctx, r.dynamoDB, input, page)
 	if err != nil {
 		return nil, err
 	}
 
-	// カレンダー情報を取得
-	var calendars []*models.Calendar
-	calendarIDSet := make(map[string]struct{})
-	for _, item := range result.Items {
-		calendarID := *item["CalendarID"].S
-		if _, exists := calendarIDSet[calendarID]; !exists {
-			calendar, err := r.FindByCalendarID(ctx, calendarID)
-			if err != nil {
-				return nil, err
-			}
-			calendars = append(calendars, calendar)
-			calendarIDSet[calendarID] = struct{}{}
-		}
-	}
-
-	return calendars, nil
-}
-
-func (r *calendarRepository) FollowCalendar(ctx context.Context, calendar *models.Calendar, user *models.User) error {
-	item, err := dynamodbattribute.MarshalMap(calendar)
-	if err != nil {
-		return err
-	}
-
-	// メインカレンダーアイテムの更新
-	input := &dynamodb.PutItemInput{
-		TableName: aws.String(r.tableName),
-		Item:      item,
-	}
-
-	_, err = r.dynamoDB.PutItemWithContext(ctx, input)
-	if err != nil {
-		return err
-	}
-
-	// 関連アイテムの作成
-	relatedItem := map[string]*dynamodb.AttributeValue{
-		"CalendarID": {
-			S: aws.String(calendar.CalendarID),
-		},
-		"SortKey": {
-			S: aws.String(fmt.Sprintf("CAL#%s#%s", calendar.CalendarID, user.UserID)),
-		},
-		"UserID": {
-			S: aws.String(user.UserID),
-		},
-	}
-
-	relatedInput := &dynamodb.PutItemInput{
-		TableName: aws.String(r.tableName),
-		Item:      relatedItem,
-	}
-	_, err = r.dynamoDB.PutItemWithContext(ctx, relatedInput)
-	if err != nil {
-		return err
-	}
-
-	// ユーザー情報の作成
-	userItem := map[string]*dynamodb.AttributeValue{
-		"CalendarID": {
-			S: aws.String(calendar.CalendarID),
-		},
-		"UserID": {
-			S: aws.String(user.UserID),
-		},
-		"DisplayName": {
-			S: aws.String(user.DisplayName),
-		},
-		"SortKey": {
-			S: aws.String(fmt.Sprintf("USER#%s", user.UserID)),
-		},
-		"AccessLevel": {
-			S: aws.String("VIEWER"),
-		},
-	}
-
-	userInput := &dynamodb.PutItemInput{
-		TableName: aws.String(r.tableName),
-		Item:      userItem,
-	}
-	_, err = r.dynamoDB.PutItemWithContext(ctx, userInput)
+	summaries, err := r.findSummaries(ctx, items)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return &models.Page[*models.CalendarSummary]{Items: summaries, NextCursor: cursor}, nil
 }
 
-func (r *calendarRepository) FindAllCalendars(ctx context.Context) ([]*models.Calendar, error) {
-	// カレンダーのメイン情報を全件取得
-	input := &dynamodb.ScanInput{
-		TableName:        aws.String(r.tableName),
-		FilterExpression: aws.String("SortKey = :sk"),
-		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
-			":sk": {S: aws.String("CALENDAR")},
-		},
+// findSummaries はメンバーシップの CALENDAR アイテムをまとめて取得し、メンバーシップの順にカレンダーの概要を返す。
+// イベントやメンバーは読まない
+func (r *calendarRepository) findSummaries(ctx context.Context, memberships []item) ([]*models.CalendarSummary, error) {
+	keys := make([]map[string]*dynamodb.AttributeValue, 0, len(memberships))
+	for _, membership := range memberships {
+		keys = append(keys, itemKey(*membership["CalendarID"].S, "CALENDAR"))
 	}
-	result, err := r.dynamoDB.ScanWithContext(ctx, input)
+	calendars, err := r.batchGet(ctx, keys)
 	if err != nil {
 		return nil, err
 	}
 
-	// カレンダー情報を取得
-	var calendars []*models.Calendar
-	calendarIDSet := make(map[string]struct{})
-	for _, item := range result.Items {
-		calendarID := *item["CalendarID"].S
-		if _, exists := calendarIDSet[calendarID]; !exists {
-			calendar, err := r.FindByCalendarID(ctx, calendarID)
-			if err != nil {
-				return nil, err
-			}
-			calendars = append(calendars, calendar)
-			calendarIDSet[calendarID] = struct{}{}
+	summaries := make([]*models.CalendarSummary, 0, len(memberships))
+	for _, membership := range memberships {
+		calendar, ok := calendars[*membership["CalendarID"].S]
+		// 削除の途中で残ったメンバーシップやゴミ箱のカレンダーは読み飛ばす
+		if !ok || calendar["DeletedAt"] != nil {
+			continue
 		}
+		var summary models.CalendarSummary
+		err = dynamodbattribute.UnmarshalMap(calendar, &summary)
+		if err != nil {
+			return nil, err
+		}
+		var user models.User
+		err = dynamodbattribute.UnmarshalMap(membership, &user)
+		if err != nil {
+			return nil, err
+		}
+		summary.AccessLevel = user.AccessLevel
+		summaries = append(summaries, &summary)
 	}
-	return calendars, nil
+	return summaries, nil
 }
 
-func (r *calendarRepository) UnfollowCalendar(ctx context.Context, calendar *models.Calendar, user *models.User) error {
-	// メインカレンダーアイテムの更新
-	item, err := dynamodbattribute.MarshalMap(calendar)
-	if err != nil {
-		return err
-	}
-
-	input := &dynamodb.PutItemInput{
-		TableName: aws.String(r.tableName),
-		Item:      item,
-	}
-	_, err = r.dynamoDB.PutItemWithContext(ctx, input)
-	if err != nil {
-		return err
+// batchGet は keys のアイテムを BatchGetItem でまとめて取得し、CalendarID ごとに返す。
+// keys は MaxPageLimit 件（BatchGetItem の上限）までとする
+func (r *calendarRepository) batchGet(ctx context.Context, keys []map[string]*dynamodb.AttributeValue) (map[string]item, error) {
+	result := make(map[string]item, len(keys))
+	requests := map[string]*dynamodb.KeysAndAttributes{}
+	if len(keys) > 0 {
+		requests[r.tableName] = &dynamodb.KeysAndAttributes{Keys: keys}
+	}
+	// 処理されなかったキーは UnprocessedKeys として返るので、なくなるまで読み直す
+	for len(requests) > 0 {
+		output, err := r.dynamoDB.BatchGetItemWithContext(ctx, &dynamodb.BatchGetItemInput{RequestItems: requests})
+		if err != nil {
+			return nil, err
+		}
+		for _, found := range output.Responses[r.tableName] {
+			result[*found["CalendarID"].S] = found
+		}
+		requests = output.UnprocessedKeys
 	}
+	return result, nil
+}
 
-	// 関連アイテムの削除
-	relatedInput := &dynamodb.DeleteItemInput{
+// FindMember はカレンダーの USER# アイテムを取得する。メンバーでない場合は nil を返す
+func (r *calendarRepository) FindMember(ctx context.Context, calendarID string, userID string) (*models.User, error) {
+	input := &dynamodb.GetItemInput{
 		TableName: aws.String(r.tableName),
 		Key: map[string]*dynamodb.AttributeValue{
-			"CalendarID": {S: aws.String(calendar.CalendarID)},
-			"SortKey":    {S: aws.String(fmt.Sprintf("CAL#%s#%s", calendar.CalendarID, user.UserID))},
+			"CalendarID": {S: aws.String(calendarID)},
+			"SortKey":    {S: aws.String(fmt.Sprintf("USER#%s", userID))},
 		},
 	}
-	_, err = r.dynamoDB.DeleteItemWithContext(ctx, relatedInput)
+	result, err := r.dynamoDB.GetItemWithContext(ctx, input)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	// ユーザーアイテムの削除
-	userInput := &dynamodb.DeleteItemInput{
-		TableName: aws.String(r.tableName),
-		Key: map[string]*dynamodb.AttributeValue{
-			"CalendarID": {S: aws.String(calendar.CalendarID)},
-			"SortKey":    {S: aws.String(fmt.Sprintf("USER#%s", user.UserID))},
-		},
+	if result.Item == nil {
+		return nil, nil
 	}
-	_, err = r.dynamoDB.DeleteItemWithContext(ctx, userInput)
+
+	var user models.User
+	err = dynamodbattribute.UnmarshalMap(result.Item, &user)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return &user, nil
 }
 
-func (r *calendarRepository) InviteUser(ctx context.Context, calendar *models.Calendar, user *models.User) error {
-	// 関連アイテムの作成
-	relatedItem := map[string]*dynamodb.AttributeValue{
-		"CalendarID": {
-			S: aws.String(calendar.CalendarID),
-		},
-		"SortKey": {
-			S: aws.String(fmt.Sprintf("CAL#%s#%s", calendar.CalendarID, user.UserID)),
-		},
-		"UserID": {
-			S: aws.String(user.UserID),
-		},
-	}
+func (r *calendarRepository) FollowCalendar(ctx context.Context, calendar *models.Calendar, user *models.User) error {
+	// すでにメンバーの場合は権限を上書きせず、フォロワー数も増やさない
+	items := []*dynamodb.TransactWriteItem{
+		{Put: r.newItem(memberItem(calendar.CalendarID, user, "VIEWER"))},
+		{Put: r.putItem(relatedItem(calendar.CalendarID, user.UserID))},
+		{Update: r.followerCountUpdate(calendar.CalendarID, 1)},
+	}
+	return transactWrite(ctx, r.dynamoDB, items, []error{
+		fmt.Errorf("%w: user %s is already a member of this calendar", ErrConflict, user.UserID),
+		nil,
+		fmt.Errorf("%w: calendar %s no longer exists", ErrConflict, calendar.CalendarID),
+	})
+}
 
-	relatedInput := &dynamodb.PutItemInput{
-		TableName: aws.String(r.tableName),
-		Item:      relatedItem,
+func (r *calendarRepository) UnfollowCalendar(ctx context.Context, calendar *models.Calendar, user *models.User) error {
+	return r.removeMember(ctx, calendar.CalendarID, user.UserID, fmt.Errorf("%w: user %s is not a follower of this calendar", ErrConflict, user.UserID))
+}
+
+// relatedItem はユーザーとカレンダーを結ぶ CAL# アイテムを組み立てる
+func relatedItem(calendarID string, userID string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"CalendarID": {S: aws.String(calendarID)},
+		"SortKey":    {S: aws.String(fmt.Sprintf("CAL#%s#%s", calendarID, userID))},
+		"UserID":     {S: aws.String(userID)},
 	}
-	_, err := r.dynamoDB.PutItemWithContext(ctx, relatedInput)
-	if err != nil {
-		return err
+}
+
+// memberItem はカレンダーのメンバーを表す USER# アイテムを組み立てる
+func memberItem(calendarID string, user *models.User, accessLevel string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"CalendarID":  {S: aws.String(calendarID)},
+		"UserID":      {S: aws.String(user.UserID)},
+		"DisplayName": {S: aws.String(user.DisplayName)},
+		"SortKey":     {S: aws.String(fmt.Sprintf("USER#%s", user.UserID))},
+		"AccessLevel": {S: aws.String(accessLevel)},
 	}
+}
 
-	// ユーザー情報の作成
-	userItem := map[string]*dynamodb.AttributeValue{
-		"CalendarID": {
-			S: aws.String(calendar.CalendarID),
-		},
-		"UserID": {
-			S: aws.String(user.UserID),
-		},
-		"DisplayName": {
-			S: aws.String(user.DisplayName),
-		},
-		"SortKey": {
-			S: aws.String(fmt.Sprintf("USER#%s", user.UserID)),
-		},
-		"AccessLevel": {
-			S: aws.String(user.AccessLevel),
-		},
+func itemKey(calendarID string, sortKey string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"CalendarID": {S: aws.String(calendarID)},
+		"SortKey":    {S: aws.String(sortKey)},
 	}
+}
 
-	userInput := &dynamodb.PutItemInput{
+func (r *calendarRepository) putItem(item map[string]*dynamodb.AttributeValue) *dynamodb.Put {
+	return &dynamodb.Put{
 		TableName: aws.String(r.tableName),
-		Item:      userItem,
+		Item:      item,
 	}
-	_, err = r.dynamoDB.PutItemWithContext(ctx, userInput)
-	return err
+}
+
+// newItem は同じキーのアイテムがまだない場合だけ書き込む Put を返す
+func (r *calendarRepository) newItem(item map[string]*dynamodb.AttributeValue) *dynamodb.Put {
+	put := r.putItem(item)
+	put.ConditionExpression = aws.String("attribute_not_exists(SortKey)")
+	return put
 }
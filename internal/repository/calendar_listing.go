@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"bonded/internal/models"
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// PublicListingIndexName は公開カレンダーの CALENDAR アイテムだけが載る GSI（PublicListing を持つアイテムだけが載る）
+const PublicListingIndexName = "PublicListing-index"
+
+// publicListing は公開カレンダーの PublicListing の値。GSI のパーティションを1つにまとめる
+const publicListing = "PUBLIC"
+
+// publicSortKey は公開カレンダーの一覧を名前順に並べるためのキー
+func publicSortKey(calendar *models.Calendar) string {
+	return strings.ToLower(calendar.Name) + "#" + calendar.CalendarID
+}
+
+func isPublic(calendar *models.Calendar) bool {
+	return calendar.IsPublic != nil && *calendar.IsPublic
+}
+
+// FindPublicCalendars は公開カレンダーの概要を名前順に page.Limit 件ずつ取得する
+func (r *calendarRepository) FindPublicCalendars(ctx context.Context, page models.PageRequest) (*models.Page[*models.CalendarSummary], error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String(PublicListingIndexName),
+		KeyConditionExpression: aws.String("PublicListing = :listing"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":listing": {S: aws.String(publicListing)},
+		},
+	}
+	items, cursor, err := queryPage(ctx, r.dynamoDB, input, page)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]*models.CalendarSummary, 0, len(items))
+	for _, item := range items {
+		var summary models.CalendarSummary
+		err = dynamodbattribute.UnmarshalMap(item, &summary)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, &summary)
+	}
+	return &models.Page[*models.CalendarSummary]{Items: summaries, NextCursor: cursor}, nil
+}
+
+// followerCountUpdate はカレンダーのフォロワー数を delta だけ増減する。カレンダーが削除されているかゴミ箱にあれば条件を満たさない
+func (r *calendarRepository) followerCountUpdate(calendarID string, delta int) *dynamodb.Update {
+	return &dynamodb.Update{
+		TableName:           aws.String(r.tableName),
+		Key:                 itemKey(calendarID, "CALENDAR"),
+		ConditionExpression: aws.String("attribute_exists(SortKey) AND " + notTrashed),
+		UpdateExpression:    aws.String("ADD FollowerCount :delta"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":delta": {N: aws.String(strconv.Itoa(delta))},
+		},
+	}
+}
@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/private/protocol/json/jsonutil"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// fakeError は偽の DynamoDB が返すエラー。Code は DynamoDB の例外名
+type fakeError struct {
+	Status int
+	Code   string
+}
+
+func (e *fakeError) Error() string {
+	return e.Code
+}
+
+var conditionalCheckFailed = &fakeError{Status: http.StatusBadRequest, Code: dynamodb.ErrCodeConditionalCheckFailedException}
+
+// fakeCall は偽の DynamoDB が受け取ったリクエスト
+type fakeCall struct {
+	Operation string
+	Body      []byte
+}
+
+// fakeDynamoDB は SDK が送るリクエストを記録し、respond の結果を返す DynamoDB のエンドポイント。
+// 本物の DynamoDB と同じく、式で使わないプレースホルダーや定義されていないプレースホルダーを含むリクエストは ValidationException にする
+type fakeDynamoDB struct {
+	t       *testing.T
+	respond func(operation string, body []byte) (interface{}, error)
+
+	mu    sync.Mutex
+	calls []fakeCall
+}
+
+func newFakeDynamoDB(t *testing.T, respond func(operation string, body []byte) (interface{}, error)) (*fakeDynamoDB, *dynamodb.DynamoDB) {
+	t.Helper()
+	fake := &fakeDynamoDB{t: t, respond: respond}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("test", "test", ""),
+		MaxRetries:  aws.Int(0),
+	}))
+	return fake, dynamodb.New(sess)
+}
+
+func (f *fakeDynamoDB) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810.")
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		f.t.Errorf("read %s request: %v", operation, err)
+		return
+	}
+	f.mu.Lock()
+	f.calls = append(f.calls, fakeCall{Operation: operation, Body: body})
+	f.mu.Unlock()
+
+	var output interface{}
+	err = checkExpressions(body)
+	if err != nil {
+		f.t.Errorf("%s: %v", operation, err)
+		err = &fakeError{Status: http.StatusBadRequest, Code: "ValidationException"}
+	} else {
+		output, err = f.respond(operation, body)
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	if fe, ok := err.(*fakeError); ok {
+		w.WriteHeader(fe.Status)
+		json.NewEncoder(w).Encode(map[string]string{"__type": "com.amazonaws.dynamodb.v20120810#" + fe.Code, "message": fe.Code})
+		return
+	}
+	if err != nil {
+		f.t.Errorf("%s: %v", operation, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	data, err := jsonutil.BuildJSON(output)
+	if err != nil {
+		f.t.Errorf("encode %s output: %v", operation, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write(data)
+}
+
+// updates は受け取った UpdateItem のリクエストを順に返す
+func (f *fakeDynamoDB) updates() []*dynamodb.UpdateItemInput {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var inputs []*dynamodb.UpdateItemInput
+	for _, call := range f.calls {
+		if call.Operation != "UpdateItem" {
+			continue
+		}
+		var input dynamodb.UpdateItemInput
+		err := decodeInput(call.Body, &input)
+		if err != nil {
+			f.t.Fatalf("decode UpdateItem: %v", err)
+		}
+		inputs = append(inputs, &input)
+	}
+	return inputs
+}
+
+func decodeInput(body []byte, v interface{}) error {
+	return jsonutil.UnmarshalJSON(v, bytes.NewReader(body))
+}
+
+var (
+	expressionFields = []string{"UpdateExpression", "ConditionExpression", "KeyConditionExpression", "FilterExpression", "ProjectionExpression"}
+	placeholder      = regexp.MustCompile(`[:#][A-Za-z0-9_]+`)
+)
+
+// checkExpressions はリクエストに含まれる式ごとに、ExpressionAttributeValues・ExpressionAttributeNames のプレースホルダーが
+// すべて式で使われ、式で使うプレースホルダーがすべて定義されているかを確かめる。トランザクションの各操作も確かめる
+func checkExpressions(body []byte) error {
+	var request interface{}
+	err := json.Unmarshal(body, &request)
+	if err != nil {
+		return err
+	}
+	return walkExpressions(request)
+}
+
+func walkExpressions(value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		err := checkPlaceholders(v)
+		if err != nil {
+			return err
+		}
+		for _, child := range v {
+			err = walkExpressions(child)
+			if err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			err := walkExpressions(child)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkPlaceholders(request map[string]interface{}) error {
+	used := map[string]bool{}
+	for _, field := range expressionFields {
+		expression, _ := request[field].(string)
+		for _, name := range placeholder.FindAllString(expression, -1) {
+			used[name] = true
+		}
+	}
+	defined := map[string]bool{}
+	for _, field := range []string{"ExpressionAttributeValues", "ExpressionAttributeNames"} {
+		values, _ := request[field].(map[string]interface{})
+		for name := range values {
+			defined[name] = true
+		}
+	}
+
+	var unused, undefined []string
+	for name := range defined {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	for name := range used {
+		if !defined[name] {
+			undefined = append(undefined, name)
+		}
+	}
+	sort.Strings(unused)
+	sort.Strings(undefined)
+	if len(unused) > 0 {
+		return fmt.Errorf("placeholders unused in expressions: %v", unused)
+	}
+	if len(undefined) > 0 {
+		return fmt.Errorf("placeholders used in expressions are not defined: %v", undefined)
+	}
+	return nil
+}
+
+func TestCheckExpressions(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   interface{}
+		wantErr bool
+	}{
+		{
+			name: "all used",
+			input: &dynamodb.UpdateItemInput{
+				UpdateExpression:          aws.String("SET #name = :name, Version = :nextVersion"),
+				ConditionExpression:       aws.String("Version = :version"),
+				ExpressionAttributeNames:  map[string]*string{"#name": aws.String("Name")},
+				ExpressionAttributeValues: versionValues(1, item{":name": {S: aws.String("Team")}}),
+			},
+		},
+		{
+			name: "unused value",
+			input: &dynamodb.UpdateItemInput{
+				UpdateExpression:          aws.String("SET DeletedAt = :deletedAt"),
+				ConditionExpression:       aws.String("Version = :version"),
+				ExpressionAttributeValues: versionValues(1, item{":deletedAt": {S: aws.String("now")}}),
+			},
+			wantErr: true,
+		},
+		{
+			name: "undefined value",
+			input: &dynamodb.UpdateItemInput{
+				UpdateExpression: aws.String("SET Version = :nextVersion"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "unused name in a transaction",
+			input: &dynamodb.TransactWriteItemsInput{TransactItems: []*dynamodb.TransactWriteItem{{Update: &dynamodb.Update{
+				UpdateExpression:         aws.String("SET Title = :title"),
+				ExpressionAttributeNames: map[string]*string{"#title": aws.String("Title")},
+				ExpressionAttributeValues: item{
+					":title": {S: aws.String("Standup")},
+				},
+			}}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := jsonutil.BuildJSON(tt.input)
+			if err != nil {
+				t.Fatalf("BuildJSON: %v", err)
+			}
+			err = checkExpressions(body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkExpressions = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
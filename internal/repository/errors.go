@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// ErrNotFound は対象のアイテムが存在しない場合に返される
+var ErrNotFound = errors.New("not found")
+
+// ErrPreconditionFailed は呼び出し元が読み込んだ後にアイテムが更新されていた場合に返される
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// ErrConflict は条件付きの書き込みが現在のアイテムの状態と矛盾して拒否された場合に返される
+var ErrConflict = errors.New("conflict")
+
+// isConditionalCheckFailed は ConditionExpression を満たさずに書き込みが拒否されたかを返す
+func isConditionalCheckFailed(err error) bool {
+	var aerr awserr.Error
+	return errors.As(err, &aerr) && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+}
+
+// maxTransactItems は1つのトランザクションで書き込めるアイテム数の上限
+const maxTransactItems = 100
+
+// transactWrite は items をすべて書き込むか、どれも書き込まない。
+// 条件を満たさない項目があった場合は、その項目に対応する conflicts のエラー（nil の場合は ErrConflict）を返す
+func transactWrite(ctx context.Context, client *dynamodb.DynamoDB, items []*dynamodb.TransactWriteItem, conflicts []error) error {
+	_, err := client.TransactWriteItemsWithContext(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+	var canceled *dynamodb.TransactionCanceledException
+	if !errors.As(err, &canceled) {
+		return err
+	}
+	for i, reason := range canceled.CancellationReasons {
+		if reason == nil || reason.Code == nil || *reason.Code != "ConditionalCheckFailed" {
+			continue
+		}
+		if i < len(conflicts) && conflicts[i] != nil {
+			return conflicts[i]
+		}
+		return ErrConflict
+	}
+	return err
+}
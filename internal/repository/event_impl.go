@@ -3,6 +3,8 @@ package repository
 import (
 	"bonded/internal/models"
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -12,13 +14,10 @@ import (
 func (r *eventRepository) CreateEvent(ctx context.Context, calendar *models.Calendar, event *models.Event) error {
 	calendar.Events = append(calendar.Events, *event)
 
-	item, err := dynamodbattribute.MarshalMap(event)
+	item, gsiItem, err := eventItems(calendar, event)
 	if err != nil {
 		return err
 	}
-
-	item["CalendarID"] = &dynamodb.AttributeValue{S: aws.String(calendar.CalendarID)}
-	item["SortKey"] = &dynamodb.AttributeValue{S: aws.String("EVENT#" + event.EventID)}
 	input := &dynamodb.PutItemInput{
 		TableName: aws.String(r.tableName),
 		Item:      item,
@@ -29,11 +28,6 @@ func (r *eventRepository) CreateEvent(ctx context.Context, calendar *models.Cale
 		return err
 	}
 
-	gsiItem := map[string]*dynamodb.AttributeValue{
-		"CalendarID": {S: aws.String(calendar.CalendarID)},
-		"SortKey":    {S: aws.String("CAL#" + calendar.CalendarID + "#" + event.EventID)},
-		"UserID":     {S: aws.String(calendar.OwnerUserID)},
-	}
 	gsiInput := &dynamodb.PutItemInput{
 		TableName: aws.String(r.tableName),
 		Item:      gsiItem,
@@ -43,35 +37,153 @@ func (r *eventRepository) CreateEvent(ctx context.Context, calendar *models.Cale
 	return err
 }
 
+// eventItems はイベントの EVENT# アイテムと、UserID-index に載せる CAL# アイテムを組み立てる
+func eventItems(calendar *models.Calendar, event *models.Event) (map[string]*dynamodb.AttributeValue, map[string]*dynamodb.AttributeValue, error) {
+	item, err := dynamodbattribute.MarshalMap(event)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	item["CalendarID"] = &dynamodb.AttributeValue{S: aws.String(calendar.CalendarID)}
+	item["SortKey"] = &dynamodb.AttributeValue{S: aws.String("EVENT#" + event.EventID)}
+	item["StartKey"] = &dynamodb.AttributeValue{S: aws.String(startKey(event))}
+
+	gsiItem := map[string]*dynamodb.AttributeValue{
+		"CalendarID": {S: aws.String(calendar.CalendarID)},
+		"SortKey":    {S: aws.String("CAL#" + calendar.CalendarID + "#" + event.EventID)},
+		"UserID":     {S: aws.String(calendar.OwnerUserID)},
+	}
+	return item, gsiItem, nil
+}
+
+// FindEvents はゴミ箱にないイベントをすべて取得する。繰り返しの個別の発生は繰り返し元の Overrides にまとめる
 func (r *eventRepository) FindEvents(ctx context.Context, calendarID string) ([]*models.Event, error) {
 	input := &dynamodb.QueryInput{
 		TableName:              aws.String(r.tableName),
 		KeyConditionExpression: aws.String("CalendarID = :calendarID AND begins_with(SortKey, :sortPrefix)"),
+		FilterExpression:       aws.String(notTrashed),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":calendarID": {S: aws.String(calendarID)},
+			":sortPrefix": {S: aws.String("EVENT#")},
+		},
+	}
+
+	items, err := queryAll(ctx, r.dynamoDB, input)
+	if err != nil {
+		return nil, err
+	}
+	events, err := unmarshalEvents(items)
+	if err != nil {
+		return nil, err
+	}
+	return FoldOverrides(events), nil
+}
+
+// FindEventsPage は繰り返し元と単発のイベントを page.Limit 件ずつ取得する。
+// 個別の発生はページの件数に数えず、繰り返し元の Overrides にまとめる
+func (r *eventRepository) FindEventsPage(ctx context.Context, calendarID string, page models.PageRequest) (*models.Page[*models.Event], error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("CalendarID = :calendarID AND begins_with(SortKey, :sortPrefix)"),
+		FilterExpression:       aws.String(notTrashed + " AND attribute_not_exists(RecurringEventID)"),
 		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
 			":calendarID": {S: aws.String(calendarID)},
 			":sortPrefix": {S: aws.String("EVENT#")},
 		},
 	}
 
-	result, err := r.dynamoDB.QueryWithContext(ctx, input)
+	items, cursor, err := queryPage(ctx, r.dynamoDB, input, page)
+	if err != nil {
+		return nil, err
+	}
+	events, err := unmarshalEvents(items)
 	if err != nil {
 		return nil, err
 	}
+	overrides, err := r.pageOverrides(ctx, calendarID, events)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Page[*models.Event]{Items: FoldOverrides(append(events, overrides...)), NextCursor: cursor}, nil
+}
 
-	events := make([]*models.Event, 0, len(result.Items))
-	for _, item := range result.Items {
+// pageOverrides はページの繰り返し元の個別の発生を取得する。個別の発生のキーは繰り返し元のすぐ後ろに並ぶので、
+// ページの最初の繰り返し元から最後の繰り返し元の個別の発生までを1回のクエリで読む
+func (r *eventRepository) pageOverrides(ctx context.Context, calendarID string, events []*models.Event) ([]*models.Event, error) {
+	var first, last string
+	for _, event := range events {
+		if event.RRule == "" && len(event.RDates) == 0 {
+			continue
+		}
+		if first == "" {
+			first = event.EventID
+		}
+		last = event.EventID
+	}
+	if first == "" {
+		return nil, nil
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("CalendarID = :calendarID AND SortKey BETWEEN :from AND :to"),
+		FilterExpression:       aws.String(notTrashed + " AND attribute_exists(RecurringEventID)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":calendarID": {S: aws.String(calendarID)},
+			":from":       {S: aws.String(overrideSortKey(first, ""))},
+			":to":         {S: aws.String(overrideSortKey(last, "\U0010FFFF"))},
+		},
+	}
+	items, err := queryAll(ctx, r.dynamoDB, input)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalEvents(items)
+}
+
+// FoldOverrides は繰り返しの個別の発生を繰り返し元の Overrides にまとめ、繰り返し元と単発のイベントだけを順に返す。
+// 繰り返し元が events にない個別の発生は返さない
+func FoldOverrides(events []*models.Event) []*models.Event {
+	masters := make([]*models.Event, 0, len(events))
+	byID := make(map[string]*models.Event, len(events))
+	for _, event := range events {
+		if event.RecurringEventID == "" {
+			masters = append(masters, event)
+			byID[event.EventID] = event
+		}
+	}
+	for _, event := range events {
+		if master, ok := byID[event.RecurringEventID]; ok && event.RecurringEventID != "" {
+			master.Overrides = append(master.Overrides, *event)
+		}
+	}
+	return masters
+}
+
+func unmarshalEvents(items []item) ([]*models.Event, error) {
+	events := make([]*models.Event, 0, len(items))
+	for _, item := range items {
 		var event models.Event
-		err = dynamodbattribute.UnmarshalMap(item, &event)
+		err := dynamodbattribute.UnmarshalMap(item, &event)
 		if err != nil {
 			return nil, err
 		}
 		events = append(events, &event)
 	}
-
 	return events, nil
 }
 
-func (r *eventRepository) EventExists(ctx context.Context, calendarID string, eventID string) bool {
+// FindEvent はイベントを1件取得する。存在しないかゴミ箱にある場合は nil を返す
+func (r *eventRepository) FindEvent(ctx context.Context, calendarID string, eventID string) (*models.Event, error) {
+	event, err := r.findEventItem(ctx, calendarID, eventID)
+	if err != nil || event == nil || event.DeletedAt != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// findEventItem はゴミ箱にあるかどうかに関わらずイベントのアイテムを取得する
+func (r *eventRepository) findEventItem(ctx context.Context, calendarID string, eventID string) (*models.Event, error) {
 	input := &dynamodb.GetItemInput{
 		TableName: aws.String(r.tableName),
 		Key: map[string]*dynamodb.AttributeValue{
@@ -82,18 +194,32 @@ func (r *eventRepository) EventExists(ctx context.Context, calendarID string, ev
 
 	result, err := r.dynamoDB.GetItemWithContext(ctx, input)
 	if err != nil {
-		return false
+		return nil, err
 	}
-
 	if result.Item == nil {
-		return false
+		return nil, nil
 	}
 
-	return true
+	var event models.Event
+	err = dynamodbattribute.UnmarshalMap(result.Item, &event)
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
 }
 
-func (r *eventRepository) EditEvent(ctx context.Context, calendarID string, event *models.Event) (*models.Event, error) {
-	updateExpression, attributeNames, attributeValues := buildUpdateExpression(event)
+func (r *eventRepository) EventExists(ctx context.Context, calendarID string, eventID string) bool {
+	event, err := r.FindEvent(ctx, calendarID, eventID)
+	return err == nil && event != nil
+}
+
+// EditEvent はイベントの fields に含まれるフィールドを更新し、版数を1つ進める。
+// 版数が version から変わっていた場合は ErrPreconditionFailed を返す
+func (r *eventRepository) EditEvent(ctx context.Context, calendarID string, event *models.Event, fields []models.EventField, version int64) (*models.Event, error) {
+	updateExpression, attributeNames, attributeValues, err := buildUpdateExpression(event, fields)
+	if err != nil {
+		return nil, err
+	}
 
 	updateInput := &dynamodb.UpdateItemInput{
 		TableName: aws.String(r.tableName),
@@ -102,12 +228,16 @@ func (r *eventRepository) EditEvent(ctx context.Context, calendarID string, even
 			"SortKey":    {S: aws.String("EVENT#" + event.EventID)},
 		},
 		UpdateExpression:          aws.String(updateExpression),
+		ConditionExpression:       aws.String("attribute_exists(SortKey) AND " + notTrashed + " AND " + versionCondition(version)),
 		ExpressionAttributeNames:  attributeNames,
-		ExpressionAttributeValues: attributeValues,
+		ExpressionAttributeValues: versionValues(version, attributeValues),
 		ReturnValues:              aws.String("ALL_NEW"),
 	}
 
 	result, err := r.dynamoDB.UpdateItemWithContext(ctx, updateInput)
+	if isConditionalCheckFailed(err) {
+		return nil, fmt.Errorf("%w: event %s has been modified", ErrPreconditionFailed, event.EventID)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -121,30 +251,224 @@ func (r *eventRepository) EditEvent(ctx context.Context, calendarID string, even
 	return &updatedEvent, nil
 }
 
-func (r *eventRepository) DeleteEvent(ctx context.Context, calendarID string, eventID string) error {
-	input := &dynamodb.DeleteItemInput{
+// SaveAttendees はイベントの参加者を置き換え、版数を1つ進める。版数が version から変わっていた場合は ErrPreconditionFailed を返す
+func (r *eventRepository) SaveAttendees(ctx context.Context, calendarID string, eventID string, attendees []models.Attendee, version int64) (*models.Event, error) {
+	updateExpression := "SET Version = :nextVersion REMOVE Attendees"
+	attributeValues := map[string]*dynamodb.AttributeValue{}
+	if len(attendees) > 0 {
+		list, err := dynamodbattribute.MarshalList(attendees)
+		if err != nil {
+			return nil, err
+		}
+		updateExpression = "SET Attendees = :attendees, Version = :nextVersion"
+		attributeValues[":attendees"] = &dynamodb.AttributeValue{L: list}
+	}
+
+	result, err := r.dynamoDB.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
 		TableName: aws.String(r.tableName),
 		Key: map[string]*dynamodb.AttributeValue{
 			"CalendarID": {S: aws.String(calendarID)},
 			"SortKey":    {S: aws.String("EVENT#" + eventID)},
 		},
+		UpdateExpression:          aws.String(updateExpression),
+		ConditionExpression:       aws.String("attribute_exists(SortKey) AND " + notTrashed + " AND " + versionCondition(version)),
+		ExpressionAttributeValues: versionValues(version, attributeValues),
+		ReturnValues:              aws.String("ALL_NEW"),
+	})
+	if isConditionalCheckFailed(err) {
+		return nil, fmt.Errorf("%w: event %s has been modified", ErrPreconditionFailed, eventID)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	_, err := r.dynamoDB.DeleteItemWithContext(ctx, input)
-	return err
+	var updatedEvent models.Event
+	err = dynamodbattribute.UnmarshalMap(result.Attributes, &updatedEvent)
+	if err != nil {
+		return nil, err
+	}
+	return &updatedEvent, nil
+}
+
+// EditSeries は繰り返し元の fields に含まれるフィールドを更新して版数を1つ進め、removedOverrides の個別の発生を削除し、
+// following があれば新しいイベントとして作成する。これらは1つのトランザクションでまとめて書き込むため、
+// 失敗した場合はどれも書き込まれず、同じ版数でやり直せる。繰り返し元の版数が version から変わっていた場合は ErrPreconditionFailed を返す
+func (r *eventRepository) EditSeries(ctx context.Context, calendar *models.Calendar, master *models.Event, fields []models.EventField, version int64, removedOverrides []string, following *models.Event) error {
+	// 繰り返し元の更新・個別の発生の削除・新しいイベントの2アイテムが1つのトランザクションに収まる必要がある
+	if 1+len(removedOverrides)+2 > maxTransactItems {
+		return fmt.Errorf("%w: too many edited occurrences to change at once", ErrConflict)
+	}
+	updateExpression, attributeNames, attributeValues, err := buildUpdateExpression(master, fields)
+	if err != nil {
+		return err
+	}
+
+	items := []*dynamodb.TransactWriteItem{
+		{Update: &dynamodb.Update{
+			TableName:                 aws.String(r.tableName),
+			Key:                       itemKey(calendar.CalendarID, "EVENT#"+master.EventID),
+			UpdateExpression:          aws.String(updateExpression),
+			ConditionExpression:       aws.String("attribute_exists(SortKey) AND " + notTrashed + " AND " + versionCondition(version)),
+			ExpressionAttributeNames:  attributeNames,
+			ExpressionAttributeValues: versionValues(version, attributeValues),
+		}},
+	}
+	for _, recurrenceID := range removedOverrides {
+		items = append(items, &dynamodb.TransactWriteItem{Delete: &dynamodb.Delete{
+			TableName: aws.String(r.tableName),
+			Key:       itemKey(calendar.CalendarID, overrideSortKey(master.EventID, recurrenceID)),
+		}})
+	}
+	if following != nil {
+		item, gsiItem, err := eventItems(calendar, following)
+		if err != nil {
+			return err
+		}
+		items = append(items,
+			&dynamodb.TransactWriteItem{Put: &dynamodb.Put{
+				TableName:           aws.String(r.tableName),
+				Item:                item,
+				ConditionExpression: aws.String("attribute_not_exists(SortKey)"),
+			}},
+			&dynamodb.TransactWriteItem{Put: &dynamodb.Put{
+				TableName: aws.String(r.tableName),
+				Item:      gsiItem,
+			}},
+		)
+	}
+	return transactWrite(ctx, r.dynamoDB, items, []error{
+		fmt.Errorf("%w: event %s has been modified", ErrPreconditionFailed, master.EventID),
+	})
 }
 
-func buildUpdateExpression(event *models.Event) (string, map[string]*string, map[string]*dynamodb.AttributeValue) {
-	return "SET Title = :title, Description = :desc, StartTime = :startTime, EndTime = :endTime, #location = :location, AllDay = :allDay",
-		map[string]*string{
-			"#location": aws.String("Location"),
+// FindOverrides は繰り返しイベントの個別の発生（EVENT#<eventId>#<recurrenceId>）を取得する
+func (r *eventRepository) FindOverrides(ctx context.Context, calendarID string, eventID string) ([]*models.Event, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("CalendarID = :calendarID AND begins_with(SortKey, :sortPrefix)"),
+		FilterExpression:       aws.String(notTrashed),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":calendarID": {S: aws.String(calendarID)},
+			":sortPrefix": {S: aws.String("EVENT#" + eventID + "#")},
 		},
-		map[string]*dynamodb.AttributeValue{
-			":title":     {S: aws.String(event.Title)},
-			":desc":      {S: aws.String(event.Description)},
-			":startTime": {S: aws.String(event.StartTime)},
-			":endTime":   {S: aws.String(event.EndTime)},
-			":location":  {S: aws.String(event.Location)},
-			":allDay":    {BOOL: aws.Bool(event.AllDay)},
+	}
+
+	items, err := queryAll(ctx, r.dynamoDB, input)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalEvents(items)
+}
+
+// SaveOverride は繰り返しイベントの個別の発生を EVENT# の子アイテムとして保存し、繰り返し元の版数を1つ進める。
+// 繰り返し元の版数が version から変わっていた場合は ErrPreconditionFailed を返す
+func (r *eventRepository) SaveOverride(ctx context.Context, calendarID string, override *models.Event, version int64) error {
+	item, err := dynamodbattribute.MarshalMap(override)
+	if err != nil {
+		return err
+	}
+	// 版数と参加者は繰り返し元で管理する
+	delete(item, "Version")
+	delete(item, "Attendees")
+
+	item["CalendarID"] = &dynamodb.AttributeValue{S: aws.String(calendarID)}
+	item["SortKey"] = &dynamodb.AttributeValue{S: aws.String(overrideSortKey(override.RecurringEventID, override.RecurrenceID))}
+	item["StartKey"] = &dynamodb.AttributeValue{S: aws.String(startKey(override))}
+	items := []*dynamodb.TransactWriteItem{
+		{Put: &dynamodb.Put{
+			TableName: aws.String(r.tableName),
+			Item:      item,
+		}},
+		{Update: &dynamodb.Update{
+			TableName: aws.String(r.tableName),
+			Key: map[string]*dynamodb.AttributeValue{
+				"CalendarID": {S: aws.String(calendarID)},
+				"SortKey":    {S: aws.String("EVENT#" + override.RecurringEventID)},
+			},
+			UpdateExpression:          aws.String("SET Version = :nextVersion"),
+			ConditionExpression:       aws.String("attribute_exists(SortKey) AND " + notTrashed + " AND " + versionCondition(version)),
+			ExpressionAttributeValues: versionValues(version, nil),
+		}},
+	}
+	return transactWrite(ctx, r.dynamoDB, items, []error{
+		nil,
+		fmt.Errorf("%w: event %s has been modified", ErrPreconditionFailed, override.RecurringEventID),
+	})
+}
+
+func overrideSortKey(eventID string, recurrenceID string) string {
+	return "EVENT#" + eventID + "#" + recurrenceID
+}
+
+// buildUpdateExpression は fields に含まれるフィールドだけを更新する式を組み立てる。
+// 省略可能なフィールドは値が空であれば属性ごと削除する。StartKey と版数は常に更新する
+func buildUpdateExpression(event *models.Event, fields []models.EventField) (string, map[string]*string, map[string]*dynamodb.AttributeValue, error) {
+	sets := []string{"StartKey = :startKey", "Version = :nextVersion"}
+	var removes []string
+	attributeNames := map[string]*string{}
+	attributeValues := map[string]*dynamodb.AttributeValue{
+		":startKey": {S: aws.String(startKey(event))},
+	}
+	setOrRemove := func(attribute string, placeholder string, value *dynamodb.AttributeValue, empty bool) {
+		if empty {
+			removes = append(removes, attribute)
+			return
+		}
+		sets = append(sets, attribute+" = "+placeholder)
+		attributeValues[placeholder] = value
+	}
+
+	for _, field := range fields {
+		switch field {
+		case models.EventFieldTitle:
+			setOrRemove("Title", ":title", &dynamodb.AttributeValue{S: aws.String(event.Title)}, false)
+		case models.EventFieldDescription:
+			setOrRemove("Description", ":desc", &dynamodb.AttributeValue{S: aws.String(event.Description)}, event.Description == "")
+		case models.EventFieldStartTime:
+			startTime, err := dynamodbattribute.Marshal(event.StartTime)
+			if err != nil {
+				return "", nil, nil, err
+			}
+			setOrRemove("StartTime", ":startTime", startTime, false)
+		case models.EventFieldEndTime:
+			endTime, err := dynamodbattribute.Marshal(event.EndTime)
+			if err != nil {
+				return "", nil, nil, err
+			}
+			setOrRemove("EndTime", ":endTime", endTime, false)
+		case models.EventFieldTimeZone:
+			setOrRemove("TimeZone", ":timeZone", &dynamodb.AttributeValue{S: aws.String(event.TimeZone)}, event.TimeZone == "")
+		case models.EventFieldLocation:
+			// Location は予約語のため別名で指定する
+			attributeNames["#location"] = aws.String("Location")
+			setOrRemove("#location", ":location", &dynamodb.AttributeValue{S: aws.String(event.Location)}, event.Location == "")
+		case models.EventFieldAllDay:
+			setOrRemove("AllDay", ":allDay", &dynamodb.AttributeValue{BOOL: aws.Bool(event.AllDay)}, false)
+		case models.EventFieldRRule:
+			setOrRemove("RRule", ":rrule", &dynamodb.AttributeValue{S: aws.String(event.RRule)}, event.RRule == "")
+		case models.EventFieldRDates:
+			setOrRemove("RDates", ":rdates", stringListAttribute(event.RDates), len(event.RDates) == 0)
+		case models.EventFieldExDates:
+			setOrRemove("ExDates", ":exdates", stringListAttribute(event.ExDates), len(event.ExDates) == 0)
+		default:
+			return "", nil, nil, fmt.Errorf("unknown event field %q", field)
 		}
+	}
+
+	expression := "SET " + strings.Join(sets, ", ")
+	if len(removes) > 0 {
+		expression += " REMOVE " + strings.Join(removes, ", ")
+	}
+	if len(attributeNames) == 0 {
+		attributeNames = nil
+	}
+	return expression, attributeNames, attributeValues, nil
+}
+
+func stringListAttribute(values []string) *dynamodb.AttributeValue {
+	list := make([]*dynamodb.AttributeValue, 0, len(values))
+	for _, v := range values {
+		list = append(list, &dynamodb.AttributeValue{S: aws.String(v)})
+	}
+	return &dynamodb.AttributeValue{L: list}
 }
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"bonded/internal/models"
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// StartKeyIndexName は EVENT# アイテムを開始日時順に並べる GSI（StartKey を持つアイテムだけが載る）
+const StartKeyIndexName = "CalendarID-StartKey-index"
+
+const (
+	// startKeyTimedPrefix は開始日時で検索できるイベントの StartKey（T#<UTC の開始日時>#<eventId>）
+	startKeyTimedPrefix = "T#"
+	// startKeyOpenPrefix は期間を問わず常に取得するイベントの StartKey（R#<eventId>）。
+	// 繰り返しイベントとその個別の発生、maxIndexedDuration より長いイベントが該当する
+	startKeyOpenPrefix = "R#"
+	// maxIndexedDuration は開始日時で検索するイベントの最大の長さ
+	maxIndexedDuration = 31 * 24 * time.Hour
+	// floatingMargin は終日イベントの floating な日付が実際に始まりうる時刻の幅（UTC-12〜UTC+14）
+	floatingMargin = 14 * time.Hour
+)
+
+// startKey はイベントの StartKey を組み立てる
+func startKey(event *models.Event) string {
+	if event.RecurringEventID != "" || event.RRule != "" || len(event.RDates) > 0 {
+		return startKeyOpenPrefix + event.EventID
+	}
+	if event.StartTime.IsZero() || event.EndTime.Sub(event.StartTime.Time) > maxIndexedDuration {
+		return startKeyOpenPrefix + event.EventID
+	}
+	return startKeyTimedPrefix + event.StartTime.UTC().Format(time.RFC3339) + "#" + event.EventID
+}
+
+// FindEventsBetween は期間と重なりうるイベントを StartKey の GSI から取得する。
+// 開始日時で絞り込めないイベント（繰り返しなど）も含むため、厳密な判定は呼び出し側で行う。
+func (r *eventRepository) FindEventsBetween(ctx context.Context, calendarID string, window models.TimeRange) ([]*models.Event, error) {
+	from := window.From.Add(-maxIndexedDuration - floatingMargin).UTC()
+	to := window.To.Add(floatingMargin).UTC()
+
+	timed, err := r.queryStartKey(ctx, "CalendarID = :calendarID AND StartKey BETWEEN :from AND :to", map[string]*dynamodb.AttributeValue{
+		":calendarID": {S: aws.String(calendarID)},
+		":from":       {S: aws.String(startKeyTimedPrefix + from.Format(time.RFC3339))},
+		":to":         {S: aws.String(startKeyTimedPrefix + to.Format(time.RFC3339))},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	open, err := r.queryStartKey(ctx, "CalendarID = :calendarID AND begins_with(StartKey, :prefix)", map[string]*dynamodb.AttributeValue{
+		":calendarID": {S: aws.String(calendarID)},
+		":prefix":     {S: aws.String(startKeyOpenPrefix)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(timed, open...), nil
+}
+
+func (r *eventRepository) queryStartKey(ctx context.Context, keyCondition string, values map[string]*dynamodb.AttributeValue) ([]*models.Event, error) {
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(r.tableName),
+		IndexName:                 aws.String(StartKeyIndexName),
+		KeyConditionExpression:    aws.String(keyCondition),
+		FilterExpression:          aws.String(notTrashed),
+		ExpressionAttributeValues: values,
+	}
+
+	var events []*models.Event
+	var unmarshalErr error
+	err := r.dynamoDB.QueryPagesWithContext(ctx, input, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var event models.Event
+			unmarshalErr = dynamodbattribute.UnmarshalMap(item, &event)
+			if unmarshalErr != nil {
+				return false
+			}
+			events = append(events, &event)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+	return events, nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"bonded/internal/models"
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+func (r *calendarRepository) CreateFeedToken(ctx context.Context, token *models.FeedToken) error {
+	item, err := dynamodbattribute.MarshalMap(token)
+	if err != nil {
+		return err
+	}
+	item["SortKey"] = &dynamodb.AttributeValue{S: aws.String(feedTokenSortKey(token.TokenHash))}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(SortKey)"),
+	}
+	_, err = r.dynamoDB.PutItemWithContext(ctx, input)
+	return err
+}
+
+// FindFeedToken はハッシュからフィードトークンを取得する。存在しない場合は nil を返す
+func (r *calendarRepository) FindFeedToken(ctx context.Context, calendarID string, tokenHash string) (*models.FeedToken, error) {
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"CalendarID": {S: aws.String(calendarID)},
+			"SortKey":    {S: aws.String(feedTokenSortKey(tokenHash))},
+		},
+	}
+	result, err := r.dynamoDB.GetItemWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var token models.FeedToken
+	err = dynamodbattribute.UnmarshalMap(result.Item, &token)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// FindFeedTokens はメンバーが発行したフィードトークンの一覧を取得する
+func (r *calendarRepository) FindFeedTokens(ctx context.Context, calendarID string, userID string) ([]*models.FeedToken, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("CalendarID = :cid AND begins_with(SortKey, :sk)"),
+		FilterExpression:       aws.String("MemberUserID = :uid"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":cid": {S: aws.String(calendarID)},
+			":sk":  {S: aws.String("FEED#")},
+			":uid": {S: aws.String(userID)},
+		},
+	}
+	items, err := queryAll(ctx, r.dynamoDB, input)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]*models.FeedToken, 0, len(items))
+	for _, item := range items {
+		var token models.FeedToken
+		err = dynamodbattribute.UnmarshalMap(item, &token)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, &token)
+	}
+	return tokens, nil
+}
+
+func (r *calendarRepository) DeleteFeedToken(ctx context.Context, calendarID string, tokenHash string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"CalendarID": {S: aws.String(calendarID)},
+			"SortKey":    {S: aws.String(feedTokenSortKey(tokenHash))},
+		},
+	}
+	_, err := r.dynamoDB.DeleteItemWithContext(ctx, input)
+	return err
+}
+
+func feedTokenSortKey(tokenHash string) string {
+	return "FEED#" + tokenHash
+}
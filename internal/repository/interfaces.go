@@ -4,6 +4,7 @@ import (
 	"bonded/internal/infra/db"
 	"bonded/internal/models"
 	"context"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
@@ -34,22 +35,54 @@ func CalendarRepositoryRequest(dynamoClient *db.DynamoDBClient) CalendarReposito
 
 type CalendarRepository interface {
 	Create(ctx context.Context, calendar *models.Calendar) error
-	Edit(ctx context.Context, calendarID *models.Calendar, input *models.Calendar) error
-	Delete(ctx context.Context, calendarID string) error
-	FindAllCalendars(ctx context.Context) ([]*models.Calendar, error)
+	Edit(ctx context.Context, calendarID *models.Calendar, input *models.EditCalendar, version int64) (*models.Calendar, error)
+	Delete(ctx context.Context, calendarID string, dryRun bool, deletedAt time.Time, version int64) (*models.DeletionReport, error)
+	Restore(ctx context.Context, calendar *models.Calendar) error
+	Purge(ctx context.Context, calendarID string) (*models.DeletionReport, error)
+	FindTrashedCalendar(ctx context.Context, calendarID string) (*models.Calendar, error)
+	FindTrashedCalendars(ctx context.Context, userID string, page models.PageRequest) (*models.Page[*models.Calendar], error)
+	FindPublicCalendars(ctx context.Context, page models.PageRequest) (*models.Page[*models.CalendarSummary], error)
 	FindByCalendarID(ctx context.Context, calendarID string) (*models.Calendar, error)
-	FindByUserID(ctx context.Context, userID string) ([]*models.Calendar, error)
+	FindByUserID(ctx context.Context, userID string, page models.PageRequest) (*models.Page[*models.CalendarSummary], error)
+	FindMember(ctx context.Context, calendarID string, userID string) (*models.User, error)
 	UnfollowCalendar(ctx context.Context, calendar *models.Calendar, user *models.User) error
 	FollowCalendar(ctx context.Context, calendar *models.Calendar, user *models.User) error
-	InviteUser(ctx context.Context, calendar *models.Calendar, user *models.User) error
+	UpdateMemberAccessLevel(ctx context.Context, calendarID string, userID string, accessLevel string) error
+	RemoveMember(ctx context.Context, calendarID string, userID string) error
+	TransferOwnership(ctx context.Context, calendar *models.Calendar, newOwner *models.User, version int64) (*models.Calendar, error)
+	CreateInvitation(ctx context.Context, invitation *models.Invitation) error
+	FindInvitation(ctx context.Context, calendarID string, userID string) (*models.Invitation, error)
+	FindInvitations(ctx context.Context, calendarID string) ([]*models.Invitation, error)
+	FindInvitationsByUserID(ctx context.Context, userID string, page models.PageRequest) (*models.Page[*models.Invitation], error)
+	AcceptInvitation(ctx context.Context, invitation *models.Invitation, respondedAt time.Time) error
+	RespondInvitation(ctx context.Context, calendarID string, userID string, status string, respondedAt time.Time) error
+	CreateShareLink(ctx context.Context, link *models.ShareLink) error
+	FindShareLink(ctx context.Context, calendarID string, tokenHash string) (*models.ShareLink, error)
+	FindShareLinks(ctx context.Context, calendarID string) ([]*models.ShareLink, error)
+	DeleteShareLink(ctx context.Context, calendarID string, tokenHash string) error
+	RedeemShareLink(ctx context.Context, link *models.ShareLink, user *models.User, now time.Time) error
+	CreateFeedToken(ctx context.Context, token *models.FeedToken) error
+	FindFeedToken(ctx context.Context, calendarID string, tokenHash string) (*models.FeedToken, error)
+	FindFeedTokens(ctx context.Context, calendarID string, userID string) ([]*models.FeedToken, error)
+	DeleteFeedToken(ctx context.Context, calendarID string, tokenHash string) error
 }
 
 type EventRepository interface {
 	CreateEvent(ctx context.Context, calendar *models.Calendar, event *models.Event) error
 	FindEvents(ctx context.Context, calendarID string) ([]*models.Event, error)
+	FindEventsPage(ctx context.Context, calendarID string, page models.PageRequest) (*models.Page[*models.Event], error)
+	FindEventsBetween(ctx context.Context, calendarID string, window models.TimeRange) ([]*models.Event, error)
+	FindEvent(ctx context.Context, calendarID string, eventID string) (*models.Event, error)
 	EventExists(ctx context.Context, calendarID string, eventID string) bool
-	EditEvent(ctx context.Context, calendarID string, event *models.Event) (*models.Event, error)
-	DeleteEvent(ctx context.Context, calendarID string, eventID string) error
+	EditEvent(ctx context.Context, calendarID string, event *models.Event, fields []models.EventField, version int64) (*models.Event, error)
+	EditSeries(ctx context.Context, calendar *models.Calendar, master *models.Event, fields []models.EventField, version int64, removedOverrides []string, following *models.Event) error
+	SaveAttendees(ctx context.Context, calendarID string, eventID string, attendees []models.Attendee, version int64) (*models.Event, error)
+	TrashEvent(ctx context.Context, calendarID string, eventID string, deletedAt time.Time, version int64) error
+	RestoreEvent(ctx context.Context, calendarID string, eventID string) error
+	FindTrashedEvent(ctx context.Context, calendarID string, eventID string) (*models.Event, error)
+	FindTrashedEvents(ctx context.Context, calendarID string, page models.PageRequest) (*models.Page[*models.Event], error)
+	FindOverrides(ctx context.Context, calendarID string, eventID string) ([]*models.Event, error)
+	SaveOverride(ctx context.Context, calendarID string, override *models.Event, version int64) error
 }
 
 type userRepository struct {
@@ -66,4 +99,10 @@ func UserRepositoryRequest(dynamoClient *db.DynamoDBClient) UserRepository {
 
 type UserRepository interface {
 	FindByUserID(ctx context.Context, userID string) (*models.User, error)
+	FindProfile(ctx context.Context, userID string) (*models.Profile, error)
+	FindProfileByEmail(ctx context.Context, email string) (*models.Profile, error)
+	FindProfilesByNamePrefix(ctx context.Context, prefix string, page models.PageRequest) (*models.Page[*models.Profile], error)
+	CreateProfile(ctx context.Context, profile *models.Profile) error
+	UpdateProfile(ctx context.Context, profile *models.Profile) error
+	SyncDisplayName(ctx context.Context, userID string, displayName string) error
 }
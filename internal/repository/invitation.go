@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"bonded/internal/models"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// 招待は招待されたユーザーごとに1件の INVITE#<userId> アイテムとしてカレンダーのパーティションに保存する。
+// UserID を持つので、招待されたユーザーからは UserID-index で引ける
+
+// pendingInvitation は回答待ちで期限内の招待を表す条件
+const pendingInvitation = "#status = :pending AND ValidUntil > :now"
+
+func invitationSortKey(userID string) string {
+	return "INVITE#" + userID
+}
+
+func pendingValues(now time.Time, values map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	if values == nil {
+		values = map[string]*dynamodb.AttributeValue{}
+	}
+	values[":pending"] = &dynamodb.AttributeValue{S: aws.String(models.InvitationStatusPending)}
+	values[":now"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(now.Unix(), 10))}
+	return values
+}
+
+// CreateInvitation は招待を保存する。回答待ちの招待がすでにある場合やメンバーになっている場合は ErrConflict を返す。
+// 回答済み・期限切れの招待は新しい招待で置き換える
+func (r *calendarRepository) CreateInvitation(ctx context.Context, invitation *models.Invitation) error {
+	item, err := dynamodbattribute.MarshalMap(invitation)
+	if err != nil {
+		return err
+	}
+	item["SortKey"] = &dynamodb.AttributeValue{S: aws.String(invitationSortKey(invitation.UserID))}
+
+	items := []*dynamodb.TransactWriteItem{
+		{Put: &dynamodb.Put{
+			TableName:                 aws.String(r.tableName),
+			Item:                      item,
+			ConditionExpression:       aws.String("attribute_not_exists(SortKey) OR NOT (" + pendingInvitation + ")"),
+			ExpressionAttributeNames:  map[string]*string{"#status": aws.String("Status")},
+			ExpressionAttributeValues: pendingValues(invitation.CreatedAt, nil),
+		}},
+		{ConditionCheck: &dynamodb.ConditionCheck{
+			TableName:           aws.String(r.tableName),
+			Key:                 itemKey(invitation.CalendarID, "USER#"+invitation.UserID),
+			ConditionExpression: aws.String("attribute_not_exists(SortKey)"),
+		}},
+		{ConditionCheck: &dynamodb.ConditionCheck{
+			TableName:           aws.String(r.tableName),
+			Key:                 itemKey(invitation.CalendarID, "CALENDAR"),
+			ConditionExpression: aws.String("attribute_exists(SortKey) AND " + notTrashed),
+		}},
+	}
+	return transactWrite(ctx, r.dynamoDB, items, []error{
+		fmt.Errorf("%w: user %s already has a pending invitation", ErrConflict, invitation.UserID),
+		fmt.Errorf("%w: user %s is already a member of this calendar", ErrConflict, invitation.UserID),
+		fmt.Errorf("%w: calendar %s no longer exists", ErrConflict, invitation.CalendarID),
+	})
+}
+
+// FindInvitation はカレンダーへのユーザーの招待を取得する。存在しない場合は nil を返す
+func (r *calendarRepository) FindInvitation(ctx context.Context, calendarID string, userID string) (*models.Invitation, error) {
+	result, err := r.dynamoDB.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       itemKey(calendarID, invitationSortKey(userID)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var invitation models.Invitation
+	err = dynamodbattribute.UnmarshalMap(result.Item, &invitation)
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// FindInvitations はカレンダーの招待を状態にかかわらずすべて取得する
+func (r *calendarRepository) FindInvitations(ctx context.Context, calendarID string) ([]*models.Invitation, error) {
+	items, err := queryAll(ctx, r.dynamoDB, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("CalendarID = :cid AND begins_with(SortKey, :sk)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":cid": {S: aws.String(calendarID)},
+			":sk":  {S: aws.String("INVITE#")},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalInvitations(items)
+}
+
+// FindInvitationsByUserID はユーザーが受け取った招待を取得する。ゴミ箱にあるカレンダーへの招待は含めない
+func (r *calendarRepository) FindInvitationsByUserID(ctx context.Context, userID string, page models.PageRequest) (*models.Page[*models.Invitation], error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String("UserID-index"),
+		KeyConditionExpression: aws.String("UserID = :uid"),
+		FilterExpression:       aws.String("begins_with(SortKey, :sk) AND attribute_not_exists(ExpiresAt)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":uid": {S: aws.String(userID)},
+			":sk":  {S: aws.String("INVITE#")},
+		},
+	}
+	items, cursor, err := queryPage(ctx, r.dynamoDB, input, page)
+	if err != nil {
+		return nil, err
+	}
+	invitations, err := unmarshalInvitations(items)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Page[*models.Invitation]{Items: invitations, NextCursor: cursor}, nil
+}
+
+// AcceptInvitation は回答待ちの招待を承諾し、同じトランザクションで招待された権限のメンバーとして追加する。
+// 招待が回答待ちでなくなっていた場合やすでにメンバーの場合は ErrConflict を返す
+func (r *calendarRepository) AcceptInvitation(ctx context.Context, invitation *models.Invitation, respondedAt time.Time) error {
+	user := &models.User{UserID: invitation.UserID, DisplayName: invitation.DisplayName}
+	items := []*dynamodb.TransactWriteItem{
+		{Update: r.invitationStatusUpdate(invitation.CalendarID, invitation.UserID, models.InvitationStatusAccepted, respondedAt)},
+		{Put: r.newItem(memberItem(invitation.CalendarID, user, invitation.AccessLevel))},
+		{Put: r.putItem(relatedItem(invitation.CalendarID, invitation.UserID))},
+		{Update: r.followerCountUpdate(invitation.CalendarID, 1)},
+	}
+	return transactWrite(ctx, r.dynamoDB, items, []error{
+		fmt.Errorf("%w: invitation is no longer pending", ErrConflict),
+		fmt.Errorf("%w: user %s is already a member of this calendar", ErrConflict, invitation.UserID),
+		nil,
+		fmt.Errorf("%w: calendar %s no longer exists", ErrConflict, invitation.CalendarID),
+	})
+}
+
+// RespondInvitation は回答待ちの招待を辞退・取り消しの状態にする。回答待ちでなくなっていた場合は ErrConflict を返す
+func (r *calendarRepository) RespondInvitation(ctx context.Context, calendarID string, userID string, status string, respondedAt time.Time) error {
+	update := r.invitationStatusUpdate(calendarID, userID, status, respondedAt)
+	_, err := r.dynamoDB.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 update.TableName,
+		Key:                       update.Key,
+		UpdateExpression:          update.UpdateExpression,
+		ConditionExpression:       update.ConditionExpression,
+		ExpressionAttributeNames:  update.ExpressionAttributeNames,
+		ExpressionAttributeValues: update.ExpressionAttributeValues,
+	})
+	if isConditionalCheckFailed(err) {
+		return fmt.Errorf("%w: invitation is no longer pending", ErrConflict)
+	}
+	return err
+}
+
+// invitationStatusUpdate は回答待ちで期限内の招待の状態を変える Update を組み立てる
+func (r *calendarRepository) invitationStatusUpdate(calendarID string, userID string, status string, respondedAt time.Time) *dynamodb.Update {
+	respondedAtValue, _ := dynamodbattribute.Marshal(respondedAt)
+	return &dynamodb.Update{
+		TableName:                aws.String(r.tableName),
+		Key:                      itemKey(calendarID, invitationSortKey(userID)),
+		UpdateExpression:         aws.String("SET #status = :status, RespondedAt = :respondedAt"),
+		ConditionExpression:      aws.String("attribute_exists(SortKey) AND " + notTrashed + " AND " + pendingInvitation),
+		ExpressionAttributeNames: map[string]*string{"#status": aws.String("Status")},
+		ExpressionAttributeValues: pendingValues(respondedAt, map[string]*dynamodb.AttributeValue{
+			":status":      {S: aws.String(status)},
+			":respondedAt": respondedAtValue,
+		}),
+	}
+}
+
+func unmarshalInvitations(items []item) ([]*models.Invitation, error) {
+	invitations := make([]*models.Invitation, 0, len(items))
+	for _, item := range items {
+		var invitation models.Invitation
+		err := dynamodbattribute.UnmarshalMap(item, &invitation)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, &invitation)
+	}
+	return invitations, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"bonded/internal/models"
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// UpdateMemberAccessLevel はメンバーの権限を変更する。メンバーでない場合やオーナーの場合は ErrConflict を返す
+func (r *calendarRepository) UpdateMemberAccessLevel(ctx context.Context, calendarID string, userID string, accessLevel string) error {
+	_, err := r.dynamoDB.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(r.tableName),
+		Key:                 itemKey(calendarID, "USER#"+userID),
+		UpdateExpression:    aws.String("SET AccessLevel = :accessLevel"),
+		ConditionExpression: aws.String("attribute_exists(SortKey) AND AccessLevel <> :owner"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":accessLevel": {S: aws.String(accessLevel)},
+			":owner":       {S: aws.String("OWNER")},
+		},
+	})
+	if isConditionalCheckFailed(err) {
+		return fmt.Errorf("%w: user %s is not a member that can be changed", ErrConflict, userID)
+	}
+	return err
+}
+
+// RemoveMember はメンバーをカレンダーから外す。メンバーでない場合やオーナーの場合は ErrConflict を返す
+func (r *calendarRepository) RemoveMember(ctx context.Context, calendarID string, userID string) error {
+	return r.removeMember(ctx, calendarID, userID, fmt.Errorf("%w: user %s is not a member that can be removed", ErrConflict, userID))
+}
+
+// removeMember は USER#・CAL# アイテムを削除してフォロワー数を減らす。オーナーのユーザーアイテムは削除しない
+func (r *calendarRepository) removeMember(ctx context.Context, calendarID string, userID string, notMember error) error {
+	items := []*dynamodb.TransactWriteItem{
+		{Delete: &dynamodb.Delete{
+			TableName:           aws.String(r.tableName),
+			Key:                 itemKey(calendarID, "USER#"+userID),
+			ConditionExpression: aws.String("attribute_exists(SortKey) AND AccessLevel <> :owner"),
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":owner": {S: aws.String("OWNER")},
+			},
+		}},
+		{Delete: &dynamodb.Delete{
+			TableName: aws.String(r.tableName),
+			Key:       itemKey(calendarID, fmt.Sprintf("CAL#%s#%s", calendarID, userID)),
+		}},
+		{Update: r.followerCountUpdate(calendarID, -1)},
+	}
+	return transactWrite(ctx, r.dynamoDB, items, []error{
+		notMember,
+		nil,
+		fmt.Errorf("%w: calendar %s no longer exists", ErrConflict, calendarID),
+	})
+}
+
+// TransferOwnership はカレンダーのオーナーを newOwner に移す。1つのトランザクションで
+// 今のオーナーを EDITOR に、新しいオーナーを OWNER にし、CALENDAR アイテムの OwnerUserID を書き換える。
+// CALENDAR アイテムが UserID-index に載らないよう UserID は持たせない（以前の移譲で書き込まれたものは削除する）。
+// オーナー以外のメンバー数は変わらないため FollowerCount はそのままにする。
+// カレンダーの版数が version から変わっていた場合は ErrPreconditionFailed を返す
+func (r *calendarRepository) TransferOwnership(ctx context.Context, calendar *models.Calendar, newOwner *models.User, version int64) (*models.Calendar, error) {
+	items := []*dynamodb.TransactWriteItem{
+		{Update: &dynamodb.Update{
+			TableName:           aws.String(r.tableName),
+			Key:                 itemKey(calendar.CalendarID, "USER#"+calendar.OwnerUserID),
+			UpdateExpression:    aws.String("SET AccessLevel = :editor"),
+			ConditionExpression: aws.String("AccessLevel = :owner"),
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":editor": {S: aws.String("EDITOR")},
+				":owner":  {S: aws.String("OWNER")},
+			},
+		}},
+		{Update: &dynamodb.Update{
+			TableName:           aws.String(r.tableName),
+			Key:                 itemKey(calendar.CalendarID, "USER#"+newOwner.UserID),
+			UpdateExpression:    aws.String("SET AccessLevel = :owner"),
+			ConditionExpression: aws.String("attribute_exists(SortKey) AND AccessLevel <> :owner"),
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":owner": {S: aws.String("OWNER")},
+			},
+		}},
+		{Update: &dynamodb.Update{
+			TableName:           aws.String(r.tableName),
+			Key:                 itemKey(calendar.CalendarID, "CALENDAR"),
+			UpdateExpression:    aws.String("SET OwnerUserID = :newOwner, OwnerName = :ownerName, Version = :nextVersion REMOVE UserID"),
+			ConditionExpression: aws.String("attribute_exists(SortKey) AND " + notTrashed + " AND OwnerUserID = :currentOwner AND " + versionCondition(version)),
+			ExpressionAttributeValues: versionValues(version, map[string]*dynamodb.AttributeValue{
+				":newOwner":     {S: aws.String(newOwner.UserID)},
+				":ownerName":    {S: aws.String(newOwner.DisplayName)},
+				":currentOwner": {S: aws.String(calendar.OwnerUserID)},
+			}),
+		}},
+	}
+	err := transactWrite(ctx, r.dynamoDB, items, []error{
+		fmt.Errorf("%w: user %s is no longer the owner", ErrConflict, calendar.OwnerUserID),
+		fmt.Errorf("%w: user %s is not a member that can become the owner", ErrConflict, newOwner.UserID),
+		fmt.Errorf("%w: calendar %s has been modified", ErrPreconditionFailed, calendar.CalendarID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.findCalendarItem(ctx, calendar.CalendarID)
+}
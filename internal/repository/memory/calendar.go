@@ -0,0 +1,226 @@
+package memory
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/repository"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type calendarRepository struct {
+	store *Store
+}
+
+func CalendarRepositoryRequest(store *Store) repository.CalendarRepository {
+	return &calendarRepository{store: store}
+}
+
+// Create はカレンダーとオーナーのメンバーシップ・関連アイテムを作成する。カレンダーがすでにある場合は ErrConflict を返す
+func (r *calendarRepository) Create(ctx context.Context, calendar *models.Calendar) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	owner := calendar.Users[0]
+	p := s.partition(calendar.CalendarID, false)
+	if p != nil && p.calendar != nil {
+		return fmt.Errorf("%w: calendar %s already exists", repository.ErrConflict, calendar.CalendarID)
+	}
+	if p != nil && (p.relations[relationKey(calendar.CalendarID, owner.UserID)] != "" || p.members[owner.UserID] != nil) {
+		return repository.ErrConflict
+	}
+
+	isPublic := *calendar.IsPublic
+	p = s.partition(calendar.CalendarID, true)
+	p.calendar = &models.Calendar{
+		CalendarID:  calendar.CalendarID,
+		SortKey:     "CALENDAR",
+		Name:        calendar.Name,
+		IsPublic:    &isPublic,
+		OwnerUserID: calendar.OwnerUserID,
+		TimeZone:    calendar.TimeZone,
+		OwnerName:   calendar.OwnerName,
+		Version:     1,
+	}
+	p.relations[relationKey(calendar.CalendarID, owner.UserID)] = owner.UserID
+	p.members[owner.UserID] = &models.User{UserID: owner.UserID, DisplayName: owner.DisplayName, AccessLevel: owner.AccessLevel}
+	return nil
+}
+
+// Edit はカレンダーの属性を更新し、更新後の CALENDAR アイテムを返す。
+// 版数が version から変わっていた場合は ErrPreconditionFailed を返す
+func (r *calendarRepository) Edit(ctx context.Context, calendarID *models.Calendar, input *models.EditCalendar, version int64) (*models.Calendar, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calendar, err := findCalendarItem(s, calendarID.CalendarID)
+	if err != nil {
+		return nil, err
+	}
+	if calendar.DeletedAt != nil || calendar.Version != version {
+		return nil, fmt.Errorf("%w: calendar %s has been modified", repository.ErrPreconditionFailed, calendar.CalendarID)
+	}
+
+	if input.Name != "" {
+		calendar.Name = input.Name
+	}
+	if input.IsPublic != nil {
+		calendar.IsPublic = clonePointer(input.IsPublic)
+	}
+	if input.TimeZone != "" {
+		calendar.TimeZone = input.TimeZone
+	}
+	calendar.Version++
+	return cloneCalendar(calendar), nil
+}
+
+// FindByCalendarID はカレンダーとイベント、メンバーを取得する。ゴミ箱のカレンダーとイベントは含まない
+func (r *calendarRepository) FindByCalendarID(ctx context.Context, calendarID string) (*models.Calendar, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return findByCalendarID(s, calendarID)
+}
+
+func findByCalendarID(s *Store, calendarID string) (*models.Calendar, error) {
+	item, err := findCalendarItem(s, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	if item.DeletedAt != nil {
+		return nil, fmt.Errorf("%w: calendar %s is in the trash", repository.ErrNotFound, calendarID)
+	}
+
+	p := s.partition(calendarID, false)
+	calendar := cloneCalendar(item)
+	for _, event := range repository.FoldOverrides(activeEvents(p, "")) {
+		calendar.Events = append(calendar.Events, *event)
+	}
+	for _, userID := range sortedKeys(p.members) {
+		calendar.Users = append(calendar.Users, *p.members[userID])
+	}
+	return calendar, nil
+}
+
+// findCalendarItem はゴミ箱にあるかどうかに関わらず CALENDAR アイテムを返す。呼び出し元はロックを取っておく
+func findCalendarItem(s *Store, calendarID string) (*models.Calendar, error) {
+	calendar := s.calendarItem(calendarID)
+	if calendar == nil {
+		return nil, fmt.Errorf("%w: calendar with CalendarID %s", repository.ErrNotFound, calendarID)
+	}
+	return calendar, nil
+}
+
+// FindByUserID はユーザーが所属するカレンダーの概要を page.Limit 件ずつ取得する
+func (r *calendarRepository) FindByUserID(ctx context.Context, userID string, page models.PageRequest) (*models.Page[*models.CalendarSummary], error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	memberships, cursor, err := paginate(s.memberships(userID), membershipKey, page)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]*models.CalendarSummary, 0, len(memberships))
+	for _, m := range memberships {
+		calendar := s.calendarItem(m.calendarID)
+		// 削除の途中で残ったメンバーシップやゴミ箱のカレンダーは読み飛ばす
+		if calendar == nil || calendar.DeletedAt != nil {
+			continue
+		}
+		summary := summaryOf(calendar)
+		summary.AccessLevel = m.user.AccessLevel
+		summaries = append(summaries, summary)
+	}
+	return &models.Page[*models.CalendarSummary]{Items: summaries, NextCursor: cursor}, nil
+}
+
+// summaryOf は CALENDAR アイテムの概要を返す
+func summaryOf(calendar *models.Calendar) *models.CalendarSummary {
+	return &models.CalendarSummary{
+		CalendarID:    calendar.CalendarID,
+		Name:          calendar.Name,
+		IsPublic:      clonePointer(calendar.IsPublic),
+		OwnerUserID:   calendar.OwnerUserID,
+		OwnerName:     calendar.OwnerName,
+		FollowerCount: calendar.FollowerCount,
+		TimeZone:      calendar.TimeZone,
+		Version:       calendar.Version,
+	}
+}
+
+func membershipKey(m membership) string {
+	return m.calendarID
+}
+
+// FindPublicCalendars は公開カレンダーの概要を名前順に page.Limit 件ずつ取得する。ゴミ箱のカレンダーは含まない
+func (r *calendarRepository) FindPublicCalendars(ctx context.Context, page models.PageRequest) (*models.Page[*models.CalendarSummary], error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var listed []*models.Calendar
+	for _, p := range s.partitions {
+		if p.calendar != nil && isPublic(p.calendar) && !p.trashed() {
+			listed = append(listed, p.calendar)
+		}
+	}
+	sort.Slice(listed, func(i, j int) bool { return publicSortKey(listed[i]) < publicSortKey(listed[j]) })
+
+	listed, cursor, err := paginate(listed, publicSortKey, page)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]*models.CalendarSummary, 0, len(listed))
+	for _, calendar := range listed {
+		summaries = append(summaries, summaryOf(calendar))
+	}
+	return &models.Page[*models.CalendarSummary]{Items: summaries, NextCursor: cursor}, nil
+}
+
+// publicSortKey は公開カレンダーの一覧を名前順に並べるためのキー
+func publicSortKey(calendar *models.Calendar) string {
+	return strings.ToLower(calendar.Name) + "#" + calendar.CalendarID
+}
+
+func isPublic(calendar *models.Calendar) bool {
+	return calendar.IsPublic != nil && *calendar.IsPublic
+}
+
+// FindMember はカレンダーの USER# アイテムを取得する。メンバーでない場合は nil を返す
+func (r *calendarRepository) FindMember(ctx context.Context, calendarID string, userID string) (*models.User, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	member := s.member(calendarID, userID)
+	if member == nil {
+		return nil, nil
+	}
+	return cloneValue(member), nil
+}
+
+func (r *calendarRepository) FollowCalendar(ctx context.Context, calendar *models.Calendar, user *models.User) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// すでにメンバーの場合は権限を上書きせず、フォロワー数も増やさない
+	return addMember(s, calendar.CalendarID, user, models.AccessLevelViewer)
+}
+
+func (r *calendarRepository) UnfollowCalendar(ctx context.Context, calendar *models.Calendar, user *models.User) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return removeMember(s, calendar.CalendarID, user.UserID, fmt.Errorf("%w: user %s is not a follower of this calendar", repository.ErrConflict, user.UserID))
+}
+
+// relationKey はユーザー（またはイベント）とカレンダーを結ぶ CAL# アイテムのキー
+func relationKey(calendarID string, id string) string {
+	return calendarID + "#" + id
+}
@@ -0,0 +1,262 @@
+package memory
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/repository"
+	"context"
+	"fmt"
+	"strings"
+)
+
+type eventRepository struct {
+	store *Store
+}
+
+func EventRepositoryRequest(store *Store) repository.EventRepository {
+	return &eventRepository{store: store}
+}
+
+// overrideKey は繰り返しイベントの個別の発生のキー（EVENT#<eventId>#<recurrenceId> の EVENT# より後ろ）
+func overrideKey(eventID string, recurrenceID string) string {
+	return eventID + "#" + recurrenceID
+}
+
+// activeEvents は prefix で始まるキーのイベントのうちゴミ箱にないものをキーの順に複製して返す
+func activeEvents(p *partition, prefix string) []*models.Event {
+	events := []*models.Event{}
+	if p == nil {
+		return events
+	}
+	for _, key := range sortedKeys(p.events) {
+		if event := p.events[key]; strings.HasPrefix(key, prefix) && event.DeletedAt == nil {
+			events = append(events, cloneEvent(event))
+		}
+	}
+	return events
+}
+
+func (r *eventRepository) CreateEvent(ctx context.Context, calendar *models.Calendar, event *models.Event) error {
+	calendar.Events = append(calendar.Events, *event)
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.partition(calendar.CalendarID, true)
+	p.events[event.EventID] = cloneEvent(event)
+	p.relations[relationKey(calendar.CalendarID, event.EventID)] = calendar.OwnerUserID
+	return nil
+}
+
+// FindEvents はゴミ箱にないイベントをすべて取得する。繰り返しの個別の発生は繰り返し元の Overrides にまとめる
+func (r *eventRepository) FindEvents(ctx context.Context, calendarID string) ([]*models.Event, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return repository.FoldOverrides(activeEvents(s.partition(calendarID, false), "")), nil
+}
+
+// FindEventsPage は繰り返し元と単発のイベントを page.Limit 件ずつ取得する。個別の発生は繰り返し元の Overrides にまとめる
+func (r *eventRepository) FindEventsPage(ctx context.Context, calendarID string, page models.PageRequest) (*models.Page[*models.Event], error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := repository.FoldOverrides(activeEvents(s.partition(calendarID, false), ""))
+	events, cursor, err := paginate(events, eventKey, page)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Page[*models.Event]{Items: events, NextCursor: cursor}, nil
+}
+
+func eventKey(event *models.Event) string {
+	return event.EventID
+}
+
+// FindEventsBetween は期間と重なりうるイベントを個別の発生も含めて取得する。DynamoDB の実装と同じく厳密な判定は呼び出し側で行うため、
+// ゴミ箱にないイベントをすべて返す
+func (r *eventRepository) FindEventsBetween(ctx context.Context, calendarID string, window models.TimeRange) ([]*models.Event, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return activeEvents(s.partition(calendarID, false), ""), nil
+}
+
+// FindEvent はイベントを1件取得する。存在しないかゴミ箱にある場合は nil を返す
+func (r *eventRepository) FindEvent(ctx context.Context, calendarID string, eventID string) (*models.Event, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	event := findEventItem(s, calendarID, eventID)
+	if event == nil || event.DeletedAt != nil {
+		return nil, nil
+	}
+	return cloneEvent(event), nil
+}
+
+// findEventItem はゴミ箱にあるかどうかに関わらずイベントのアイテムを返す。呼び出し元はロックを取っておく
+func findEventItem(s *Store, calendarID string, eventID string) *models.Event {
+	p := s.partition(calendarID, false)
+	if p == nil {
+		return nil
+	}
+	return p.events[eventID]
+}
+
+func (r *eventRepository) EventExists(ctx context.Context, calendarID string, eventID string) bool {
+	event, err := r.FindEvent(ctx, calendarID, eventID)
+	return err == nil && event != nil
+}
+
+// editableEvent は版数が version のままでゴミ箱にないイベントを返す。そうでない場合は ErrPreconditionFailed を返す
+func editableEvent(s *Store, calendarID string, eventID string, version int64) (*models.Event, error) {
+	event := findEventItem(s, calendarID, eventID)
+	if event == nil || event.DeletedAt != nil || event.Version != version {
+		return nil, fmt.Errorf("%w: event %s has been modified", repository.ErrPreconditionFailed, eventID)
+	}
+	return event, nil
+}
+
+// EditEvent はイベントの fields に含まれるフィールドを更新し、版数を1つ進める。
+// 版数が version から変わっていた場合は ErrPreconditionFailed を返す
+func (r *eventRepository) EditEvent(ctx context.Context, calendarID string, event *models.Event, fields []models.EventField, version int64) (*models.Event, error) {
+	for _, field := range fields {
+		if !knownField(field) {
+			return nil, fmt.Errorf("unknown event field %q", field)
+		}
+	}
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := editableEvent(s, calendarID, event.EventID, version)
+	if err != nil {
+		return nil, err
+	}
+	updated := applyFields(stored, event, fields)
+	s.partition(calendarID, false).events[event.EventID] = updated
+	return cloneEvent(updated), nil
+}
+
+// applyFields は stored を複製し、fields に含まれるフィールドを event の値にして版数を1つ進めたものを返す
+func applyFields(stored *models.Event, event *models.Event, fields []models.EventField) *models.Event {
+	updated := cloneEvent(stored)
+	input := cloneEvent(event)
+	for _, field := range fields {
+		switch field {
+		case models.EventFieldTitle:
+			updated.Title = input.Title
+		case models.EventFieldDescription:
+			updated.Description = input.Description
+		case models.EventFieldStartTime:
+			updated.StartTime = input.StartTime
+		case models.EventFieldEndTime:
+			updated.EndTime = input.EndTime
+		case models.EventFieldTimeZone:
+			updated.TimeZone = input.TimeZone
+		case models.EventFieldLocation:
+			updated.Location = input.Location
+		case models.EventFieldAllDay:
+			updated.AllDay = input.AllDay
+		case models.EventFieldRRule:
+			updated.RRule = input.RRule
+		case models.EventFieldRDates:
+			updated.RDates = input.RDates
+		case models.EventFieldExDates:
+			updated.ExDates = input.ExDates
+		}
+	}
+	updated.Version++
+	return updated
+}
+
+// EditSeries は繰り返し元の更新、個別の発生の削除、following の作成をまとめて行う。
+// 繰り返し元の版数が version から変わっていた場合は何も書き込まずに ErrPreconditionFailed を返す
+func (r *eventRepository) EditSeries(ctx context.Context, calendar *models.Calendar, master *models.Event, fields []models.EventField, version int64, removedOverrides []string, following *models.Event) error {
+	for _, field := range fields {
+		if !knownField(field) {
+			return fmt.Errorf("unknown event field %q", field)
+		}
+	}
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := editableEvent(s, calendar.CalendarID, master.EventID, version)
+	if err != nil {
+		return err
+	}
+	p := s.partition(calendar.CalendarID, false)
+	if following != nil && p.events[following.EventID] != nil {
+		return repository.ErrConflict
+	}
+	p.events[master.EventID] = applyFields(stored, master, fields)
+	for _, recurrenceID := range removedOverrides {
+		delete(p.events, overrideKey(master.EventID, recurrenceID))
+	}
+	if following != nil {
+		p.events[following.EventID] = cloneEvent(following)
+		p.relations[relationKey(calendar.CalendarID, following.EventID)] = calendar.OwnerUserID
+	}
+	return nil
+}
+
+func knownField(field models.EventField) bool {
+	switch field {
+	case models.EventFieldTitle, models.EventFieldDescription, models.EventFieldStartTime, models.EventFieldEndTime,
+		models.EventFieldTimeZone, models.EventFieldLocation, models.EventFieldAllDay, models.EventFieldRRule,
+		models.EventFieldRDates, models.EventFieldExDates:
+		return true
+	}
+	return false
+}
+
+// SaveAttendees はイベントの参加者を置き換え、版数を1つ進める。版数が version から変わっていた場合は ErrPreconditionFailed を返す
+func (r *eventRepository) SaveAttendees(ctx context.Context, calendarID string, eventID string, attendees []models.Attendee, version int64) (*models.Event, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, err := editableEvent(s, calendarID, eventID, version)
+	if err != nil {
+		return nil, err
+	}
+	event.Attendees = append([]models.Attendee(nil), attendees...)
+	event.Version++
+	return cloneEvent(event), nil
+}
+
+// FindOverrides は繰り返しイベントの個別の発生を取得する
+func (r *eventRepository) FindOverrides(ctx context.Context, calendarID string, eventID string) ([]*models.Event, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return activeEvents(s.partition(calendarID, false), overrideKey(eventID, "")), nil
+}
+
+// SaveOverride は繰り返しイベントの個別の発生を保存し、繰り返し元の版数を1つ進める。
+// 繰り返し元の版数が version から変わっていた場合は ErrPreconditionFailed を返す
+func (r *eventRepository) SaveOverride(ctx context.Context, calendarID string, override *models.Event, version int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	master, err := editableEvent(s, calendarID, override.RecurringEventID, version)
+	if err != nil {
+		return err
+	}
+	// 版数と参加者は繰り返し元で管理する
+	stored := cloneEvent(override)
+	stored.Version = 0
+	stored.Attendees = nil
+	s.partition(calendarID, false).events[overrideKey(override.RecurringEventID, override.RecurrenceID)] = stored
+	master.Version++
+	return nil
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/repository"
+	"context"
+	"fmt"
+)
+
+// CreateFeedToken はフィードトークンを保存する。同じハッシュのトークンがすでにある場合は ErrConflict を返す
+func (r *calendarRepository) CreateFeedToken(ctx context.Context, token *models.FeedToken) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.partition(token.CalendarID, true)
+	if p.feedTokens[token.TokenHash] != nil {
+		return fmt.Errorf("%w: feed token already exists", repository.ErrConflict)
+	}
+	stored := cloneValue(token)
+	stored.Token, stored.FeedURL, stored.WebcalURL = "", "", ""
+	p.feedTokens[token.TokenHash] = stored
+	return nil
+}
+
+// FindFeedToken はハッシュからフィードトークンを取得する。存在しない場合は nil を返す
+func (r *calendarRepository) FindFeedToken(ctx context.Context, calendarID string, tokenHash string) (*models.FeedToken, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p := s.partition(calendarID, false)
+	if p == nil || p.feedTokens[tokenHash] == nil {
+		return nil, nil
+	}
+	return cloneValue(p.feedTokens[tokenHash]), nil
+}
+
+// FindFeedTokens はメンバーが発行したフィードトークンの一覧を取得する
+func (r *calendarRepository) FindFeedTokens(ctx context.Context, calendarID string, userID string) ([]*models.FeedToken, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokens := []*models.FeedToken{}
+	p := s.partition(calendarID, false)
+	if p == nil {
+		return tokens, nil
+	}
+	for _, tokenHash := range sortedKeys(p.feedTokens) {
+		if token := p.feedTokens[tokenHash]; token.UserID == userID {
+			tokens = append(tokens, cloneValue(token))
+		}
+	}
+	return tokens, nil
+}
+
+func (r *calendarRepository) DeleteFeedToken(ctx context.Context, calendarID string, tokenHash string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p := s.partition(calendarID, false); p != nil {
+		delete(p.feedTokens, tokenHash)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/repository"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// pending は招待が回答待ちで期限内かを返す。期限は保存した属性と同じく秒単位で比べる
+func pending(invitation *models.Invitation, now time.Time) bool {
+	return invitation.Status == models.InvitationStatusPending && invitation.ValidUntil.Unix() > now.Unix()
+}
+
+// CreateInvitation は招待を保存する。回答待ちの招待がすでにある場合やメンバーになっている場合は ErrConflict を返す。
+// 回答済み・期限切れの招待は新しい招待で置き換える
+func (r *calendarRepository) CreateInvitation(ctx context.Context, invitation *models.Invitation) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p := s.partition(invitation.CalendarID, false); p != nil {
+		if existing := p.invitations[invitation.UserID]; existing != nil && pending(existing, invitation.CreatedAt) {
+			return fmt.Errorf("%w: user %s already has a pending invitation", repository.ErrConflict, invitation.UserID)
+		}
+	}
+	if s.member(invitation.CalendarID, invitation.UserID) != nil {
+		return fmt.Errorf("%w: user %s is already a member of this calendar", repository.ErrConflict, invitation.UserID)
+	}
+	calendar := s.calendarItem(invitation.CalendarID)
+	if calendar == nil || calendar.DeletedAt != nil {
+		return fmt.Errorf("%w: calendar %s no longer exists", repository.ErrConflict, invitation.CalendarID)
+	}
+
+	s.partition(invitation.CalendarID, false).invitations[invitation.UserID] = cloneInvitation(invitation)
+	return nil
+}
+
+// FindInvitation はカレンダーへのユーザーの招待を取得する。存在しない場合は nil を返す
+func (r *calendarRepository) FindInvitation(ctx context.Context, calendarID string, userID string) (*models.Invitation, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p := s.partition(calendarID, false)
+	if p == nil || p.invitations[userID] == nil {
+		return nil, nil
+	}
+	return cloneInvitation(p.invitations[userID]), nil
+}
+
+// FindInvitations はカレンダーの招待を状態にかかわらずすべて取得する
+func (r *calendarRepository) FindInvitations(ctx context.Context, calendarID string) ([]*models.Invitation, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	invitations := []*models.Invitation{}
+	p := s.partition(calendarID, false)
+	if p == nil {
+		return invitations, nil
+	}
+	for _, userID := range sortedKeys(p.invitations) {
+		invitations = append(invitations, cloneInvitation(p.invitations[userID]))
+	}
+	return invitations, nil
+}
+
+// FindInvitationsByUserID はユーザーが受け取った招待を取得する。ゴミ箱にあるカレンダーへの招待は含めない
+func (r *calendarRepository) FindInvitationsByUserID(ctx context.Context, userID string, page models.PageRequest) (*models.Page[*models.Invitation], error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var received []*models.Invitation
+	for _, p := range s.partitions {
+		if invitation := p.invitations[userID]; invitation != nil && !p.trashed() {
+			received = append(received, invitation)
+		}
+	}
+	sort.Slice(received, func(i, j int) bool { return received[i].CalendarID < received[j].CalendarID })
+
+	received, cursor, err := paginate(received, invitationKey, page)
+	if err != nil {
+		return nil, err
+	}
+	invitations := make([]*models.Invitation, 0, len(received))
+	for _, invitation := range received {
+		invitations = append(invitations, cloneInvitation(invitation))
+	}
+	return &models.Page[*models.Invitation]{Items: invitations, NextCursor: cursor}, nil
+}
+
+func invitationKey(invitation *models.Invitation) string {
+	return invitation.CalendarID
+}
+
+// AcceptInvitation は回答待ちの招待を承諾し、招待された権限のメンバーとして追加する。
+// 招待が回答待ちでなくなっていた場合やすでにメンバーの場合は ErrConflict を返す
+func (r *calendarRepository) AcceptInvitation(ctx context.Context, invitation *models.Invitation, respondedAt time.Time) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := pendingInvitation(s, invitation.CalendarID, invitation.UserID, respondedAt)
+	if err != nil {
+		return err
+	}
+	user := &models.User{UserID: invitation.UserID, DisplayName: invitation.DisplayName}
+	err = addMember(s, invitation.CalendarID, user, invitation.AccessLevel)
+	if err != nil {
+		return err
+	}
+	respond(stored, models.InvitationStatusAccepted, respondedAt)
+	return nil
+}
+
+// RespondInvitation は回答待ちの招待を辞退・取り消しの状態にする。回答待ちでなくなっていた場合は ErrConflict を返す
+func (r *calendarRepository) RespondInvitation(ctx context.Context, calendarID string, userID string, status string, respondedAt time.Time) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := pendingInvitation(s, calendarID, userID, respondedAt)
+	if err != nil {
+		return err
+	}
+	respond(stored, status, respondedAt)
+	return nil
+}
+
+// pendingInvitation は回答待ちで期限内の招待を返す。ない場合は ErrConflict を返す
+func pendingInvitation(s *Store, calendarID string, userID string, now time.Time) (*models.Invitation, error) {
+	p := s.partition(calendarID, false)
+	if p == nil || p.invitations[userID] == nil || !pending(p.invitations[userID], now) {
+		return nil, fmt.Errorf("%w: invitation is no longer pending", repository.ErrConflict)
+	}
+	return p.invitations[userID], nil
+}
+
+func respond(invitation *models.Invitation, status string, respondedAt time.Time) {
+	invitation.Status = status
+	invitation.RespondedAt = &respondedAt
+}
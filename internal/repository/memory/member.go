@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/repository"
+	"context"
+	"fmt"
+)
+
+// UpdateMemberAccessLevel はメンバーの権限を変更する。メンバーでない場合やオーナーの場合は ErrConflict を返す
+func (r *calendarRepository) UpdateMemberAccessLevel(ctx context.Context, calendarID string, userID string, accessLevel string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	member := s.member(calendarID, userID)
+	if member == nil || member.AccessLevel == models.AccessLevelOwner {
+		return fmt.Errorf("%w: user %s is not a member that can be changed", repository.ErrConflict, userID)
+	}
+	member.AccessLevel = accessLevel
+	return nil
+}
+
+// RemoveMember はメンバーをカレンダーから外す。メンバーでない場合やオーナーの場合は ErrConflict を返す
+func (r *calendarRepository) RemoveMember(ctx context.Context, calendarID string, userID string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return removeMember(s, calendarID, userID, fmt.Errorf("%w: user %s is not a member that can be removed", repository.ErrConflict, userID))
+}
+
+// addMember は USER#・CAL# アイテムを追加してフォロワー数を増やす。すでにメンバーの場合やカレンダーがないかゴミ箱にある場合は ErrConflict を返す
+func addMember(s *Store, calendarID string, user *models.User, accessLevel string) error {
+	if s.member(calendarID, user.UserID) != nil {
+		return fmt.Errorf("%w: user %s is already a member of this calendar", repository.ErrConflict, user.UserID)
+	}
+	if !live(s, calendarID) {
+		return fmt.Errorf("%w: calendar %s no longer exists", repository.ErrConflict, calendarID)
+	}
+
+	p := s.partition(calendarID, false)
+	p.members[user.UserID] = &models.User{UserID: user.UserID, DisplayName: user.DisplayName, AccessLevel: accessLevel}
+	p.relations[relationKey(calendarID, user.UserID)] = user.UserID
+	p.calendar.FollowerCount++
+	return nil
+}
+
+// removeMember は USER#・CAL# アイテムを削除してフォロワー数を減らす。オーナーのユーザーアイテムは削除しない
+func removeMember(s *Store, calendarID string, userID string, notMember error) error {
+	member := s.member(calendarID, userID)
+	if member == nil || member.AccessLevel == models.AccessLevelOwner {
+		return notMember
+	}
+	if !live(s, calendarID) {
+		return fmt.Errorf("%w: calendar %s no longer exists", repository.ErrConflict, calendarID)
+	}
+
+	p := s.partition(calendarID, false)
+	delete(p.members, userID)
+	delete(p.relations, relationKey(calendarID, userID))
+	p.calendar.FollowerCount--
+	return nil
+}
+
+// live はフォロワー数を更新できる（カレンダーがあり、ゴミ箱にない）かを返す
+func live(s *Store, calendarID string) bool {
+	calendar := s.calendarItem(calendarID)
+	return calendar != nil && calendar.DeletedAt == nil
+}
+
+// TransferOwnership はカレンダーのオーナーを newOwner に移す。今のオーナーを EDITOR に、新しいオーナーを OWNER にし、
+// CALENDAR アイテムの OwnerUserID を書き換える。カレンダーの版数が version から変わっていた場合は ErrPreconditionFailed を返す
+func (r *calendarRepository) TransferOwnership(ctx context.Context, calendar *models.Calendar, newOwner *models.User, version int64) (*models.Calendar, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.member(calendar.CalendarID, calendar.OwnerUserID)
+	if current == nil || current.AccessLevel != models.AccessLevelOwner {
+		return nil, fmt.Errorf("%w: user %s is no longer the owner", repository.ErrConflict, calendar.OwnerUserID)
+	}
+	next := s.member(calendar.CalendarID, newOwner.UserID)
+	if next == nil || next.AccessLevel == models.AccessLevelOwner {
+		return nil, fmt.Errorf("%w: user %s is not a member that can become the owner", repository.ErrConflict, newOwner.UserID)
+	}
+	item := s.calendarItem(calendar.CalendarID)
+	if item == nil || item.DeletedAt != nil || item.OwnerUserID != calendar.OwnerUserID || item.Version != version {
+		return nil, fmt.Errorf("%w: calendar %s has been modified", repository.ErrPreconditionFailed, calendar.CalendarID)
+	}
+
+	current.AccessLevel = models.AccessLevelEditor
+	next.AccessLevel = models.AccessLevelOwner
+	item.OwnerUserID = newOwner.UserID
+	item.OwnerName = newOwner.DisplayName
+	item.Version++
+	return cloneCalendar(item), nil
+}
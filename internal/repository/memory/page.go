@@ -0,0 +1,33 @@
+package memory
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/repository"
+	"encoding/base64"
+)
+
+// paginate は key の順に並んだ items から、カーソルのキーより後ろの page.Limit 件を返す。
+// カーソルは最後に返した項目のキーで、続きがない場合は空にする
+func paginate[T any](items []T, key func(T) string, page models.PageRequest) ([]T, string, error) {
+	start := 0
+	if page.Cursor != "" {
+		data, err := base64.RawURLEncoding.DecodeString(page.Cursor)
+		if err != nil || len(data) == 0 {
+			return nil, "", repository.ErrInvalidCursor
+		}
+		last := string(data)
+		for start < len(items) && key(items[start]) <= last {
+			start++
+		}
+	}
+
+	end := len(items)
+	if page.Limit > 0 && start+page.Limit < end {
+		end = start + page.Limit
+	}
+	result := items[start:end]
+	if end == len(items) {
+		return result, "", nil
+	}
+	return result, base64.RawURLEncoding.EncodeToString([]byte(key(result[len(result)-1]))), nil
+}
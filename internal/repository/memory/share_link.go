@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/repository"
+	"context"
+	"fmt"
+	"time"
+)
+
+// CreateShareLink は共有リンクを保存する。カレンダーが存在しない・ゴミ箱にある場合は ErrConflict を返す
+func (r *calendarRepository) CreateShareLink(ctx context.Context, link *models.ShareLink) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p := s.partition(link.CalendarID, false); p != nil && p.shareLinks[link.TokenHash] != nil {
+		return fmt.Errorf("%w: share link already exists", repository.ErrConflict)
+	}
+	calendar := s.calendarItem(link.CalendarID)
+	if calendar == nil || calendar.DeletedAt != nil {
+		return fmt.Errorf("%w: calendar %s no longer exists", repository.ErrConflict, link.CalendarID)
+	}
+
+	stored := cloneValue(link)
+	stored.Token = ""
+	s.partition(link.CalendarID, false).shareLinks[link.TokenHash] = stored
+	return nil
+}
+
+// FindShareLink はハッシュから共有リンクを取得する。存在しない場合は nil を返す
+func (r *calendarRepository) FindShareLink(ctx context.Context, calendarID string, tokenHash string) (*models.ShareLink, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p := s.partition(calendarID, false)
+	if p == nil || p.shareLinks[tokenHash] == nil {
+		return nil, nil
+	}
+	return cloneValue(p.shareLinks[tokenHash]), nil
+}
+
+// FindShareLinks はカレンダーの共有リンクを期限切れ・上限に達したものも含めてすべて取得する
+func (r *calendarRepository) FindShareLinks(ctx context.Context, calendarID string) ([]*models.ShareLink, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	links := []*models.ShareLink{}
+	p := s.partition(calendarID, false)
+	if p == nil {
+		return links, nil
+	}
+	for _, tokenHash := range sortedKeys(p.shareLinks) {
+		links = append(links, cloneValue(p.shareLinks[tokenHash]))
+	}
+	return links, nil
+}
+
+func (r *calendarRepository) DeleteShareLink(ctx context.Context, calendarID string, tokenHash string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p := s.partition(calendarID, false); p != nil {
+		delete(p.shareLinks, tokenHash)
+	}
+	return nil
+}
+
+// RedeemShareLink は共有リンクの利用回数を増やし、ユーザーをリンクの権限のメンバーとして追加する。
+// リンクが取り消された・期限切れ・上限に達した場合やすでにメンバーの場合は ErrConflict を返す
+func (r *calendarRepository) RedeemShareLink(ctx context.Context, link *models.ShareLink, user *models.User, now time.Time) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stored *models.ShareLink
+	if p := s.partition(link.CalendarID, false); p != nil {
+		stored = p.shareLinks[link.TokenHash]
+	}
+	if stored == nil || stored.ValidUntil.Unix() <= now.Unix() || stored.UseCount >= stored.MaxUses {
+		return fmt.Errorf("%w: share link has been revoked, has expired or has reached its usage limit", repository.ErrConflict)
+	}
+	err := addMember(s, link.CalendarID, user, link.AccessLevel)
+	if err != nil {
+		return err
+	}
+	stored.UseCount++
+	return nil
+}
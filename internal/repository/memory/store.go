@@ -0,0 +1,155 @@
+// Package memory は repository のインターフェースをメモリ上で実装する。DynamoDB のテーブルと同じく
+// カレンダーごとのパーティションにアイテムを持ち、条件付きの書き込みが失敗した場合は repository と同じエラーを返す。
+// DynamoDB Local なしでユースケースやハンドラーを動かすテストに使う
+package memory
+
+import (
+	"bonded/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store はメモリ上のテーブル。同じ Store から作ったリポジトリはアイテムを共有する
+type Store struct {
+	mu         sync.RWMutex
+	partitions map[string]*partition      // CalendarID ごとのパーティション
+	profiles   map[string]*models.Profile // PROFILE#<userId> パーティションの PROFILE アイテム
+}
+
+// partition はカレンダーのパーティションにあるアイテム。マップのキーは SortKey の接頭辞を除いた部分
+type partition struct {
+	calendar    *models.Calendar              // CALENDAR アイテム（Users・Events は持たない）
+	members     map[string]*models.User       // USER#<userId>
+	relations   map[string]string             // CAL#<calendarId>#<userId または eventId> と UserID
+	events      map[string]*models.Event      // EVENT#<eventId> と EVENT#<eventId>#<recurrenceId>
+	feedTokens  map[string]*models.FeedToken  // FEED#<tokenHash>
+	invitations map[string]*models.Invitation // INVITE#<userId>
+	shareLinks  map[string]*models.ShareLink  // LINK#<tokenHash>
+}
+
+// NewStore は空の Store を作る
+func NewStore() *Store {
+	return &Store{
+		partitions: map[string]*partition{},
+		profiles:   map[string]*models.Profile{},
+	}
+}
+
+// partition は calendarID のパーティションを返す。create が true であればなければ作る
+func (s *Store) partition(calendarID string, create bool) *partition {
+	p, ok := s.partitions[calendarID]
+	if !ok && create {
+		p = &partition{
+			members:     map[string]*models.User{},
+			relations:   map[string]string{},
+			events:      map[string]*models.Event{},
+			feedTokens:  map[string]*models.FeedToken{},
+			invitations: map[string]*models.Invitation{},
+			shareLinks:  map[string]*models.ShareLink{},
+		}
+		s.partitions[calendarID] = p
+	}
+	return p
+}
+
+// calendarItem は CALENDAR アイテムを返す。パーティションやアイテムがない場合は nil を返す
+func (s *Store) calendarItem(calendarID string) *models.Calendar {
+	p := s.partition(calendarID, false)
+	if p == nil {
+		return nil
+	}
+	return p.calendar
+}
+
+// member はカレンダーの USER# アイテムを返す。メンバーでない場合は nil を返す
+func (s *Store) member(calendarID string, userID string) *models.User {
+	p := s.partition(calendarID, false)
+	if p == nil {
+		return nil
+	}
+	return p.members[userID]
+}
+
+// membership は UserID-index に載る USER# アイテムと、そのパーティションの CalendarID
+type membership struct {
+	calendarID string
+	user       *models.User
+}
+
+// memberships はユーザーのメンバーシップを CalendarID 順に返す
+func (s *Store) memberships(userID string) []membership {
+	var result []membership
+	for calendarID, p := range s.partitions {
+		if user, ok := p.members[userID]; ok {
+			result = append(result, membership{calendarID: calendarID, user: user})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].calendarID < result[j].calendarID })
+	return result
+}
+
+// trashed はパーティションのアイテムに TTL が設定されている（カレンダーがゴミ箱にある）かを返す
+func (p *partition) trashed() bool {
+	return p.calendar != nil && p.calendar.DeletedAt != nil
+}
+
+// sortedKeys はマップのキーを SortKey の順に返す
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// 保存したアイテムを呼び出し元が書き換えても影響しないよう、読み書きのたびに複製する
+
+func cloneCalendar(calendar *models.Calendar) *models.Calendar {
+	c := *calendar
+	c.IsPublic = clonePointer(calendar.IsPublic)
+	c.DeletedAt = clonePointer(calendar.DeletedAt)
+	c.ExpiresAt = clonePointer(calendar.ExpiresAt)
+	c.Users = append([]models.User(nil), calendar.Users...)
+	c.Events = nil
+	for i := range calendar.Events {
+		c.Events = append(c.Events, *cloneEvent(&calendar.Events[i]))
+	}
+	return &c
+}
+
+func cloneEvent(event *models.Event) *models.Event {
+	e := *event
+	e.RDates = append([]string(nil), event.RDates...)
+	e.ExDates = append([]string(nil), event.ExDates...)
+	e.Attendees = append([]models.Attendee(nil), event.Attendees...)
+	e.DeletedAt = clonePointer(event.DeletedAt)
+	e.ExpiresAt = clonePointer(event.ExpiresAt)
+	return &e
+}
+
+func cloneInvitation(invitation *models.Invitation) *models.Invitation {
+	i := *invitation
+	i.RespondedAt = clonePointer(invitation.RespondedAt)
+	return &i
+}
+
+func cloneValue[T any](value *T) *T {
+	v := *value
+	return &v
+}
+
+func clonePointer[T any](value *T) *T {
+	if value == nil {
+		return nil
+	}
+	return cloneValue(value)
+}
+
+// trashTimes はゴミ箱に移すときの DeletedAt と ExpiresAt。ExpiresAt は TTL 属性と同じく秒単位にする
+func trashTimes(deletedAt time.Time) (*time.Time, *time.Time) {
+	deleted := deletedAt.UTC()
+	expires := models.TrashExpiry(deletedAt).Truncate(time.Second)
+	return &deleted, &expires
+}
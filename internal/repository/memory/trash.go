@@ -0,0 +1,219 @@
+package memory
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/repository"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// report はパーティションのアイテムを種類ごとに数える
+func (p *partition) report(calendarID string) *models.DeletionReport {
+	report := &models.DeletionReport{
+		CalendarID:  calendarID,
+		Events:      len(p.events),
+		Members:     len(p.members),
+		Relations:   len(p.relations),
+		FeedTokens:  len(p.feedTokens),
+		Invitations: len(p.invitations),
+		ShareLinks:  len(p.shareLinks),
+	}
+	report.Total = report.Events + report.Members + report.Relations + report.FeedTokens + report.Invitations + report.ShareLinks
+	if p.calendar != nil {
+		report.Total++
+	}
+	return report
+}
+
+// Delete はカレンダーをゴミ箱に移す。パーティションのアイテムはカレンダーと一緒にゴミ箱にあるものとして扱う。
+// dryRun の場合は件数を数えるだけで何も変更しない。版数が version から変わっていた場合は ErrPreconditionFailed を返す
+func (r *calendarRepository) Delete(ctx context.Context, calendarID string, dryRun bool, deletedAt time.Time, version int64) (*models.DeletionReport, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.partition(calendarID, false)
+	report := &models.DeletionReport{CalendarID: calendarID}
+	if p != nil {
+		report = p.report(calendarID)
+	}
+	report.DryRun = dryRun
+	if dryRun {
+		return report, nil
+	}
+	if p == nil || p.calendar == nil {
+		return nil, fmt.Errorf("%w: calendar with CalendarID %s", repository.ErrNotFound, calendarID)
+	}
+	if p.calendar.DeletedAt != nil {
+		return nil, fmt.Errorf("%w: calendar %s is already in the trash", repository.ErrConflict, calendarID)
+	}
+	if p.calendar.Version != version {
+		return nil, fmt.Errorf("%w: calendar %s has been modified", repository.ErrPreconditionFailed, calendarID)
+	}
+
+	// ゴミ箱のカレンダーは公開カレンダーの一覧から外れる（FindPublicCalendars で除く）
+	p.calendar.DeletedAt, p.calendar.ExpiresAt = trashTimes(deletedAt)
+	p.calendar.Version++
+	return report, nil
+}
+
+// Restore はゴミ箱のカレンダーを元に戻す。カレンダーより前に個別にゴミ箱へ移したイベントはゴミ箱に残す
+func (r *calendarRepository) Restore(ctx context.Context, calendar *models.Calendar) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, err := findCalendarItem(s, calendar.CalendarID)
+	if err != nil {
+		return err
+	}
+	if item.DeletedAt == nil {
+		return fmt.Errorf("%w: calendar %s is not in the trash", repository.ErrConflict, calendar.CalendarID)
+	}
+	item.DeletedAt, item.ExpiresAt = nil, nil
+	return nil
+}
+
+// Purge はカレンダーのパーティションにあるアイテムをすべて削除する
+func (r *calendarRepository) Purge(ctx context.Context, calendarID string) (*models.DeletionReport, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.partition(calendarID, false)
+	if p == nil {
+		return &models.DeletionReport{CalendarID: calendarID}, nil
+	}
+	delete(s.partitions, calendarID)
+	return p.report(calendarID), nil
+}
+
+// FindTrashedCalendar はゴミ箱にある CALENDAR アイテムを取得する。ゴミ箱にない場合は ErrNotFound を返す
+func (r *calendarRepository) FindTrashedCalendar(ctx context.Context, calendarID string) (*models.Calendar, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return findTrashedCalendar(s, calendarID)
+}
+
+func findTrashedCalendar(s *Store, calendarID string) (*models.Calendar, error) {
+	calendar, err := findCalendarItem(s, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	if calendar.DeletedAt == nil {
+		return nil, fmt.Errorf("%w: calendar %s is not in the trash", repository.ErrNotFound, calendarID)
+	}
+	return cloneCalendar(calendar), nil
+}
+
+// FindTrashedCalendars はユーザーがオーナーのゴミ箱のカレンダーを page.Limit 件ずつ取得する
+func (r *calendarRepository) FindTrashedCalendars(ctx context.Context, userID string, page models.PageRequest) (*models.Page[*models.Calendar], error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var owned []membership
+	for _, m := range s.memberships(userID) {
+		if m.user.AccessLevel == models.AccessLevelOwner && s.partition(m.calendarID, false).trashed() {
+			owned = append(owned, m)
+		}
+	}
+	owned, cursor, err := paginate(owned, membershipKey, page)
+	if err != nil {
+		return nil, err
+	}
+
+	calendars := make([]*models.Calendar, 0, len(owned))
+	for _, m := range owned {
+		calendar, err := findTrashedCalendar(s, m.calendarID)
+		if err != nil {
+			continue
+		}
+		calendars = append(calendars, calendar)
+	}
+	return &models.Page[*models.Calendar]{Items: calendars, NextCursor: cursor}, nil
+}
+
+// TrashEvent はイベントとその個別の発生をゴミ箱に移す。元のイベントの版数が version から変わっていた場合は ErrPreconditionFailed を返す
+func (r *eventRepository) TrashEvent(ctx context.Context, calendarID string, eventID string, deletedAt time.Time, version int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	master, err := editableEvent(s, calendarID, eventID, version)
+	if err != nil {
+		return err
+	}
+	for _, override := range overrides(s.partition(calendarID, false), eventID) {
+		override.DeletedAt, override.ExpiresAt = trashTimes(deletedAt)
+	}
+	master.DeletedAt, master.ExpiresAt = trashTimes(deletedAt)
+	master.Version++
+	return nil
+}
+
+// RestoreEvent はゴミ箱のイベントとその個別の発生を元に戻す
+func (r *eventRepository) RestoreEvent(ctx context.Context, calendarID string, eventID string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	master := findEventItem(s, calendarID, eventID)
+	if master == nil || master.DeletedAt == nil {
+		return fmt.Errorf("%w: event %s is not in the trash", repository.ErrConflict, eventID)
+	}
+	for _, override := range overrides(s.partition(calendarID, false), eventID) {
+		override.DeletedAt, override.ExpiresAt = nil, nil
+	}
+	master.DeletedAt, master.ExpiresAt = nil, nil
+	return nil
+}
+
+// overrides はゴミ箱にあるかどうかに関わらず繰り返しイベントの個別の発生を返す
+func overrides(p *partition, eventID string) []*models.Event {
+	var result []*models.Event
+	for key, event := range p.events {
+		if strings.HasPrefix(key, overrideKey(eventID, "")) {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+// FindTrashedEvent はゴミ箱のイベントを1件取得する。ゴミ箱にない場合は nil を返す
+func (r *eventRepository) FindTrashedEvent(ctx context.Context, calendarID string, eventID string) (*models.Event, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	event := findEventItem(s, calendarID, eventID)
+	if event == nil || event.DeletedAt == nil {
+		return nil, nil
+	}
+	return cloneEvent(event), nil
+}
+
+// FindTrashedEvents はカレンダーのゴミ箱にあるイベントを page.Limit 件ずつ取得する。繰り返しの個別の発生は含まない
+func (r *eventRepository) FindTrashedEvents(ctx context.Context, calendarID string, page models.PageRequest) (*models.Page[*models.Event], error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	trashed := []*models.Event{}
+	if p := s.partition(calendarID, false); p != nil {
+		for _, key := range sortedKeys(p.events) {
+			if event := p.events[key]; event.DeletedAt != nil && event.RecurringEventID == "" {
+				trashed = append(trashed, cloneEvent(event))
+			}
+		}
+	}
+	events, cursor, err := paginate(trashed, eventKey, page)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Page[*models.Event]{Items: events, NextCursor: cursor}, nil
+}
@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/repository"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type userRepository struct {
+	store *Store
+}
+
+func UserRepositoryRequest(store *Store) repository.UserRepository {
+	return &userRepository{store: store}
+}
+
+// FindByUserID はユーザーを取得する。プロフィールがあればそれを、なければいずれかのカレンダーのメンバーシップを使う。
+// どちらもない場合は nil を返す
+func (r *userRepository) FindByUserID(ctx context.Context, userID string) (*models.User, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if profile := s.profiles[userID]; profile != nil {
+		return profile.User(), nil
+	}
+	for _, m := range s.memberships(userID) {
+		if m.user.DisplayName != "" {
+			return &models.User{UserID: m.user.UserID, DisplayName: m.user.DisplayName}, nil
+		}
+	}
+	return nil, nil
+}
+
+// FindProfile はユーザーのプロフィールを取得する。まだ作成されていない場合は nil を返す
+func (r *userRepository) FindProfile(ctx context.Context, userID string) (*models.Profile, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if profile := s.profiles[userID]; profile != nil {
+		return cloneValue(profile), nil
+	}
+	return nil, nil
+}
+
+// FindProfileByEmail はメールアドレスが一致するプロフィールを大文字・小文字を区別せずに取得する。見つからない場合は nil を返す
+func (r *userRepository) FindProfileByEmail(ctx context.Context, email string) (*models.Profile, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key := emailKey(email)
+	if key == "" {
+		return nil, nil
+	}
+	// EmailKey-index と同じく、同じメールアドレスのプロフィールはユーザーID順の先頭を返す
+	for _, userID := range sortedKeys(s.profiles) {
+		if profile := s.profiles[userID]; emailKey(profile.Email) == key {
+			return cloneValue(profile), nil
+		}
+	}
+	return nil, nil
+}
+
+// FindProfilesByNamePrefix は表示名が prefix で始まるプロフィールを表示名順に page.Limit 件ずつ取得する（大文字・小文字は区別しない）
+func (r *userRepository) FindProfilesByNamePrefix(ctx context.Context, prefix string, page models.PageRequest) (*models.Page[*models.Profile], error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matched []*models.Profile
+	for _, profile := range s.profiles {
+		if strings.HasPrefix(nameKey(profile), strings.ToLower(prefix)) {
+			matched = append(matched, profile)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return nameKey(matched[i]) < nameKey(matched[j]) })
+
+	matched, cursor, err := paginate(matched, nameKey, page)
+	if err != nil {
+		return nil, err
+	}
+	profiles := make([]*models.Profile, 0, len(matched))
+	for _, profile := range matched {
+		profiles = append(profiles, cloneValue(profile))
+	}
+	return &models.Page[*models.Profile]{Items: profiles, NextCursor: cursor}, nil
+}
+
+// emailKey はメールアドレスを大文字・小文字を区別せずに照合するためのキー
+func emailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// nameKey は表示名の前方一致で検索するためのキー。同じ表示名のユーザーはユーザーID順に並べる
+func nameKey(profile *models.Profile) string {
+	return strings.ToLower(profile.DisplayName) + "#" + profile.UserID
+}
+
+// CreateProfile はプロフィールを作成する。すでにある場合は ErrConflict を返す
+func (r *userRepository) CreateProfile(ctx context.Context, profile *models.Profile) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.profiles[profile.UserID] != nil {
+		return fmt.Errorf("%w: profile of user %s already exists", repository.ErrConflict, profile.UserID)
+	}
+	s.profiles[profile.UserID] = cloneValue(profile)
+	return nil
+}
+
+// UpdateProfile はプロフィールを上書きする。まだ作成されていない場合は ErrNotFound を返す
+func (r *userRepository) UpdateProfile(ctx context.Context, profile *models.Profile) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.profiles[profile.UserID] == nil {
+		return fmt.Errorf("%w: profile of user %s", repository.ErrNotFound, profile.UserID)
+	}
+	s.profiles[profile.UserID] = cloneValue(profile)
+	return nil
+}
+
+// SyncDisplayName はユーザーのメンバーシップ・招待の DisplayName と、
+// オーナーのカレンダーの OwnerName をプロフィールの表示名に合わせる
+func (r *userRepository) SyncDisplayName(ctx context.Context, userID string, displayName string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.partitions {
+		if invitation := p.invitations[userID]; invitation != nil {
+			invitation.DisplayName = displayName
+		}
+		member := p.members[userID]
+		if member == nil || member.DisplayName == displayName {
+			continue
+		}
+		member.DisplayName = displayName
+		// カレンダーの版数は変えない（OwnerName はプロフィールから導かれる値のため）
+		if member.AccessLevel == models.AccessLevelOwner && p.calendar != nil && p.calendar.OwnerUserID == userID {
+			p.calendar.OwnerName = displayName
+		}
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"bonded/internal/infra/db"
+	"bonded/internal/models"
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// EnsureStartKeyIndex は StartKey の GSI がなければ作成する。作成した場合は true を返す
+func EnsureStartKeyIndex(ctx context.Context, dynamoClient *db.DynamoDBClient) (bool, error) {
+	return ensureIndex(ctx, dynamoClient.Client, StartKeyIndexName, "CalendarID", "StartKey")
+}
+
+// EnsurePublicListingIndex は公開カレンダー一覧の GSI がなければ作成する。作成した場合は true を返す
+func EnsurePublicListingIndex(ctx context.Context, dynamoClient *db.DynamoDBClient) (bool, error) {
+	return ensureIndex(ctx, dynamoClient.Client, PublicListingIndexName, "PublicListing", "PublicSortKey")
+}
+
+// EnsureTrashTTL はゴミ箱のアイテムを削除する TTL が無効であれば有効にする。有効にした場合は true を返す
+func EnsureTrashTTL(ctx context.Context, dynamoClient *db.DynamoDBClient) (bool, error) {
+	client := dynamoClient.Client
+	ttl, err := client.DescribeTimeToLiveWithContext(ctx, &dynamodb.DescribeTimeToLiveInput{
+		TableName: aws.String("Calendars"),
+	})
+	if err != nil {
+		return false, err
+	}
+	if description := ttl.TimeToLiveDescription; description != nil {
+		switch aws.StringValue(description.TimeToLiveStatus) {
+		case dynamodb.TimeToLiveStatusEnabled, dynamodb.TimeToLiveStatusEnabling:
+			return false, nil
+		}
+	}
+
+	_, err = client.UpdateTimeToLiveWithContext(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String("Calendars"),
+		TimeToLiveSpecification: &dynamodb.TimeToLiveSpecification{
+			AttributeName: aws.String(TrashTTLAttribute),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func ensureIndex(ctx context.Context, client *dynamodb.DynamoDB, indexName string, hashKey string, rangeKey string) (bool, error) {
+	table, err := client.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String("Calendars"),
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, index := range table.Table.GlobalSecondaryIndexes {
+		if aws.StringValue(index.IndexName) == indexName {
+			return false, nil
+		}
+	}
+
+	create := &dynamodb.CreateGlobalSecondaryIndexAction{
+		IndexName: aws.String(indexName),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String(hashKey), KeyType: aws.String("HASH")},
+			{AttributeName: aws.String(rangeKey), KeyType: aws.String("RANGE")},
+		},
+		Projection: &dynamodb.Projection{ProjectionType: aws.String("ALL")},
+	}
+	// オンデマンドでないテーブルは GSI にもスループットの指定が必要
+	if table.Table.BillingModeSummary == nil || aws.StringValue(table.Table.BillingModeSummary.BillingMode) != dynamodb.BillingModePayPerRequest {
+		create.ProvisionedThroughput = &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(5),
+			WriteCapacityUnits: aws.Int64(5),
+		}
+	}
+
+	_, err = client.UpdateTableWithContext(ctx, &dynamodb.UpdateTableInput{
+		TableName: aws.String("Calendars"),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String(hashKey), AttributeType: aws.String("S")},
+			{AttributeName: aws.String(rangeKey), AttributeType: aws.String("S")},
+		},
+		GlobalSecondaryIndexUpdates: []*dynamodb.GlobalSecondaryIndexUpdate{{Create: create}},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// GSI の作成は1つずつしか行えないため、次の GSI を作成する前に作成の完了を待つ
+	for {
+		table, err = client.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{
+			TableName: aws.String("Calendars"),
+		})
+		if err != nil {
+			return true, err
+		}
+		for _, index := range table.Table.GlobalSecondaryIndexes {
+			if aws.StringValue(index.IndexName) == indexName && aws.StringValue(index.IndexStatus) == dynamodb.IndexStatusActive {
+				return true, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return true, ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// MigrateEventStartKeys は StartKey を持たない既存の EVENT# アイテムに StartKey を設定し、更新した件数を返す
+func MigrateEventStartKeys(ctx context.Context, dynamoClient *db.DynamoDBClient) (int, error) {
+	client := dynamoClient.Client
+	input := &dynamodb.ScanInput{
+		TableName:        aws.String("Calendars"),
+		FilterExpression: aws.String("begins_with(SortKey, :sortPrefix) AND attribute_not_exists(StartKey)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":sortPrefix": {S: aws.String("EVENT#")},
+		},
+	}
+
+	migrated := 0
+	var migrateErr error
+	err := client.ScanPagesWithContext(ctx, input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var event models.Event
+			migrateErr = dynamodbattribute.UnmarshalMap(item, &event)
+			if migrateErr != nil {
+				return false
+			}
+
+			_, migrateErr = client.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+				TableName: aws.String("Calendars"),
+				Key: map[string]*dynamodb.AttributeValue{
+					"CalendarID": item["CalendarID"],
+					"SortKey":    item["SortKey"],
+				},
+				// 移行中にアプリケーションが書き込んだ StartKey は上書きしない
+				ConditionExpression: aws.String("attribute_exists(SortKey) AND attribute_not_exists(StartKey)"),
+				UpdateExpression:    aws.String("SET StartKey = :startKey"),
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":startKey": {S: aws.String(startKey(&event))},
+				},
+			})
+			if isConditionalCheckFailed(migrateErr) {
+				migrateErr = nil
+				continue
+			}
+			if migrateErr != nil {
+				return false
+			}
+			migrated++
+		}
+		return true
+	})
+	if err != nil {
+		return migrated, err
+	}
+	return migrated, migrateErr
+}
+
+// MigratePublicListings は既存の CALENDAR アイテムに一覧用の属性（PublicListing・OwnerName・FollowerCount）を設定し、
+// 更新した件数を返す。フォロワー数は USER# アイテムから数え直す
+func MigratePublicListings(ctx context.Context, dynamoClient *db.DynamoDBClient) (int, error) {
+	r := &calendarRepository{dynamoDB: dynamoClient.Client, tableName: "Calendars"}
+	input := &dynamodb.ScanInput{
+		TableName:        aws.String(r.tableName),
+		FilterExpression: aws.String("SortKey = :sk"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":sk": {S: aws.String("CALENDAR")},
+		},
+	}
+
+	migrated := 0
+	var migrateErr error
+	err := r.dynamoDB.ScanPagesWithContext(ctx, input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var calendar models.Calendar
+			migrateErr = dynamodbattribute.UnmarshalMap(item, &calendar)
+			if migrateErr != nil {
+				return false
+			}
+			migrateErr = r.migratePublicListing(ctx, &calendar)
+			if migrateErr != nil {
+				return false
+			}
+			migrated++
+		}
+		return true
+	})
+	if err != nil {
+		return migrated, err
+	}
+	return migrated, migrateErr
+}
+
+func (r *calendarRepository) migratePublicListing(ctx context.Context, calendar *models.Calendar) error {
+	members, err := queryAll(ctx, r.dynamoDB, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("CalendarID = :cid AND begins_with(SortKey, :sk)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":cid": {S: aws.String(calendar.CalendarID)},
+			":sk":  {S: aws.String("USER#")},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	followers := 0
+	ownerName := calendar.OwnerName
+	for _, item := range members {
+		var member models.User
+		err = dynamodbattribute.UnmarshalMap(item, &member)
+		if err != nil {
+			return err
+		}
+		if member.UserID == calendar.OwnerUserID {
+			if ownerName == "" {
+				ownerName = member.DisplayName
+			}
+			continue
+		}
+		followers++
+	}
+
+	expression := "SET FollowerCount = :followers, OwnerName = :ownerName"
+	attributeValues := map[string]*dynamodb.AttributeValue{
+		":followers": {N: aws.String(strconv.Itoa(followers))},
+		":ownerName": {S: aws.String(ownerName)},
+	}
+	// ゴミ箱のカレンダーは一覧に載せない
+	if isPublic(calendar) && calendar.DeletedAt == nil {
+		expression += ", PublicListing = :listing, PublicSortKey = :publicSortKey"
+		attributeValues[":listing"] = &dynamodb.AttributeValue{S: aws.String(publicListing)}
+		attributeValues[":publicSortKey"] = &dynamodb.AttributeValue{S: aws.String(publicSortKey(calendar))}
+	} else {
+		expression += " REMOVE PublicListing, PublicSortKey"
+	}
+
+	_, err = r.dynamoDB.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"CalendarID": {S: aws.String(calendar.CalendarID)},
+			"SortKey":    {S: aws.String("CALENDAR")},
+		},
+		UpdateExpression:          aws.String(expression),
+		ExpressionAttributeValues: attributeValues,
+	})
+	return err
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"bonded/internal/models"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// ErrInvalidCursor はページのカーソルを解釈できない場合に返される
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type item = map[string]*dynamodb.AttributeValue
+
+// fetchFunc は start から最大 limit 件を評価し、結果と LastEvaluatedKey を返す
+type fetchFunc func(start item, limit int64) ([]item, item, error)
+
+// paginate は page.Limit 件に達するか最後まで読むまで fetch を繰り返す。
+// FilterExpression で除外される項目があっても件数が足りるまで読み進める。
+func paginate(page models.PageRequest, fetch fetchFunc) ([]item, string, error) {
+	start, err := decodeCursor(page.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var items []item
+	for {
+		// 評価する件数を残りの件数に抑え、返す件数が limit を超えないようにする
+		result, last, err := fetch(start, int64(page.Limit-len(items)))
+		if err != nil {
+			return nil, "", err
+		}
+		items = append(items, result...)
+		if len(last) == 0 {
+			return items, "", nil
+		}
+		if len(items) >= page.Limit {
+			cursor, err := encodeCursor(last)
+			return items, cursor, err
+		}
+		start = last
+	}
+}
+
+func queryPage(ctx context.Context, client *dynamodb.DynamoDB, input *dynamodb.QueryInput, page models.PageRequest) ([]item, string, error) {
+	return paginate(page, func(start item, limit int64) ([]item, item, error) {
+		input.ExclusiveStartKey = start
+		input.Limit = aws.Int64(limit)
+		result, err := client.QueryWithContext(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		return result.Items, result.LastEvaluatedKey, nil
+	})
+}
+
+func scanPage(ctx context.Context, client *dynamodb.DynamoDB, input *dynamodb.ScanInput, page models.PageRequest) ([]item, string, error) {
+	return paginate(page, func(start item, limit int64) ([]item, item, error) {
+		input.ExclusiveStartKey = start
+		input.Limit = aws.Int64(limit)
+		result, err := client.ScanWithContext(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		return result.Items, result.LastEvaluatedKey, nil
+	})
+}
+
+// queryAll は LastEvaluatedKey をたどってすべてのページの結果を返す
+func queryAll(ctx context.Context, client *dynamodb.DynamoDB, input *dynamodb.QueryInput) ([]item, error) {
+	var items []item
+	err := client.QueryPagesWithContext(ctx, input, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		items = append(items, page.Items...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// encodeCursor は LastEvaluatedKey を URL に含められる不透明な文字列にする。キーはすべて文字列型
+func encodeCursor(key item) (string, error) {
+	values := make(map[string]string, len(key))
+	for name, value := range key {
+		if value.S == nil {
+			return "", errors.New("unsupported key attribute " + name)
+		}
+		values[name] = *value.S
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(cursor string) (item, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var values map[string]string
+	err = json.Unmarshal(data, &values)
+	if err != nil || len(values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	key := make(item, len(values))
+	for name, value := range values {
+		key[name] = &dynamodb.AttributeValue{S: aws.String(value)}
+	}
+	return key, nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"bonded/internal/models"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// 共有リンクはトークンのハッシュごとに1件の LINK#<hash> アイテムとしてカレンダーのパーティションに保存する
+
+func shareLinkSortKey(tokenHash string) string {
+	return "LINK#" + tokenHash
+}
+
+// CreateShareLink は共有リンクを保存する。カレンダーが存在しない・ゴミ箱にある場合は ErrConflict を返す
+func (r *calendarRepository) CreateShareLink(ctx context.Context, link *models.ShareLink) error {
+	item, err := dynamodbattribute.MarshalMap(link)
+	if err != nil {
+		return err
+	}
+	item["SortKey"] = &dynamodb.AttributeValue{S: aws.String(shareLinkSortKey(link.TokenHash))}
+
+	items := []*dynamodb.TransactWriteItem{
+		{Put: r.newItem(item)},
+		{ConditionCheck: &dynamodb.ConditionCheck{
+			TableName:           aws.String(r.tableName),
+			Key:                 itemKey(link.CalendarID, "CALENDAR"),
+			ConditionExpression: aws.String("attribute_exists(SortKey) AND " + notTrashed),
+		}},
+	}
+	return transactWrite(ctx, r.dynamoDB, items, []error{
+		fmt.Errorf("%w: share link already exists", ErrConflict),
+		fmt.Errorf("%w: calendar %s no longer exists", ErrConflict, link.CalendarID),
+	})
+}
+
+// FindShareLink はハッシュから共有リンクを取得する。存在しない場合は nil を返す
+func (r *calendarRepository) FindShareLink(ctx context.Context, calendarID string, tokenHash string) (*models.ShareLink, error) {
+	result, err := r.dynamoDB.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       itemKey(calendarID, shareLinkSortKey(tokenHash)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var link models.ShareLink
+	err = dynamodbattribute.UnmarshalMap(result.Item, &link)
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// FindShareLinks はカレンダーの共有リンクを期限切れ・上限に達したものも含めてすべて取得する
+func (r *calendarRepository) FindShareLinks(ctx context.Context, calendarID string) ([]*models.ShareLink, error) {
+	items, err := queryAll(ctx, r.dynamoDB, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("CalendarID = :cid AND begins_with(SortKey, :sk)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":cid": {S: aws.String(calendarID)},
+			":sk":  {S: aws.String("LINK#")},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]*models.ShareLink, 0, len(items))
+	for _, item := range items {
+		var link models.ShareLink
+		err = dynamodbattribute.UnmarshalMap(item, &link)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, &link)
+	}
+	return links, nil
+}
+
+func (r *calendarRepository) DeleteShareLink(ctx context.Context, calendarID string, tokenHash string) error {
+	_, err := r.dynamoDB.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       itemKey(calendarID, shareLinkSortKey(tokenHash)),
+	})
+	return err
+}
+
+// RedeemShareLink は共有リンクの利用回数を増やし、同じトランザクションでユーザーをリンクの権限のメンバーとして追加する。
+// リンクが取り消された・期限切れ・上限に達した場合やすでにメンバーの場合は ErrConflict を返す
+func (r *calendarRepository) RedeemShareLink(ctx context.Context, link *models.ShareLink, user *models.User, now time.Time) error {
+	items := []*dynamodb.TransactWriteItem{
+		{Update: &dynamodb.Update{
+			TableName:           aws.String(r.tableName),
+			Key:                 itemKey(link.CalendarID, shareLinkSortKey(link.TokenHash)),
+			UpdateExpression:    aws.String("SET UseCount = UseCount + :one"),
+			ConditionExpression: aws.String("attribute_exists(SortKey) AND " + notTrashed + " AND ValidUntil > :now AND UseCount < MaxUses"),
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":one": {N: aws.String("1")},
+				":now": {N: aws.String(strconv.FormatInt(now.Unix(), 10))},
+			},
+		}},
+		{Put: r.newItem(memberItem(link.CalendarID, user, link.AccessLevel))},
+		{Put: r.putItem(relatedItem(link.CalendarID, user.UserID))},
+		{Update: r.followerCountUpdate(link.CalendarID, 1)},
+	}
+	return transactWrite(ctx, r.dynamoDB, items, []error{
+		fmt.Errorf("%w: share link has been revoked, has expired or has reached its usage limit", ErrConflict),
+		fmt.Errorf("%w: user %s is already a member of this calendar", ErrConflict, user.UserID),
+		nil,
+		fmt.Errorf("%w: calendar %s no longer exists", ErrConflict, link.CalendarID),
+	})
+}
@@ -0,0 +1,420 @@
+package repository
+
+import (
+	"bonded/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// TrashTTLAttribute はゴミ箱のアイテムを完全に削除する日時（UNIX 秒）を持つ TTL 属性
+const TrashTTLAttribute = "ExpiresAt"
+
+// notTrashed はゴミ箱のアイテムを読み飛ばす FilterExpression
+const notTrashed = "attribute_not_exists(DeletedAt)"
+
+// trashValues はゴミ箱に移すときの DeletedAt と ExpiresAt の値
+func trashValues(deletedAt time.Time) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		":deletedAt": {S: aws.String(deletedAt.UTC().Format(time.RFC3339Nano))},
+		":expiresAt": {N: aws.String(strconv.FormatInt(models.TrashExpiry(deletedAt).Unix(), 10))},
+	}
+}
+
+func updateItem(ctx context.Context, client *dynamodb.DynamoDB, input *dynamodb.UpdateItemInput) error {
+	_, err := client.UpdateItemWithContext(ctx, input)
+	return err
+}
+
+// partitionKeys はカレンダーのパーティションにあるアイテムのキーと DeletedAt を取得する
+func partitionKeys(ctx context.Context, client *dynamodb.DynamoDB, tableName string, calendarID string) ([]item, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		KeyConditionExpression: aws.String("CalendarID = :cid"),
+		ProjectionExpression:   aws.String("CalendarID, SortKey, DeletedAt"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":cid": {S: aws.String(calendarID)},
+		},
+	}
+	return queryAll(ctx, client, input)
+}
+
+// splitPartition はパーティションのキーを CALENDAR アイテムとそれ以外に分け、種類ごとの件数を数える
+func splitPartition(calendarID string, keys []item) (*models.DeletionReport, item, []item) {
+	report := &models.DeletionReport{CalendarID: calendarID, Total: len(keys)}
+	var calendarKey item
+	others := make([]item, 0, len(keys))
+	for _, key := range keys {
+		sortKey := aws.StringValue(key["SortKey"].S)
+		switch {
+		case sortKey == "CALENDAR":
+			calendarKey = key
+			continue
+		case strings.HasPrefix(sortKey, "EVENT#"):
+			report.Events++
+		case strings.HasPrefix(sortKey, "USER#"):
+			report.Members++
+		case strings.HasPrefix(sortKey, "CAL#"):
+			report.Relations++
+		case strings.HasPrefix(sortKey, "FEED#"):
+			report.FeedTokens++
+		case strings.HasPrefix(sortKey, "INVITE#"):
+			report.Invitations++
+		case strings.HasPrefix(sortKey, "LINK#"):
+			report.ShareLinks++
+		}
+		others = append(others, key)
+	}
+	return report, calendarKey, others
+}
+
+func primaryKey(key item) item {
+	return item{"CalendarID": key["CalendarID"], "SortKey": key["SortKey"]}
+}
+
+// Delete はカレンダーをゴミ箱に移す。パーティションのアイテムにはすべて TTL を設定し、
+// 保存期間が過ぎると DynamoDB がまとめて削除する。dryRun の場合は件数を数えるだけで何も変更しない。
+// 先に版数を条件に CALENDAR アイテムをゴミ箱に移し、それが成功してから子のアイテムに TTL を設定する。
+// 子のアイテムの更新に失敗した場合は設定した TTL を外してカレンダーを元に戻すので、生きているカレンダーのアイテムが TTL で消えることはない。
+// 版数が version から変わっていた場合は何も変更せずに ErrPreconditionFailed を返す。ゴミ箱に移すとカレンダーの版数は1つ進む
+func (r *calendarRepository) Delete(ctx context.Context, calendarID string, dryRun bool, deletedAt time.Time, version int64) (*models.DeletionReport, error) {
+	keys, err := partitionKeys(ctx, r.dynamoDB, r.tableName, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	report, calendarKey, others := splitPartition(calendarID, keys)
+	report.DryRun = dryRun
+	if dryRun {
+		return report, nil
+	}
+	if calendarKey == nil {
+		return nil, fmt.Errorf("%w: calendar with CalendarID %s", ErrNotFound, calendarID)
+	}
+
+	calendar, err := r.findCalendarItem(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	if calendar.DeletedAt != nil {
+		return nil, fmt.Errorf("%w: calendar %s is already in the trash", ErrConflict, calendarID)
+	}
+	if calendar.Version != version {
+		return nil, fmt.Errorf("%w: calendar %s has been modified", ErrPreconditionFailed, calendarID)
+	}
+
+	// ゴミ箱のカレンダーは公開カレンダーの一覧から外す
+	values := trashValues(deletedAt)
+	err = updateItem(ctx, r.dynamoDB, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(r.tableName),
+		Key:                       primaryKey(calendarKey),
+		UpdateExpression:          aws.String("SET DeletedAt = :deletedAt, ExpiresAt = :expiresAt, Version = :nextVersion REMOVE PublicListing, PublicSortKey"),
+		ConditionExpression:       aws.String("attribute_exists(SortKey) AND " + notTrashed + " AND " + versionCondition(version)),
+		ExpressionAttributeValues: versionValues(version, values),
+	})
+	if isConditionalCheckFailed(err) {
+		return nil, fmt.Errorf("%w: calendar %s has been modified", ErrPreconditionFailed, calendarID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	expired := make([]item, 0, len(others))
+	for _, key := range others {
+		// 個別にゴミ箱へ移したイベントは、先に決まった削除日時のままにする
+		if key["DeletedAt"] != nil {
+			continue
+		}
+		err = updateItem(ctx, r.dynamoDB, &dynamodb.UpdateItemInput{
+			TableName:                 aws.String(r.tableName),
+			Key:                       primaryKey(key),
+			UpdateExpression:          aws.String("SET ExpiresAt = :expiresAt"),
+			ConditionExpression:       aws.String("attribute_exists(SortKey)"),
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":expiresAt": values[":expiresAt"]},
+		})
+		if isConditionalCheckFailed(err) {
+			continue
+		}
+		if err != nil {
+			return nil, r.rollbackDelete(ctx, calendar, calendarKey, expired, err)
+		}
+		expired = append(expired, key)
+	}
+	return report, nil
+}
+
+// rollbackDelete は途中で失敗した Delete の変更を取り消し、元のエラーを返す。
+// 取り消しにも失敗した場合はカレンダーがゴミ箱に残るので、Restore でやり直せる
+func (r *calendarRepository) rollbackDelete(ctx context.Context, calendar *models.Calendar, calendarKey item, expired []item, cause error) error {
+	err := r.restoreItems(ctx, calendar, calendarKey, expired)
+	if err != nil {
+		return errors.Join(cause, fmt.Errorf("restore calendar %s: %w", calendar.CalendarID, err))
+	}
+	return cause
+}
+
+// Restore はゴミ箱のカレンダーを元に戻す。カレンダーより前に個別にゴミ箱へ移したイベントはゴミ箱に残す
+func (r *calendarRepository) Restore(ctx context.Context, calendar *models.Calendar) error {
+	keys, err := partitionKeys(ctx, r.dynamoDB, r.tableName, calendar.CalendarID)
+	if err != nil {
+		return err
+	}
+	_, calendarKey, others := splitPartition(calendar.CalendarID, keys)
+	if calendarKey == nil {
+		return fmt.Errorf("%w: calendar with CalendarID %s", ErrNotFound, calendar.CalendarID)
+	}
+
+	restored := make([]item, 0, len(others))
+	for _, key := range others {
+		if key["DeletedAt"] == nil {
+			restored = append(restored, key)
+		}
+	}
+	return r.restoreItems(ctx, calendar, calendarKey, restored)
+}
+
+// restoreItems は keys のアイテムの TTL を外してから、CALENDAR アイテムをゴミ箱から戻す。
+// CALENDAR アイテムは最後に更新するので、途中で失敗してもカレンダーはゴミ箱に残り、もう一度戻せる
+func (r *calendarRepository) restoreItems(ctx context.Context, calendar *models.Calendar, calendarKey item, keys []item) error {
+	for _, key := range keys {
+		err := updateItem(ctx, r.dynamoDB, &dynamodb.UpdateItemInput{
+			TableName:           aws.String(r.tableName),
+			Key:                 primaryKey(key),
+			UpdateExpression:    aws.String("REMOVE ExpiresAt"),
+			ConditionExpression: aws.String("attribute_exists(SortKey)"),
+		})
+		if err != nil && !isConditionalCheckFailed(err) {
+			return err
+		}
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:           aws.String(r.tableName),
+		Key:                 primaryKey(calendarKey),
+		UpdateExpression:    aws.String("REMOVE DeletedAt, ExpiresAt"),
+		ConditionExpression: aws.String("attribute_exists(DeletedAt)"),
+	}
+	if isPublic(calendar) {
+		input.UpdateExpression = aws.String("SET PublicListing = :listing, PublicSortKey = :publicSortKey REMOVE DeletedAt, ExpiresAt")
+		input.ExpressionAttributeValues = map[string]*dynamodb.AttributeValue{
+			":listing":       {S: aws.String(publicListing)},
+			":publicSortKey": {S: aws.String(publicSortKey(calendar))},
+		}
+	}
+	err := updateItem(ctx, r.dynamoDB, input)
+	if isConditionalCheckFailed(err) {
+		return fmt.Errorf("%w: calendar %s is not in the trash", ErrConflict, calendar.CalendarID)
+	}
+	return err
+}
+
+// Purge はカレンダーのパーティションにあるアイテムを TTL を待たずにすべて削除する。
+// CALENDAR アイテムは最後に削除するので、途中で失敗してもカレンダーが残り、もう一度削除できる
+func (r *calendarRepository) Purge(ctx context.Context, calendarID string) (*models.DeletionReport, error) {
+	keys, err := partitionKeys(ctx, r.dynamoDB, r.tableName, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	report, calendarKey, others := splitPartition(calendarID, keys)
+
+	deletes := make([]item, 0, len(others))
+	for _, key := range others {
+		deletes = append(deletes, primaryKey(key))
+	}
+	err = batchDelete(ctx, r.dynamoDB, r.tableName, deletes)
+	if err != nil {
+		return nil, err
+	}
+	if calendarKey != nil {
+		err = batchDelete(ctx, r.dynamoDB, r.tableName, []item{primaryKey(calendarKey)})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// FindTrashedCalendar はゴミ箱にある CALENDAR アイテムを取得する。ゴミ箱にない場合は ErrNotFound を返す
+func (r *calendarRepository) FindTrashedCalendar(ctx context.Context, calendarID string) (*models.Calendar, error) {
+	calendar, err := r.findCalendarItem(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	if calendar.DeletedAt == nil {
+		return nil, fmt.Errorf("%w: calendar %s is not in the trash", ErrNotFound, calendarID)
+	}
+	return calendar, nil
+}
+
+// FindTrashedCalendars はユーザーがオーナーのゴミ箱のカレンダーを page.Limit 件ずつ取得する
+func (r *calendarRepository) FindTrashedCalendars(ctx context.Context, userID string, page models.PageRequest) (*models.Page[*models.Calendar], error) {
+	// ゴミ箱のカレンダーのメンバーシップには TTL が設定されている
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String("UserID-index"),
+		KeyConditionExpression: aws.String("UserID = :uid"),
+		FilterExpression:       aws.String("begins_with(SortKey, :sk) AND AccessLevel = :owner AND attribute_exists(ExpiresAt)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":uid":   {S: aws.String(userID)},
+			":sk":    {S: aws.String("USER#")},
+			":owner": {S: aws.String("OWNER")},
+		},
+	}
+	items, cursor, err := queryPage(ctx, r.dynamoDB, input, page)
+	if err != nil {
+		return nil, err
+	}
+
+	calendars := make([]*models.Calendar, 0, len(items))
+	for _, item := range items {
+		calendar, err := r.FindTrashedCalendar(ctx, aws.StringValue(item["CalendarID"].S))
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		calendars = append(calendars, calendar)
+	}
+	return &models.Page[*models.Calendar]{Items: calendars, NextCursor: cursor}, nil
+}
+
+// findCalendarItem はゴミ箱にあるかどうかに関わらず CALENDAR アイテムだけを取得する
+func (r *calendarRepository) findCalendarItem(ctx context.Context, calendarID string) (*models.Calendar, error) {
+	result, err := r.dynamoDB.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       itemKey(calendarID, "CALENDAR"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, fmt.Errorf("%w: calendar with CalendarID %s", ErrNotFound, calendarID)
+	}
+	var calendar models.Calendar
+	err = dynamodbattribute.UnmarshalMap(result.Item, &calendar)
+	if err != nil {
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+// eventKeys は繰り返しの個別の発生を含むイベントのアイテムのキーを、個別の発生、元のイベントの順に返す
+func (r *eventRepository) eventKeys(ctx context.Context, calendarID string, eventID string) ([]item, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("CalendarID = :cid AND begins_with(SortKey, :sk)"),
+		ProjectionExpression:   aws.String("CalendarID, SortKey"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":cid": {S: aws.String(calendarID)},
+			":sk":  {S: aws.String(overrideSortKey(eventID, ""))},
+		},
+	}
+	keys, err := queryAll(ctx, r.dynamoDB, input)
+	if err != nil {
+		return nil, err
+	}
+	return append(keys, itemKey(calendarID, "EVENT#"+eventID)), nil
+}
+
+// TrashEvent はイベントとその個別の発生をゴミ箱に移す。元のイベントは最後に更新するので、途中で失敗してももう一度削除できる。
+// 元のイベントの版数が version から変わっていた場合は ErrPreconditionFailed を返す。ゴミ箱に移すと元のイベントの版数は1つ進む
+func (r *eventRepository) TrashEvent(ctx context.Context, calendarID string, eventID string, deletedAt time.Time, version int64) error {
+	keys, err := r.eventKeys(ctx, calendarID, eventID)
+	if err != nil {
+		return err
+	}
+	for i, key := range keys {
+		master := i == len(keys)-1
+		expression := "SET DeletedAt = :deletedAt, ExpiresAt = :expiresAt"
+		condition := "attribute_exists(SortKey)"
+		values := trashValues(deletedAt)
+		if master {
+			// ゴミ箱に移すのも変更なので、元のイベントの版数（ETag）を進める
+			expression += ", Version = :nextVersion"
+			condition += " AND " + notTrashed + " AND " + versionCondition(version)
+			values = versionValues(version, values)
+		}
+		err = updateItem(ctx, r.dynamoDB, &dynamodb.UpdateItemInput{
+			TableName:                 aws.String(r.tableName),
+			Key:                       key,
+			UpdateExpression:          aws.String(expression),
+			ConditionExpression:       aws.String(condition),
+			ExpressionAttributeValues: values,
+		})
+		if isConditionalCheckFailed(err) {
+			if master {
+				return fmt.Errorf("%w: event %s has been modified", ErrPreconditionFailed, eventID)
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreEvent はゴミ箱のイベントとその個別の発生を元に戻す
+func (r *eventRepository) RestoreEvent(ctx context.Context, calendarID string, eventID string) error {
+	keys, err := r.eventKeys(ctx, calendarID, eventID)
+	if err != nil {
+		return err
+	}
+	for i, key := range keys {
+		master := i == len(keys)-1
+		err = updateItem(ctx, r.dynamoDB, &dynamodb.UpdateItemInput{
+			TableName:           aws.String(r.tableName),
+			Key:                 key,
+			UpdateExpression:    aws.String("REMOVE DeletedAt, ExpiresAt"),
+			ConditionExpression: aws.String("attribute_exists(DeletedAt)"),
+		})
+		if isConditionalCheckFailed(err) {
+			if master {
+				return fmt.Errorf("%w: event %s is not in the trash", ErrConflict, eventID)
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindTrashedEvent はゴミ箱のイベントを1件取得する。ゴミ箱にない場合は nil を返す
+func (r *eventRepository) FindTrashedEvent(ctx context.Context, calendarID string, eventID string) (*models.Event, error) {
+	event, err := r.findEventItem(ctx, calendarID, eventID)
+	if err != nil || event == nil || event.DeletedAt == nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// FindTrashedEvents はカレンダーのゴミ箱にあるイベントを page.Limit 件ずつ取得する。繰り返しの個別の発生は含まない
+func (r *eventRepository) FindTrashedEvents(ctx context.Context, calendarID string, page models.PageRequest) (*models.Page[*models.Event], error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("CalendarID = :calendarID AND begins_with(SortKey, :sortPrefix)"),
+		FilterExpression:       aws.String("attribute_exists(DeletedAt) AND attribute_not_exists(RecurringEventID)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":calendarID": {S: aws.String(calendarID)},
+			":sortPrefix": {S: aws.String("EVENT#")},
+		},
+	}
+	items, cursor, err := queryPage(ctx, r.dynamoDB, input, page)
+	if err != nil {
+		return nil, err
+	}
+	events, err := unmarshalEvents(items)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Page[*models.Event]{Items: events, NextCursor: cursor}, nil
+}
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"bonded/internal/models"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+var trashedAt = time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+
+func TestTrashEvent(t *testing.T) {
+	override := itemKey("cal-1", overrideSortKey("event-1", "2024-04-02T10:00:00Z"))
+	fake, client := newFakeDynamoDB(t, func(operation string, body []byte) (interface{}, error) {
+		switch operation {
+		case "Query":
+			return &dynamodb.QueryOutput{Items: []item{override}}, nil
+		case "UpdateItem":
+			return &dynamodb.UpdateItemOutput{}, nil
+		}
+		return nil, errors.New("unexpected " + operation)
+	})
+	repo := &eventRepository{dynamoDB: client, tableName: "Calendars"}
+
+	err := repo.TrashEvent(context.Background(), "cal-1", "event-1", trashedAt, 3)
+	if err != nil {
+		t.Fatalf("TrashEvent: %v", err)
+	}
+
+	updates := fake.updates()
+	if len(updates) != 2 {
+		t.Fatalf("updates = %d, want the override and then the master", len(updates))
+	}
+	if got := aws.StringValue(updates[0].Key["SortKey"].S); got != "EVENT#event-1#2024-04-02T10:00:00Z" {
+		t.Errorf("first update = %s, want the override", got)
+	}
+	if updates[0].ExpressionAttributeValues[":nextVersion"] != nil {
+		t.Errorf("override update = %s, want no version change", aws.StringValue(updates[0].UpdateExpression))
+	}
+
+	// ゴミ箱に移すと元のイベントの版数が進む
+	master := updates[1]
+	if got := aws.StringValue(master.Key["SortKey"].S); got != "EVENT#event-1" {
+		t.Errorf("last update = %s, want the master", got)
+	}
+	if !strings.Contains(aws.StringValue(master.UpdateExpression), "Version = :nextVersion") ||
+		!strings.Contains(aws.StringValue(master.ConditionExpression), "Version = :version") {
+		t.Errorf("master update = %s IF %s", aws.StringValue(master.UpdateExpression), aws.StringValue(master.ConditionExpression))
+	}
+	if got := aws.StringValue(master.ExpressionAttributeValues[":nextVersion"].N); got != "4" {
+		t.Errorf(":nextVersion = %s, want 4", got)
+	}
+}
+
+func TestTrashEventChecksVersion(t *testing.T) {
+	_, client := newFakeDynamoDB(t, func(operation string, body []byte) (interface{}, error) {
+		switch operation {
+		case "Query":
+			return &dynamodb.QueryOutput{}, nil
+		case "UpdateItem":
+			return nil, conditionalCheckFailed
+		}
+		return nil, errors.New("unexpected " + operation)
+	})
+	repo := &eventRepository{dynamoDB: client, tableName: "Calendars"}
+
+	err := repo.TrashEvent(context.Background(), "cal-1", "event-1", trashedAt, 3)
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("TrashEvent = %v, want ErrPreconditionFailed", err)
+	}
+}
+
+// calendarPartition は Delete が読むパーティションのキー。EVENT#trashed は先に個別にゴミ箱へ移したイベント
+func calendarPartition() []item {
+	trashed := itemKey("cal-1", "EVENT#trashed")
+	trashed["DeletedAt"] = &dynamodb.AttributeValue{S: aws.String("2024-03-01T00:00:00Z")}
+	return []item{
+		itemKey("cal-1", "CALENDAR"),
+		itemKey("cal-1", "USER#alice"),
+		itemKey("cal-1", "CAL#cal-1#alice"),
+		itemKey("cal-1", "EVENT#event-1"),
+		trashed,
+	}
+}
+
+// deleteResponder は Delete が送るリクエストに答える。failSortKey のアイテムの更新は err で失敗させる
+func deleteResponder(t *testing.T, version int64, failSortKey string, err error) func(string, []byte) (interface{}, error) {
+	public := true
+	calendar, marshalErr := dynamodbattribute.MarshalMap(&models.Calendar{
+		CalendarID: "cal-1",
+		SortKey:    "CALENDAR",
+		Name:       "Team",
+		IsPublic:   &public,
+		Version:    version,
+	})
+	if marshalErr != nil {
+		t.Fatalf("MarshalMap: %v", marshalErr)
+	}
+	return func(operation string, body []byte) (interface{}, error) {
+		switch operation {
+		case "Query":
+			return &dynamodb.QueryOutput{Items: calendarPartition()}, nil
+		case "GetItem":
+			return &dynamodb.GetItemOutput{Item: calendar}, nil
+		case "UpdateItem":
+			var input dynamodb.UpdateItemInput
+			decodeErr := decodeInput(body, &input)
+			if decodeErr != nil {
+				return nil, decodeErr
+			}
+			if aws.StringValue(input.Key["SortKey"].S) == failSortKey && strings.HasPrefix(aws.StringValue(input.UpdateExpression), "SET") {
+				return nil, err
+			}
+			return &dynamodb.UpdateItemOutput{}, nil
+		}
+		return nil, errors.New("unexpected " + operation)
+	}
+}
+
+func sortKeys(updates []*dynamodb.UpdateItemInput) []string {
+	keys := make([]string, len(updates))
+	for i, update := range updates {
+		keys[i] = aws.StringValue(update.Key["SortKey"].S)
+	}
+	return keys
+}
+
+func TestDeleteCalendar(t *testing.T) {
+	fake, client := newFakeDynamoDB(t, deleteResponder(t, 2, "", nil))
+	repo := &calendarRepository{dynamoDB: client, tableName: "Calendars"}
+
+	report, err := repo.Delete(context.Background(), "cal-1", false, trashedAt, 2)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if report.Total != 5 || report.Events != 2 || report.Members != 1 || report.Relations != 1 {
+		t.Errorf("report = %+v", report)
+	}
+
+	updates := fake.updates()
+	if got := strings.Join(sortKeys(updates), ","); got != "CALENDAR,USER#alice,CAL#cal-1#alice,EVENT#event-1" {
+		t.Fatalf("updated items = %s", got)
+	}
+
+	// 先に版数を条件に CALENDAR アイテムをゴミ箱に移し、公開カレンダーの一覧から外して版数を進める
+	calendar := updates[0]
+	if got := aws.StringValue(calendar.UpdateExpression); got != "SET DeletedAt = :deletedAt, ExpiresAt = :expiresAt, Version = :nextVersion REMOVE PublicListing, PublicSortKey" {
+		t.Errorf("calendar update = %s", got)
+	}
+	if got := aws.StringValue(calendar.ConditionExpression); !strings.Contains(got, "Version = :version") || !strings.Contains(got, notTrashed) {
+		t.Errorf("calendar condition = %s", got)
+	}
+	values := calendar.ExpressionAttributeValues
+	if aws.StringValue(values[":version"].N) != "2" || aws.StringValue(values[":nextVersion"].N) != "3" {
+		t.Errorf("versions = %s -> %s, want 2 -> 3", aws.StringValue(values[":version"].N), aws.StringValue(values[":nextVersion"].N))
+	}
+	expiresAt := aws.StringValue(values[":expiresAt"].N)
+	if expiresAt != strconv.FormatInt(models.TrashExpiry(trashedAt).Unix(), 10) {
+		t.Errorf(":expiresAt = %s", expiresAt)
+	}
+
+	// 子のアイテムにはカレンダーと同じ削除日時の TTL だけを設定する
+	for _, child := range updates[1:] {
+		if aws.StringValue(child.UpdateExpression) != "SET ExpiresAt = :expiresAt" || aws.StringValue(child.ExpressionAttributeValues[":expiresAt"].N) != expiresAt {
+			t.Errorf("%s update = %s", aws.StringValue(child.Key["SortKey"].S), aws.StringValue(child.UpdateExpression))
+		}
+	}
+}
+
+func TestDeleteCalendarChecksVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		stored  int64 // GetItem で読んだ版数
+		failErr error // CALENDAR アイテムの更新のエラー
+	}{
+		{name: "stale version", stored: 3},
+		{name: "modified before the update", stored: 2, failErr: conditionalCheckFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, client := newFakeDynamoDB(t, deleteResponder(t, tt.stored, "CALENDAR", tt.failErr))
+			repo := &calendarRepository{dynamoDB: client, tableName: "Calendars"}
+
+			_, err := repo.Delete(context.Background(), "cal-1", false, trashedAt, 2)
+			if !errors.Is(err, ErrPreconditionFailed) {
+				t.Fatalf("Delete = %v, want ErrPreconditionFailed", err)
+			}
+			// カレンダーを移せなかった場合は子のアイテムに TTL を設定しない
+			if got := sortKeys(fake.updates()); len(got) > 1 {
+				t.Errorf("updated items = %v", got)
+			}
+		})
+	}
+}
+
+func TestDeleteCalendarRollsBack(t *testing.T) {
+	failure := &fakeError{Status: http.StatusInternalServerError, Code: "InternalServerError"}
+	fake, client := newFakeDynamoDB(t, deleteResponder(t, 2, "EVENT#event-1", failure))
+	repo := &calendarRepository{dynamoDB: client, tableName: "Calendars"}
+
+	_, err := repo.Delete(context.Background(), "cal-1", false, trashedAt, 2)
+	if err == nil {
+		t.Fatal("Delete = nil, want the update error")
+	}
+
+	// TTL を設定した子のアイテムから TTL を外し、最後にカレンダーを公開カレンダーの一覧に戻す
+	updates := fake.updates()
+	if got := strings.Join(sortKeys(updates), ","); got != "CALENDAR,USER#alice,CAL#cal-1#alice,EVENT#event-1,USER#alice,CAL#cal-1#alice,CALENDAR" {
+		t.Fatalf("updated items = %s", got)
+	}
+	for _, child := range updates[4:6] {
+		if got := aws.StringValue(child.UpdateExpression); got != "REMOVE ExpiresAt" {
+			t.Errorf("%s rollback = %s", aws.StringValue(child.Key["SortKey"].S), got)
+		}
+	}
+	restored := updates[6]
+	if got := aws.StringValue(restored.UpdateExpression); got != "SET PublicListing = :listing, PublicSortKey = :publicSortKey REMOVE DeletedAt, ExpiresAt" {
+		t.Errorf("calendar rollback = %s", got)
+	}
+}
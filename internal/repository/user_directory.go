@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"bonded/internal/infra/db"
+	"bonded/internal/models"
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// ProfileEmailIndexName はプロフィールをメールアドレスで引く GSI（EmailKey を持つ PROFILE アイテムだけが載る）
+const ProfileEmailIndexName = "EmailKey-index"
+
+// ProfileNameIndexName はプロフィールを表示名順に並べる GSI（Directory を持つ PROFILE アイテムだけが載る）
+const ProfileNameIndexName = "Directory-NameKey-index"
+
+// directory は PROFILE アイテムの Directory の値。GSI のパーティションを1つにまとめる
+const directory = "PROFILE"
+
+// emailKey はメールアドレスを大文字・小文字を区別せずに照合するためのキー
+func emailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// nameKey は表示名の前方一致で検索するためのキー。同じ表示名のユーザーはユーザーID順に並べる
+func nameKey(profile *models.Profile) string {
+	return strings.ToLower(profile.DisplayName) + "#" + profile.UserID
+}
+
+// directoryAttributes はプロフィールを検索用の GSI に載せる属性を item に設定する
+func directoryAttributes(profile *models.Profile, item map[string]*dynamodb.AttributeValue) {
+	item["Directory"] = &dynamodb.AttributeValue{S: aws.String(directory)}
+	item["NameKey"] = &dynamodb.AttributeValue{S: aws.String(nameKey(profile))}
+	if profile.Email != "" {
+		item["EmailKey"] = &dynamodb.AttributeValue{S: aws.String(emailKey(profile.Email))}
+	}
+}
+
+// FindProfileByEmail はメールアドレスが一致するプロフィールを取得する。見つからない場合は nil を返す
+func (r *userRepository) FindProfileByEmail(ctx context.Context, email string) (*models.Profile, error) {
+	result, err := r.dynamoDB.QueryWithContext(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String(ProfileEmailIndexName),
+		KeyConditionExpression: aws.String("EmailKey = :email"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":email": {S: aws.String(emailKey(email))},
+		},
+		Limit: aws.Int64(1),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Items) == 0 {
+		return nil, nil
+	}
+
+	var profile models.Profile
+	err = dynamodbattribute.UnmarshalMap(result.Items[0], &profile)
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// FindProfilesByNamePrefix は表示名が prefix で始まるプロフィールを表示名順に page.Limit 件ずつ取得する（大文字・小文字は区別しない）
+func (r *userRepository) FindProfilesByNamePrefix(ctx context.Context, prefix string, page models.PageRequest) (*models.Page[*models.Profile], error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String(ProfileNameIndexName),
+		KeyConditionExpression: aws.String("Directory = :directory AND begins_with(NameKey, :prefix)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":directory": {S: aws.String(directory)},
+			":prefix":    {S: aws.String(strings.ToLower(prefix))},
+		},
+	}
+	items, cursor, err := queryPage(ctx, r.dynamoDB, input, page)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make([]*models.Profile, 0, len(items))
+	for _, item := range items {
+		var profile models.Profile
+		err = dynamodbattribute.UnmarshalMap(item, &profile)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, &profile)
+	}
+	return &models.Page[*models.Profile]{Items: profiles, NextCursor: cursor}, nil
+}
+
+// EnsureProfileEmailIndex はメールアドレスで検索する GSI がなければ作成する。作成した場合は true を返す
+func EnsureProfileEmailIndex(ctx context.Context, dynamoClient *db.DynamoDBClient) (bool, error) {
+	return ensureIndex(ctx, dynamoClient.Client, ProfileEmailIndexName, "EmailKey", "ProfileUserID")
+}
+
+// EnsureProfileNameIndex は表示名で検索する GSI がなければ作成する。作成した場合は true を返す
+func EnsureProfileNameIndex(ctx context.Context, dynamoClient *db.DynamoDBClient) (bool, error) {
+	return ensureIndex(ctx, dynamoClient.Client, ProfileNameIndexName, "Directory", "NameKey")
+}
+
+// MigrateProfileDirectory は検索用の属性を持たない既存の PROFILE アイテムに属性を設定し、更新した件数を返す
+func MigrateProfileDirectory(ctx context.Context, dynamoClient *db.DynamoDBClient) (int, error) {
+	r := &userRepository{dynamoDB: dynamoClient.Client, tableName: "Calendars"}
+	input := &dynamodb.ScanInput{
+		TableName:        aws.String(r.tableName),
+		FilterExpression: aws.String("SortKey = :sk AND attribute_not_exists(Directory)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":sk": {S: aws.String("PROFILE")},
+		},
+	}
+
+	migrated := 0
+	var migrateErr error
+	err := r.dynamoDB.ScanPagesWithContext(ctx, input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var profile models.Profile
+			migrateErr = dynamodbattribute.UnmarshalMap(item, &profile)
+			if migrateErr != nil {
+				return false
+			}
+			attributes := map[string]*dynamodb.AttributeValue{}
+			directoryAttributes(&profile, attributes)
+			expression := "SET Directory = :directory, NameKey = :nameKey"
+			values := map[string]*dynamodb.AttributeValue{
+				":directory": attributes["Directory"],
+				":nameKey":   attributes["NameKey"],
+			}
+			if emailKey, ok := attributes["EmailKey"]; ok {
+				expression += ", EmailKey = :emailKey"
+				values[":emailKey"] = emailKey
+			}
+			_, migrateErr = r.dynamoDB.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+				TableName: aws.String(r.tableName),
+				Key: map[string]*dynamodb.AttributeValue{
+					"CalendarID": item["CalendarID"],
+					"SortKey":    item["SortKey"],
+				},
+				// 移行中にアプリケーションが更新したプロフィールには属性が設定されているので上書きしない
+				ConditionExpression:       aws.String("attribute_exists(SortKey) AND attribute_not_exists(Directory)"),
+				UpdateExpression:          aws.String(expression),
+				ExpressionAttributeValues: values,
+			})
+			if isConditionalCheckFailed(migrateErr) {
+				migrateErr = nil
+				continue
+			}
+			if migrateErr != nil {
+				return false
+			}
+			migrated++
+		}
+		return true
+	})
+	if err != nil {
+		return migrated, err
+	}
+	return migrated, migrateErr
+}
@@ -10,37 +10,174 @@ import (
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 )
 
+// プロフィールはユーザーごとに PROFILE#<userId> パーティションの PROFILE アイテムとして保存する
+
+func profileKey(userID string) map[string]*dynamodb.AttributeValue {
+	return itemKey("PROFILE#"+userID, "PROFILE")
+}
+
+// FindByUserID はユーザーを取得する。プロフィールがあればそれを、なければ（プロフィール導入前のユーザー）
+// いずれかのカレンダーのメンバーシップを使う。どちらもない場合は nil を返す
 func (r *userRepository) FindByUserID(ctx context.Context, userID string) (*models.User, error) {
+	profile, err := r.FindProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if profile != nil {
+		return profile.User(), nil
+	}
+
 	input := &dynamodb.QueryInput{
 		TableName:              aws.String(r.tableName),
 		IndexName:              aws.String("UserID-index"),
 		KeyConditionExpression: aws.String("UserID = :uid"),
+		// 同じ GSI に載る CAL#・INVITE# アイテムを除き、メンバーシップ（USER#）だけを対象にする
+		FilterExpression: aws.String("begins_with(SortKey, :sk)"),
 		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
 			":uid": {S: aws.String(userID)},
+			":sk":  {S: aws.String("USER#")},
 		},
 	}
+	items, err := queryAll(ctx, r.dynamoDB, input)
+	if err != nil {
+		return nil, err
+	}
 
-	result, err := r.dynamoDB.QueryWithContext(ctx, input)
+	var users []models.User
+	err = dynamodbattribute.UnmarshalListOfMaps(items, &users)
 	if err != nil {
 		return nil, err
 	}
+	for _, user := range users {
+		if user.DisplayName != "" {
+			return &models.User{UserID: user.UserID, DisplayName: user.DisplayName}, nil
+		}
+	}
+	return nil, nil
+}
 
-	if len(result.Items) == 0 {
-		return nil, fmt.Errorf("user with UserID %s not found", userID)
+// FindProfile はユーザーのプロフィールを取得する。まだ作成されていない場合は nil を返す
+func (r *userRepository) FindProfile(ctx context.Context, userID string) (*models.Profile, error) {
+	result, err := r.dynamoDB.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       profileKey(userID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, nil
 	}
 
-	var users []models.User
-	err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &users)
+	var profile models.Profile
+	err = dynamodbattribute.UnmarshalMap(result.Item, &profile)
 	if err != nil {
 		return nil, err
 	}
+	return &profile, nil
+}
 
-	for _, user := range users {
-		if user.DisplayName != "" && user.AccessLevel != "" {
-			fmt.Printf("Selected user: %v\n", user)
-			return &user, nil
+// CreateProfile はプロフィールを作成する。すでにある場合は ErrConflict を返す
+func (r *userRepository) CreateProfile(ctx context.Context, profile *models.Profile) error {
+	item, err := r.profileItem(profile)
+	if err != nil {
+		return err
+	}
+	_, err = r.dynamoDB.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(SortKey)"),
+	})
+	if isConditionalCheckFailed(err) {
+		return fmt.Errorf("%w: profile of user %s already exists", ErrConflict, profile.UserID)
+	}
+	return err
+}
+
+// UpdateProfile はプロフィールを上書きする。まだ作成されていない場合は ErrNotFound を返す
+func (r *userRepository) UpdateProfile(ctx context.Context, profile *models.Profile) error {
+	item, err := r.profileItem(profile)
+	if err != nil {
+		return err
+	}
+	_, err = r.dynamoDB.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_exists(SortKey)"),
+	})
+	if isConditionalCheckFailed(err) {
+		return fmt.Errorf("%w: profile of user %s", ErrNotFound, profile.UserID)
+	}
+	return err
+}
+
+func (r *userRepository) profileItem(profile *models.Profile) (map[string]*dynamodb.AttributeValue, error) {
+	item, err := dynamodbattribute.MarshalMap(profile)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range profileKey(profile.UserID) {
+		item[name] = value
+	}
+	directoryAttributes(profile, item)
+	return item, nil
+}
+
+// SyncDisplayName はユーザーのメンバーシップ・招待の DisplayName と、
+// オーナーのカレンダーの OwnerName をプロフィールの表示名に合わせる。
+// アイテムごとに更新するため途中で失敗した場合は一部だけが更新されるが、同じ表示名で再実行すれば揃う
+func (r *userRepository) SyncDisplayName(ctx context.Context, userID string, displayName string) error {
+	items, err := queryAll(ctx, r.dynamoDB, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String("UserID-index"),
+		KeyConditionExpression: aws.String("UserID = :uid"),
+		FilterExpression:       aws.String("(begins_with(SortKey, :user) OR begins_with(SortKey, :invite)) AND DisplayName <> :name"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":uid":    {S: aws.String(userID)},
+			":user":   {S: aws.String("USER#")},
+			":invite": {S: aws.String("INVITE#")},
+			":name":   {S: aws.String(displayName)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		calendarID := aws.StringValue(item["CalendarID"].S)
+		err = r.setAttribute(ctx, itemKey(calendarID, aws.StringValue(item["SortKey"].S)), "DisplayName", displayName, "attribute_exists(SortKey)", nil)
+		if err != nil {
+			return err
+		}
+		if accessLevel, ok := item["AccessLevel"]; ok && aws.StringValue(accessLevel.S) == "OWNER" {
+			// カレンダーの版数は変えない（OwnerName はプロフィールから導かれる値のため）
+			err = r.setAttribute(ctx, itemKey(calendarID, "CALENDAR"), "OwnerName", displayName, "OwnerUserID = :uid", map[string]*dynamodb.AttributeValue{
+				":uid": {S: aws.String(userID)},
+			})
+			if err != nil {
+				return err
+			}
 		}
 	}
+	return nil
+}
 
-	return nil, fmt.Errorf("valid user with UserID %s not found", userID)
+// setAttribute は condition を満たすアイテムの属性を1つ書き換える。条件を満たさないアイテムは読み飛ばす
+func (r *userRepository) setAttribute(ctx context.Context, key map[string]*dynamodb.AttributeValue, name string, value string, condition string, values map[string]*dynamodb.AttributeValue) error {
+	if values == nil {
+		values = map[string]*dynamodb.AttributeValue{}
+	}
+	values[":value"] = &dynamodb.AttributeValue{S: aws.String(value)}
+	_, err := r.dynamoDB.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(r.tableName),
+		Key:                       key,
+		UpdateExpression:          aws.String("SET #attr = :value"),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  map[string]*string{"#attr": aws.String(name)},
+		ExpressionAttributeValues: values,
+	})
+	if isConditionalCheckFailed(err) {
+		return nil
+	}
+	return err
 }
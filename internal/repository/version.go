@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// versionCondition は Version が expected のままであることを確かめる ConditionExpression を返す。
+// Version を導入する前のアイテムは属性を持たないため、版数 0 として扱う
+func versionCondition(expected int64) string {
+	if expected == 0 {
+		return "(attribute_not_exists(Version) OR Version = :version)"
+	}
+	return "Version = :version"
+}
+
+// versionValues は versionCondition と、版数を1つ進める "SET Version = :nextVersion" で使う値
+func versionValues(expected int64, values map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	if values == nil {
+		values = map[string]*dynamodb.AttributeValue{}
+	}
+	values[":version"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(expected, 10))}
+	values[":nextVersion"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(expected+1, 10))}
+	return values
+}
@@ -0,0 +1,243 @@
+// Package router は API Gateway のプロキシ統合のリクエストを、{name} 形式のパスパラメータを含むルート表でハンドラーに振り分ける
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// HandlerFunc はルートに一致したリクエストを処理する関数
+type HandlerFunc func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// Route はメソッドとパスのパターンの組に対するハンドラー
+type Route struct {
+	Method  string
+	Path    string // "/calendar/{calendarId}" のように {name} でパスパラメータを表す
+	Handler HandlerFunc
+	Public  bool // 認証なしで呼び出せる（トークンがあれば検証してメンバーとして扱う）
+}
+
+// resource は同じパスのパターンを持つルートをまとめたもの（API Gateway のリソースに当たる）
+type resource struct {
+	path     string
+	segments []string
+	methods  map[string]*Route
+}
+
+// Router はパスからリソースを選び、メソッドでルートを選ぶ
+type Router struct {
+	resources []*resource
+}
+
+// New はルート表から Router を作る。パターンが不正な場合や同じメソッドとパスのルートが重複する場合は panic する
+func New(routes ...Route) *Router {
+	r := &Router{}
+	byPath := map[string]*resource{}
+	for i := range routes {
+		route := &routes[i]
+		segments, err := parsePattern(route.Path)
+		if err != nil {
+			panic(err)
+		}
+		res, ok := byPath[route.Path]
+		if !ok {
+			res = &resource{path: route.Path, segments: segments, methods: map[string]*Route{}}
+			byPath[route.Path] = res
+			r.resources = append(r.resources, res)
+		}
+		if _, exists := res.methods[route.Method]; exists {
+			panic(fmt.Sprintf("router: duplicate route %s %s", route.Method, route.Path))
+		}
+		res.methods[route.Method] = route
+	}
+
+	// 固定のセグメントが先に現れるパターンを優先する（/calendar/list は /calendar/{calendarId} より先に照合する）
+	sort.SliceStable(r.resources, func(i, j int) bool {
+		a, b := r.resources[i].segments, r.resources[j].segments
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return moreSpecific(a, b)
+	})
+	return r
+}
+
+// Handle はリクエストをルートのハンドラーに渡す。パスパラメータはパターンから取り出して設定する。
+// パスに一致するリソースがない場合は 404、メソッドがない場合は Allow ヘッダー付きの 405 を返し、
+// OPTIONS にはリソースが受け付けるメソッドを返す
+func (r *Router) Handle(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	res, params := r.find(request.Path)
+	if res == nil {
+		return ProblemResponse(http.StatusNotFound, fmt.Sprintf("no resource matches %s", request.Path), nil), nil
+	}
+
+	route, ok := res.methods[request.HTTPMethod]
+	if !ok {
+		if request.HTTPMethod == http.MethodOptions {
+			headers := CORSHeaders()
+			headers["Allow"] = res.allow()
+			headers["Access-Control-Allow-Methods"] = headers["Allow"]
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusNoContent, Headers: headers}, nil
+		}
+		detail := fmt.Sprintf("%s is not allowed on %s", request.HTTPMethod, res.path)
+		return ProblemResponse(http.StatusMethodNotAllowed, detail, map[string]string{"Allow": res.allow()}), nil
+	}
+
+	request.Resource = res.path
+	request.PathParameters = params
+	return route.Handler(ctx, request)
+}
+
+// Match はメソッドとパスに一致するルートとパスパラメータを返す
+func (r *Router) Match(method string, path string) (*Route, map[string]string, bool) {
+	res, params := r.find(path)
+	if res == nil {
+		return nil, nil, false
+	}
+	route, ok := res.methods[method]
+	if !ok {
+		return nil, nil, false
+	}
+	return route, params, true
+}
+
+// IsPublic は認証なしで受け付けるリクエストかを返す。CORS のプリフライト（OPTIONS）はトークンを送らないため、
+// 存在するリソースへの OPTIONS も認証なしで受け付ける
+func (r *Router) IsPublic(method string, path string) bool {
+	res, _ := r.find(path)
+	if res == nil {
+		return false
+	}
+	if method == http.MethodOptions {
+		return true
+	}
+	route, ok := res.methods[method]
+	return ok && route.Public
+}
+
+// find はパスに一致するリソースのうち最も具体的なものとパスパラメータを返す
+func (r *Router) find(path string) (*resource, map[string]string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, res := range r.resources {
+		if params, ok := res.match(segments); ok {
+			return res, params
+		}
+	}
+	return nil, nil
+}
+
+func (res *resource) match(segments []string) (map[string]string, bool) {
+	if len(res.segments) != len(segments) {
+		return nil, false
+	}
+	var params map[string]string
+	for i, pattern := range res.segments {
+		name, ok := paramName(pattern)
+		if !ok {
+			if pattern != segments[i] {
+				return nil, false
+			}
+			continue
+		}
+		if segments[i] == "" {
+			return nil, false
+		}
+		if params == nil {
+			params = map[string]string{}
+		}
+		params[name] = segments[i]
+	}
+	return params, true
+}
+
+// allow はリソースが受け付けるメソッドを Allow ヘッダーの形式で返す
+func (res *resource) allow() string {
+	methods := make([]string, 0, len(res.methods)+1)
+	for method := range res.methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return strings.Join(append(methods, http.MethodOptions), ",")
+}
+
+// problem は RFC 7807 の problem details（application/problem+json）
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ProblemResponse は statusCode の problem details を CORS のヘッダー付きで返す。headers はレスポンスに追加するヘッダー
+func ProblemResponse(statusCode int, detail string, headers map[string]string) events.APIGatewayProxyResponse {
+	merged := CORSHeaders()
+	for name, value := range headers {
+		merged[name] = value
+	}
+	merged["Content-Type"] = "application/problem+json"
+	// フィールドはすべて文字列と数値なので Marshal は失敗しない
+	body, _ := json.Marshal(problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Detail: detail,
+	})
+	return events.APIGatewayProxyResponse{StatusCode: statusCode, Headers: merged, Body: string(body)}
+}
+
+// CORSHeaders は各レスポンスに付ける CORS のヘッダーを返す
+func CORSHeaders() map[string]string {
+	return map[string]string{
+		"Access-Control-Allow-Origin":  "*",
+		"Access-Control-Allow-Headers": "Content-Type,Authorization,X-ID-Token,If-Match",
+		"Access-Control-Allow-Methods": "GET,POST,PUT,DELETE,OPTIONS",
+	}
+}
+
+func parsePattern(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("router: path %q must start with /", path)
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	seen := map[string]bool{}
+	for _, segment := range segments {
+		if strings.ContainsAny(segment, "{}") {
+			name, ok := paramName(segment)
+			if !ok || name == "" {
+				return nil, fmt.Errorf("router: invalid segment %q in %q", segment, path)
+			}
+			if seen[name] {
+				return nil, fmt.Errorf("router: duplicate parameter %q in %q", name, path)
+			}
+			seen[name] = true
+		}
+	}
+	return segments, nil
+}
+
+// paramName はセグメントが {name} の形であればパラメータ名を返す
+func paramName(segment string) (string, bool) {
+	if len(segment) < 2 || segment[0] != '{' || segment[len(segment)-1] != '}' {
+		return "", false
+	}
+	name := segment[1 : len(segment)-1]
+	return name, !strings.ContainsAny(name, "{}")
+}
+
+// moreSpecific は同じ長さのパターン a が b より先に固定のセグメントを持つかを返す
+func moreSpecific(a []string, b []string) bool {
+	for i := range a {
+		_, aParam := paramName(a[i])
+		_, bParam := paramName(b[i])
+		if aParam != bParam {
+			return !aParam
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package router_test
+
+import (
+	"bonded/internal/router"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// echo はルートのパスパターンとパスパラメータを本文に書いて返すハンドラーを作る
+func echo(name string) router.HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		body, err := json.Marshal(map[string]interface{}{"route": name, "resource": request.Resource, "params": request.PathParameters})
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: string(body)}, err
+	}
+}
+
+func newRouter() *router.Router {
+	return router.New(
+		router.Route{Method: http.MethodGet, Path: "/calendar/{calendarId}", Handler: echo("get calendar")},
+		router.Route{Method: http.MethodGet, Path: "/calendar/list", Handler: echo("list calendars"), Public: true},
+		router.Route{Method: http.MethodPut, Path: "/calendar/{calendarId}", Handler: echo("edit calendar")},
+		router.Route{Method: http.MethodDelete, Path: "/event/{calendarId}/{eventId}", Handler: echo("delete event")},
+	)
+}
+
+type routed struct {
+	Route    string            `json:"route"`
+	Resource string            `json:"resource"`
+	Params   map[string]string `json:"params"`
+}
+
+func TestHandleRoutes(t *testing.T) {
+	r := newRouter()
+	tests := []struct {
+		method string
+		path   string
+		route  string
+		params map[string]string
+	}{
+		{http.MethodGet, "/calendar/list", "list calendars", nil},
+		{http.MethodGet, "/calendar/abc", "get calendar", map[string]string{"calendarId": "abc"}},
+		{http.MethodPut, "/calendar/abc/", "edit calendar", map[string]string{"calendarId": "abc"}},
+		{http.MethodDelete, "/event/abc/e1", "delete event", map[string]string{"calendarId": "abc", "eventId": "e1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			res, err := r.Handle(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: tt.method, Path: tt.path})
+			if err != nil || res.StatusCode != http.StatusOK {
+				t.Fatalf("Handle = %d %s, %v", res.StatusCode, res.Body, err)
+			}
+			var got routed
+			if err := json.Unmarshal([]byte(res.Body), &got); err != nil {
+				t.Fatalf("decode %q: %v", res.Body, err)
+			}
+			if got.Route != tt.route || len(got.Params) != len(tt.params) {
+				t.Fatalf("routed to %+v, want %s %v", got, tt.route, tt.params)
+			}
+			for name, value := range tt.params {
+				if got.Params[name] != value {
+					t.Errorf("param %s = %q, want %q", name, got.Params[name], value)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleErrorsAreProblems(t *testing.T) {
+	r := newRouter()
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		allow  string
+	}{
+		{"unknown path", http.MethodGet, "/nothing/here", http.StatusNotFound, ""},
+		{"empty parameter", http.MethodGet, "/event//e1", http.StatusNotFound, ""},
+		{"wrong method", http.MethodPost, "/calendar/abc", http.StatusMethodNotAllowed, "GET,PUT,OPTIONS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := r.Handle(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: tt.method, Path: tt.path})
+			if err != nil {
+				t.Fatalf("Handle: %v", err)
+			}
+			if res.StatusCode != tt.status || res.Headers["Content-Type"] != "application/problem+json" {
+				t.Fatalf("response = %d %v", res.StatusCode, res.Headers)
+			}
+			if res.Headers["Allow"] != tt.allow || res.Headers["Access-Control-Allow-Origin"] != "*" {
+				t.Errorf("headers = %v", res.Headers)
+			}
+			var p struct {
+				Title  string `json:"title"`
+				Status int    `json:"status"`
+				Detail string `json:"detail"`
+			}
+			if err := json.Unmarshal([]byte(res.Body), &p); err != nil {
+				t.Fatalf("decode %q: %v", res.Body, err)
+			}
+			if p.Status != tt.status || p.Title != http.StatusText(tt.status) || p.Detail == "" {
+				t.Errorf("problem = %+v", p)
+			}
+		})
+	}
+}
+
+func TestHandleOptions(t *testing.T) {
+	res, err := newRouter().Handle(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: http.MethodOptions, Path: "/calendar/abc"})
+	if err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if res.StatusCode != http.StatusNoContent || res.Headers["Access-Control-Allow-Methods"] != "GET,PUT,OPTIONS" {
+		t.Errorf("response = %d %v", res.StatusCode, res.Headers)
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	r := newRouter()
+	tests := []struct {
+		method string
+		path   string
+		want   bool
+	}{
+		{http.MethodGet, "/calendar/list", true},
+		{http.MethodGet, "/calendar/abc", false},
+		{http.MethodOptions, "/calendar/abc", true},
+		{http.MethodOptions, "/nothing", false},
+	}
+	for _, tt := range tests {
+		if got := r.IsPublic(tt.method, tt.path); got != tt.want {
+			t.Errorf("IsPublic(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNewRejectsInvalidRoutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []router.Route
+	}{
+		{"relative path", []router.Route{{Method: http.MethodGet, Path: "calendar"}}},
+		{"unclosed parameter", []router.Route{{Method: http.MethodGet, Path: "/calendar/{id"}}},
+		{"duplicate parameter", []router.Route{{Method: http.MethodGet, Path: "/a/{id}/{id}"}}},
+		{"duplicate route", []router.Route{{Method: http.MethodGet, Path: "/a"}, {Method: http.MethodGet, Path: "/a"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("New did not panic")
+				}
+			}()
+			router.New(tt.routes...)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"bonded/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+)
+
+// maxRSVPAttempts は出欠の回答が他の更新と競合した場合に読み直して再試行する回数
+const maxRSVPAttempts = 3
+
+// InviteAttendees はイベントに参加者を追加する。すでに参加者になっている人は出欠をそのまま残す。
+// version は呼び出し元が読み込んだイベントの版数
+func (u *eventUsecase) InviteAttendees(ctx context.Context, calendarID string, eventID string, attendees []models.Attendee, version int64) (*models.Event, error) {
+	if len(attendees) == 0 {
+		return nil, invalidFieldf("attendees", "attendees are required")
+	}
+
+	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = u.authorizer.authorize(ctx, calendar, PermissionEditEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := u.eventRepo.FindEvent(ctx, calendarID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, notFoundf("event %s", eventID)
+	}
+	if event.Version != version {
+		return nil, fmt.Errorf("%w: event %s has been modified", ErrPreconditionFailed, eventID)
+	}
+
+	merged, err := u.mergeAttendees(ctx, calendar, event.Attendees, attendees)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := u.eventRepo.SaveAttendees(ctx, calendarID, eventID, merged, event.Version)
+	if err != nil {
+		return nil, err
+	}
+	localizeEvent(updated)
+	return updated, nil
+}
+
+// RespondToEvent は呼び出し元のユーザーの出欠を記録する。呼び出し元がイベントの参加者でなければ ErrForbidden を返す
+func (u *eventUsecase) RespondToEvent(ctx context.Context, calendarID string, eventID string, rsvp *models.RSVP) (*models.Event, error) {
+	switch rsvp.Status {
+	case models.AttendeeStatusAccepted, models.AttendeeStatusDeclined, models.AttendeeStatusTentative:
+	default:
+		return nil, invalidFieldf("status", "status must be ACCEPTED, DECLINED or TENTATIVE")
+	}
+
+	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := u.authorizer.authorize(ctx, calendar, PermissionViewCalendar)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, forbiddenf("not an attendee of event %s", eventID)
+	}
+
+	// 出欠の回答は版数を指定しないため、他の更新と競合した場合は読み直して再試行する
+	for attempt := 0; ; attempt++ {
+		event, err := u.eventRepo.FindEvent(ctx, calendarID, eventID)
+		if err != nil {
+			return nil, err
+		}
+		if event == nil {
+			return nil, notFoundf("event %s", eventID)
+		}
+
+		attendees := append([]models.Attendee(nil), event.Attendees...)
+		index := -1
+		for i := range attendees {
+			if attendees[i].UserID == member.UserID {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, forbiddenf("not an attendee of event %s", eventID)
+		}
+		attendees[index].Status = rsvp.Status
+		attendees[index].Comment = rsvp.Comment
+
+		updated, err := u.eventRepo.SaveAttendees(ctx, calendarID, eventID, attendees, event.Version)
+		if errors.Is(err, ErrPreconditionFailed) && attempt+1 < maxRSVPAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		localizeEvent(updated)
+		return updated, nil
+	}
+}
+
+// mergeAttendees は追加する参加者を検証して既存の参加者の後ろに加える
+func (u *eventUsecase) mergeAttendees(ctx context.Context, calendar *models.Calendar, current []models.Attendee, added []models.Attendee) ([]models.Attendee, error) {
+	merged := append([]models.Attendee(nil), current...)
+	for _, attendee := range added {
+		normalized, err := u.newAttendee(ctx, calendar, attendee)
+		if err != nil {
+			return nil, err
+		}
+		if containsAttendee(merged, normalized) {
+			continue
+		}
+		merged = append(merged, *normalized)
+	}
+	return merged, nil
+}
+
+// newAttendee は招待する参加者を検証し、出欠が未回答の参加者を返す。
+// ユーザーIDで指定する場合はカレンダーのメンバーでなければならない
+func (u *eventUsecase) newAttendee(ctx context.Context, calendar *models.Calendar, attendee models.Attendee) (*models.Attendee, error) {
+	switch {
+	case attendee.UserID != "" && attendee.Email != "":
+		return nil, invalidf("specify either userId or email for an attendee")
+	case attendee.UserID != "":
+		member, err := u.calendarRepo.FindMember(ctx, calendar.CalendarID, attendee.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if member == nil {
+			return nil, invalidf("user %s is not a member of the calendar", attendee.UserID)
+		}
+		return &models.Attendee{
+			UserID:      member.UserID,
+			DisplayName: member.DisplayName,
+			Status:      models.AttendeeStatusNeedsAction,
+		}, nil
+	case attendee.Email != "":
+		address, err := mail.ParseAddress(attendee.Email)
+		if err != nil {
+			return nil, invalidf("invalid email %q", attendee.Email)
+		}
+		displayName := attendee.DisplayName
+		if displayName == "" {
+			displayName = address.Name
+		}
+		return &models.Attendee{
+			Email:       address.Address,
+			DisplayName: displayName,
+			Status:      models.AttendeeStatusNeedsAction,
+		}, nil
+	default:
+		return nil, invalidf("userId or email is required for an attendee")
+	}
+}
+
+func containsAttendee(attendees []models.Attendee, attendee *models.Attendee) bool {
+	for i := range attendees {
+		if attendees[i].SameAs(attendee) {
+			return true
+		}
+	}
+	return false
+}
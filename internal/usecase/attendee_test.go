@@ -0,0 +1,88 @@
+package usecase_test
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/usecase"
+	"testing"
+)
+
+func TestInviteAttendeesAndRespond(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+	alice, bob := signedIn("alice"), signedIn("bob")
+	signUp(t, u, "bob")
+	_, err := u.Calendar().InviteUser(alice, calendar.CalendarID, "bob", usecase.AccessLevelViewer)
+	if err != nil {
+		t.Fatalf("InviteUser: %v", err)
+	}
+	err = u.Calendar().AcceptInvitation(bob, calendar.CalendarID)
+	if err != nil {
+		t.Fatalf("AcceptInvitation: %v", err)
+	}
+	event := createEvent(t, u, "alice", calendar, "Planning", "")
+
+	_, err = u.Event().InviteAttendees(alice, calendar.CalendarID, event.EventID, []models.Attendee{{UserID: "carol"}}, event.Version)
+	assertErrorIs(t, err, usecase.ErrInvalidInput)
+	_, err = u.Event().InviteAttendees(bob, calendar.CalendarID, event.EventID, []models.Attendee{{UserID: "bob"}}, event.Version)
+	assertErrorIs(t, err, usecase.ErrForbidden)
+
+	attendees := []models.Attendee{{UserID: "bob"}, {Email: "Carol <carol@example.com>"}}
+	invited, err := u.Event().InviteAttendees(alice, calendar.CalendarID, event.EventID, attendees, event.Version)
+	if err != nil {
+		t.Fatalf("InviteAttendees: %v", err)
+	}
+	want := []models.Attendee{
+		{UserID: "bob", DisplayName: "bob name", Status: models.AttendeeStatusNeedsAction},
+		{Email: "carol@example.com", DisplayName: "Carol", Status: models.AttendeeStatusNeedsAction},
+	}
+	if len(invited.Attendees) != len(want) || invited.Version != event.Version+1 {
+		t.Fatalf("invited = %+v", invited)
+	}
+	for i := range want {
+		if invited.Attendees[i] != want[i] {
+			t.Errorf("attendee %d = %+v, want %+v", i, invited.Attendees[i], want[i])
+		}
+	}
+	_, err = u.Event().InviteAttendees(alice, calendar.CalendarID, event.EventID, attendees, event.Version)
+	assertErrorIs(t, err, usecase.ErrPreconditionFailed)
+
+	responded, err := u.Event().RespondToEvent(bob, calendar.CalendarID, event.EventID, &models.RSVP{Status: models.AttendeeStatusAccepted, Comment: "See you"})
+	if err != nil {
+		t.Fatalf("RespondToEvent: %v", err)
+	}
+	if got := responded.Attendees[0]; got.Status != models.AttendeeStatusAccepted || got.Comment != "See you" {
+		t.Errorf("bob = %+v", got)
+	}
+
+	// 招待し直しても回答済みの出欠は残り、重複した参加者は加えない
+	more := []models.Attendee{{UserID: "bob"}, {Email: "CAROL@example.com"}, {Email: "dave@example.com"}}
+	invited, err = u.Event().InviteAttendees(alice, calendar.CalendarID, event.EventID, more, responded.Version)
+	if err != nil {
+		t.Fatalf("InviteAttendees: %v", err)
+	}
+	if len(invited.Attendees) != 3 || invited.Attendees[0].Status != models.AttendeeStatusAccepted {
+		t.Errorf("attendees = %+v", invited.Attendees)
+	}
+}
+
+func TestRespondToEventRequiresAttendee(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Public", true)
+	event := createEvent(t, u, "alice", calendar, "Party", "")
+	accepted := &models.RSVP{Status: models.AttendeeStatusAccepted}
+
+	_, err := u.Event().RespondToEvent(signedIn("bob"), calendar.CalendarID, event.EventID, accepted)
+	assertErrorIs(t, err, usecase.ErrForbidden)
+
+	err = u.Calendar().FollowCalendar(signedIn("bob"), calendar)
+	if err != nil {
+		t.Fatalf("FollowCalendar: %v", err)
+	}
+	_, err = u.Event().RespondToEvent(signedIn("bob"), calendar.CalendarID, event.EventID, accepted)
+	assertErrorIs(t, err, usecase.ErrForbidden)
+
+	_, err = u.Event().RespondToEvent(signedIn("alice"), calendar.CalendarID, event.EventID, &models.RSVP{Status: models.AttendeeStatusNeedsAction})
+	assertErrorIs(t, err, usecase.ErrInvalidInput)
+	_, err = u.Event().RespondToEvent(signedIn("alice"), calendar.CalendarID, "missing", accepted)
+	assertErrorIs(t, err, usecase.ErrNotFound)
+}
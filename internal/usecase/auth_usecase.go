@@ -10,6 +10,7 @@ import (
 
 type IAuthUsecase interface {
 	ValidateJWT(tokenString string) (*jwt.Token, error)
+	ValidateIDToken(tokenString string, accessToken *jwt.Token) (*jwt.Token, error)
 }
 
 type AuthUsecase struct {
@@ -33,31 +34,59 @@ func NewAuthUsecase(
 }
 
 func (u *AuthUsecase) ValidateJWT(tokenString string) (*jwt.Token, error) {
-	token, err := jwt.Parse(tokenString, u.jwks.Keyfunc)
+	token, _, err := u.parse(tokenString, false)
+	return token, err
+}
+
+// ValidateIDToken は Cognito の ID トークンを検証する。アクセストークンにはない email などのクレームを読むために使い、
+// このアプリケーション宛てに発行された、accessToken と同じユーザーの ID トークンだけを受け付ける
+func (u *AuthUsecase) ValidateIDToken(tokenString string, accessToken *jwt.Token) (*jwt.Token, error) {
+	token, claims, err := u.parse(tokenString, true)
 	if err != nil {
 		return nil, err
 	}
+	if tokenUse, _ := claims["token_use"].(string); tokenUse != "id" {
+		return nil, errors.New("not an ID token")
+	}
+
+	accessClaims, ok := accessToken.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid claims")
+	}
+	subject, _ := claims["sub"].(string)
+	if accessSubject, _ := accessClaims["sub"].(string); subject == "" || subject != accessSubject {
+		return nil, errors.New("ID token belongs to another user")
+	}
+	return token, nil
+}
+
+// parse はトークンの署名・有効期限・発行者・対象を検証する。requireAudience が true の場合は aud が必須になる
+func (u *AuthUsecase) parse(tokenString string, requireAudience bool) (*jwt.Token, jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, u.jwks.Keyfunc)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	if !token.Valid {
-		return nil, errors.New("invalid token")
+		return nil, nil, errors.New("invalid token")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return nil, errors.New("invalid claims")
+		return nil, nil, errors.New("invalid claims")
 	}
 
 	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
-		return nil, errors.New("token expired")
+		return nil, nil, errors.New("token expired")
 	}
 
 	if !claims.VerifyIssuer(u.cognitoIssuer, true) {
-		return nil, errors.New("invalid issuer")
+		return nil, nil, errors.New("invalid issuer")
 	}
 
-	if !claims.VerifyAudience(u.clientID, false) {
-		return nil, errors.New("invalid audience")
+	if !claims.VerifyAudience(u.clientID, requireAudience) {
+		return nil, nil, errors.New("invalid audience")
 	}
 
-	return token, nil
+	return token, claims, nil
 }
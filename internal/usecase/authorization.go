@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"bonded/internal/contextKey"
+	"bonded/internal/models"
+	"bonded/internal/repository"
+	"context"
+	"errors"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// ErrForbidden は呼び出し元に操作の権限がない場合に返される
+var ErrForbidden = errors.New("forbidden")
+
+const (
+	AccessLevelOwner  = models.AccessLevelOwner
+	AccessLevelEditor = models.AccessLevelEditor
+	AccessLevelViewer = models.AccessLevelViewer
+)
+
+// Permission はカレンダーに対する操作の種類
+type Permission int
+
+const (
+	PermissionViewCalendar Permission = iota
+	PermissionEditCalendar
+	PermissionDeleteCalendar
+	PermissionManageMembers
+	PermissionUnfollowCalendar
+	PermissionCreateEvent
+	PermissionEditEvent
+	PermissionDeleteEvent
+)
+
+// permissionMatrix は権限レベルごとに許可される操作
+var permissionMatrix = map[string]map[Permission]bool{
+	AccessLevelOwner: {
+		PermissionViewCalendar:   true,
+		PermissionEditCalendar:   true,
+		PermissionDeleteCalendar: true,
+		PermissionManageMembers:  true,
+		PermissionCreateEvent:    true,
+		PermissionEditEvent:      true,
+		PermissionDeleteEvent:    true,
+	},
+	AccessLevelEditor: {
+		PermissionViewCalendar:     true,
+		PermissionUnfollowCalendar: true,
+		PermissionCreateEvent:      true,
+		PermissionEditEvent:        true,
+		PermissionDeleteEvent:      true,
+	},
+	AccessLevelViewer: {
+		PermissionViewCalendar:     true,
+		PermissionUnfollowCalendar: true,
+	},
+}
+
+// publicPermissions はメンバーでなくても公開カレンダーに対して許可される操作
+var publicPermissions = map[Permission]bool{
+	PermissionViewCalendar: true,
+}
+
+// Allows は権限レベルが操作を許可しているかを返す
+func Allows(accessLevel string, permission Permission) bool {
+	return permissionMatrix[accessLevel][permission]
+}
+
+type authorizer struct {
+	calendarRepo repository.CalendarRepository
+}
+
+// authorize は呼び出し元のメンバーシップを USER# アイテムから解決し、操作が許可されているかを確認する。
+// 公開カレンダーの閲覧は非メンバーにも許可し、その場合は nil のメンバーを返す。
+func (a *authorizer) authorize(ctx context.Context, calendar *models.Calendar, permission Permission) (*models.User, error) {
+	accessUserID, err := accessUserIDFromContext(ctx)
+	if err != nil {
+		if calendar.IsPublic != nil && *calendar.IsPublic && publicPermissions[permission] {
+			return nil, nil
+		}
+		return nil, ErrForbidden
+	}
+
+	member, err := a.calendarRepo.FindMember(ctx, calendar.CalendarID, accessUserID)
+	if err != nil {
+		return nil, err
+	}
+	if member != nil && Allows(member.AccessLevel, permission) {
+		return member, nil
+	}
+	if calendar.IsPublic != nil && *calendar.IsPublic && publicPermissions[permission] {
+		return member, nil
+	}
+	return nil, ErrForbidden
+}
+
+func accessUserIDFromContext(ctx context.Context) (string, error) {
+	jwtData, ok := ctx.Value(contextKey.JwtDataKey).(*jwt.Token)
+	if !ok {
+		return "", forbiddenf("failed to get JWT data from context")
+	}
+
+	accessUserID, ok := jwtData.Claims.(jwt.MapClaims)["sub"].(string)
+	if !ok {
+		return "", forbiddenf("failed to get UserID from JWT data")
+	}
+	return accessUserID, nil
+}
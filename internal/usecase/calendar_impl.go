@@ -1,12 +1,10 @@
 package usecase
 
 import (
-	"bonded/internal/contextKey"
 	"bonded/internal/models"
 	"context"
-	"errors"
+	"time"
 
-	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 )
 
@@ -16,74 +14,51 @@ func (u *calendarUsecase) FindCalendar(ctx context.Context, calendarID string) (
 		return nil, err
 	}
 
-	if !*calendarData.IsPublic {
-		jwtData, ok := ctx.Value(contextKey.JwtDataKey).(*jwt.Token)
-		if !ok {
-			return nil, errors.New("failed to get JWT data from context")
-		}
-
-		accessUserID, ok := jwtData.Claims.(jwt.MapClaims)["sub"].(string)
-		if !ok {
-			return nil, errors.New("failed to get UserID from JWT data")
-		}
-
-		isExist := false
-		for _, user := range calendarData.Users {
-			if user.UserID == accessUserID {
-				isExist = true
-				break
-			}
-		}
-
-		if !isExist {
-			return nil, errors.New("access user is not registered in the calendar")
-		}
+	_, err = u.authorizer.authorize(ctx, calendarData, PermissionViewCalendar)
+	if err != nil {
+		return nil, err
 	}
 
+	for i := range calendarData.Events {
+		localizeEvent(&calendarData.Events[i])
+	}
 	return calendarData, nil
 }
 
-func (u *calendarUsecase) FindPublicCalendars(ctx context.Context) ([]*models.Calendar, error) {
-	//全件取得してフィルタリング
-	calendars, err := u.calendarRepo.FindAllCalendars(ctx)
+func (u *calendarUsecase) FindPublicCalendars(ctx context.Context, page models.PageRequest) (*models.Page[*models.CalendarSummary], error) {
+	calendars, err := u.calendarRepo.FindPublicCalendars(ctx, page)
 	if err != nil {
-		return nil, err
+		return nil, pageError(err)
 	}
-	publicCalendars := []*models.Calendar{}
-	for _, calendar := range calendars {
-		if *calendar.IsPublic {
-			publicCalendars = append(publicCalendars, calendar)
-		}
-	}
-	return publicCalendars, nil
+	return calendars, nil
 }
 
 func (u *calendarUsecase) CreateCalendar(ctx context.Context, calendar *models.CreateCalendar) error {
-
-	jwtData, ok := ctx.Value(contextKey.JwtDataKey).(*jwt.Token)
-	if !ok {
-		return errors.New("failed to get JWT data from context")
-	}
-
-	accessUserID, ok := jwtData.Claims.(jwt.MapClaims)["sub"].(string)
-	if !ok {
-		return errors.New("failed to get UserID from JWT data")
+	profile, err := u.profiles.current(ctx)
+	if err != nil {
+		return err
 	}
-	calendar.OwnerUserID = accessUserID
+	calendar.OwnerUserID = profile.UserID
+	// オーナー名とタイムゾーンは指定がなければプロフィールのものを使う
 	if calendar.OwnerName == "" {
-		user, err := u.userRepo.FindByUserID(ctx, calendar.OwnerUserID)
-		if err != nil {
-			return err
-		}
-		calendar.OwnerName = user.DisplayName
+		calendar.OwnerName = profile.DisplayName
+	}
+	if calendar.TimeZone == "" {
+		calendar.TimeZone = profile.TimeZone
 	}
 	user := models.User{
 		UserID:      calendar.OwnerUserID,
 		DisplayName: calendar.OwnerName,
-		AccessLevel: "OWNER",
+		AccessLevel: AccessLevelOwner,
 	}
 	calendar.Users = []models.User{user}
 
+	timeZone, err := validTimeZone(calendar.TimeZone)
+	if err != nil {
+		return err
+	}
+	calendar.TimeZone = timeZone
+
 	calendar.CalendarID = uuid.New().String()
 
 	// CreateCalendarのフィールドをCalendarに変換
@@ -93,6 +68,8 @@ func (u *calendarUsecase) CreateCalendar(ctx context.Context, calendar *models.C
 		Name:        calendar.Name,
 		IsPublic:    calendar.IsPublic,
 		OwnerUserID: calendar.OwnerUserID,
+		OwnerName:   calendar.OwnerName,
+		TimeZone:    calendar.TimeZone,
 		Users:       calendar.Users,
 		Events:      calendar.Events,
 	}
@@ -100,113 +77,70 @@ func (u *calendarUsecase) CreateCalendar(ctx context.Context, calendar *models.C
 	return u.calendarRepo.Create(ctx, &calendarReq)
 }
 
-func (u *calendarUsecase) EditCalendar(ctx context.Context, calendar *models.Calendar, input *models.Calendar) error {
-	return u.calendarRepo.Edit(ctx, calendar, input)
-}
-
-func (u *calendarUsecase) DeleteCalendar(ctx context.Context, calendarID string) error {
-	return u.calendarRepo.Delete(ctx, calendarID)
-}
-
-func (u *calendarUsecase) FindCalendars(ctx context.Context) ([]*models.Calendar, error) {
-	jwtData, ok := ctx.Value(contextKey.JwtDataKey).(*jwt.Token)
-	if !ok {
-		return nil, errors.New("failed to get JWT data from context")
+// EditCalendar はカレンダーを更新し、更新後のカレンダーを返す。version は呼び出し元が読み込んだ版数
+func (u *calendarUsecase) EditCalendar(ctx context.Context, calendar *models.Calendar, input *models.EditCalendar, version int64) (*models.Calendar, error) {
+	_, err := u.authorizer.authorize(ctx, calendar, PermissionEditCalendar)
+	if err != nil {
+		return nil, err
 	}
-
-	accessUserID, ok := jwtData.Claims.(jwt.MapClaims)["sub"].(string)
-	if !ok {
-		return nil, errors.New("failed to get UserID from JWT data")
+	if input.TimeZone != "" {
+		input.TimeZone, err = validTimeZone(input.TimeZone)
+		if err != nil {
+			return nil, err
+		}
 	}
-	return u.calendarRepo.FindByUserID(ctx, accessUserID)
+	return u.calendarRepo.Edit(ctx, calendar, input, version)
 }
 
-func (u *calendarUsecase) FollowCalendar(ctx context.Context, calendar *models.Calendar) error {
-	jwtData, ok := ctx.Value(contextKey.JwtDataKey).(*jwt.Token)
-	if !ok {
-		return errors.New("failed to get JWT data from context")
+// DeleteCalendar はカレンダーとイベント、メンバーなどの関連アイテムをゴミ箱に移す。dryRun の場合は対象の件数だけを返す。
+// version は呼び出し元が読み込んだ版数で、dryRun の場合は使わない
+func (u *calendarUsecase) DeleteCalendar(ctx context.Context, calendarID string, dryRun bool, version int64) (*models.DeletionReport, error) {
+	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, err
 	}
 
-	accessUserID, ok := jwtData.Claims.(jwt.MapClaims)["sub"].(string)
-	if !ok {
-		return errors.New("failed to get UserID from JWT data")
-	}
-	user, err := u.userRepo.FindByUserID(ctx, accessUserID)
+	_, err = u.authorizer.authorize(ctx, calendar, PermissionDeleteCalendar)
 	if err != nil {
-		return err
-	}
-	if user == nil {
-		return errors.New("user not found")
+		return nil, err
 	}
-
-	return u.calendarRepo.FollowCalendar(ctx, calendar, user)
+	return u.calendarRepo.Delete(ctx, calendarID, dryRun, time.Now(), version)
 }
 
-func (u *calendarUsecase) UnfollowCalendar(ctx context.Context, calendar *models.Calendar) error {
-	jwtData, ok := ctx.Value(contextKey.JwtDataKey).(*jwt.Token)
-	if !ok {
-		return errors.New("failed to get JWT data from context")
-	}
-
-	accessUserID, ok := jwtData.Claims.(jwt.MapClaims)["sub"].(string)
-	if !ok {
-		return errors.New("failed to get UserID from JWT data")
-	}
-	user, err := u.userRepo.FindByUserID(ctx, accessUserID)
+func (u *calendarUsecase) FindCalendars(ctx context.Context, page models.PageRequest) (*models.Page[*models.CalendarSummary], error) {
+	accessUserID, err := accessUserIDFromContext(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if user == nil {
-		return errors.New("user not found")
+	calendars, err := u.calendarRepo.FindByUserID(ctx, accessUserID, page)
+	if err != nil {
+		return nil, pageError(err)
 	}
-
-	return u.calendarRepo.UnfollowCalendar(ctx, calendar, user)
+	return calendars, nil
 }
 
-func (u *calendarUsecase) InviteUser(ctx context.Context, calendarID string, inviteUserID string, accessLevel string) error {
-	// カレンダーの取得
-
-	jwtData, ok := ctx.Value(contextKey.JwtDataKey).(*jwt.Token)
-	if !ok {
-		return errors.New("failed to get JWT data from context")
-	}
-
-	ownerUserID, ok := jwtData.Claims.(jwt.MapClaims)["sub"].(string)
-	if !ok {
-		return errors.New("failed to get UserID from JWT data")
+func (u *calendarUsecase) FollowCalendar(ctx context.Context, calendar *models.Calendar) error {
+	if calendar.IsPublic == nil || !*calendar.IsPublic {
+		return forbiddenf("calendar %s is not public", calendar.CalendarID)
 	}
 
-	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	profile, err := u.profiles.current(ctx)
 	if err != nil {
 		return err
 	}
-	if calendar == nil {
-		return errors.New("calendar not found")
-	}
 
-	// オーナーのチェック
-	if calendar.OwnerUserID != ownerUserID {
-		return errors.New("only the owner can invite users")
-	}
+	return u.calendarRepo.FollowCalendar(ctx, calendar, profile.User())
+}
 
-	// 招待するユーザーの存在確認
-	inviteUser, err := u.userRepo.FindByUserID(ctx, inviteUserID)
+func (u *calendarUsecase) UnfollowCalendar(ctx context.Context, calendar *models.Calendar) error {
+	// オーナーはフォロー解除できない
+	user, err := u.authorizer.authorize(ctx, calendar, PermissionUnfollowCalendar)
 	if err != nil {
 		return err
 	}
-	if inviteUser == nil {
-		return errors.New("invite user not found")
-	}
-
-	// ユーザーが既に追加されているか確認
-	for _, user := range calendar.Users {
-		if user.UserID == inviteUserID {
-			return errors.New("user is already a member of this calendar")
-		}
+	if user == nil {
+		return ErrForbidden
 	}
 
-	// ユーザー情報の設定
-	inviteUser.AccessLevel = accessLevel
-
-	return u.calendarRepo.InviteUser(ctx, calendar, inviteUser)
+	return u.calendarRepo.UnfollowCalendar(ctx, calendar, user)
 }
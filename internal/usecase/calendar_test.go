@@ -0,0 +1,218 @@
+package usecase_test
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/usecase"
+	"context"
+	"testing"
+)
+
+func TestCreateCalendarMakesCallerOwner(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+
+	if calendar.OwnerUserID != "alice" || calendar.OwnerName != "alice name" {
+		t.Errorf("owner = %s (%s), want alice (alice name)", calendar.OwnerUserID, calendar.OwnerName)
+	}
+	if calendar.Version != 1 || calendar.FollowerCount != 0 {
+		t.Errorf("version = %d, followerCount = %d, want 1, 0", calendar.Version, calendar.FollowerCount)
+	}
+	if owner := member(calendar, "alice"); owner == nil || owner.AccessLevel != usecase.AccessLevelOwner {
+		t.Errorf("owner membership = %+v", owner)
+	}
+
+	page, err := u.Calendar().FindCalendars(signedIn("alice"), models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("FindCalendars: %v", err)
+	}
+	if len(page.Items) != 1 || page.Items[0].CalendarID != calendar.CalendarID || page.Items[0].AccessLevel != usecase.AccessLevelOwner {
+		t.Errorf("alice's calendars = %+v", page.Items)
+	}
+	page, err = u.Calendar().FindCalendars(signedIn("bob"), models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("FindCalendars: %v", err)
+	}
+	if len(page.Items) != 0 {
+		t.Errorf("bob's calendars = %+v, want none", page.Items)
+	}
+}
+
+func TestFindCalendarAccess(t *testing.T) {
+	u := newUsecase()
+	private := createCalendar(t, u, "alice", "Private", false)
+	public := createCalendar(t, u, "alice", "Public", true)
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		calendarID string
+		want       error
+	}{
+		{"owner reads private", signedIn("alice"), private.CalendarID, nil},
+		{"non-member reads private", signedIn("bob"), private.CalendarID, usecase.ErrForbidden},
+		{"anonymous reads private", context.Background(), private.CalendarID, usecase.ErrForbidden},
+		{"anonymous reads public", context.Background(), public.CalendarID, nil},
+		{"missing calendar", signedIn("alice"), "missing", usecase.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := u.Calendar().FindCalendar(tt.ctx, tt.calendarID)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("FindCalendar: %v", err)
+				}
+				return
+			}
+			assertErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestFollowAndUnfollowCalendar(t *testing.T) {
+	u := newUsecase()
+	public := createCalendar(t, u, "alice", "Public", true)
+	private := createCalendar(t, u, "alice", "Private", false)
+	bob := signedIn("bob")
+
+	assertErrorIs(t, u.Calendar().FollowCalendar(bob, private), usecase.ErrForbidden)
+
+	err := u.Calendar().FollowCalendar(bob, public)
+	if err != nil {
+		t.Fatalf("FollowCalendar: %v", err)
+	}
+	calendar := findCalendar(t, u, "bob", public.CalendarID)
+	if calendar.FollowerCount != 1 {
+		t.Errorf("followerCount = %d, want 1", calendar.FollowerCount)
+	}
+	if follower := member(calendar, "bob"); follower == nil || follower.AccessLevel != usecase.AccessLevelViewer {
+		t.Errorf("follower membership = %+v", follower)
+	}
+	assertErrorIs(t, u.Calendar().FollowCalendar(bob, public), usecase.ErrConflict)
+
+	// オーナーはフォロー解除できない
+	assertErrorIs(t, u.Calendar().UnfollowCalendar(signedIn("alice"), calendar), usecase.ErrForbidden)
+
+	err = u.Calendar().UnfollowCalendar(bob, calendar)
+	if err != nil {
+		t.Fatalf("UnfollowCalendar: %v", err)
+	}
+	calendar = findCalendar(t, u, "alice", public.CalendarID)
+	if calendar.FollowerCount != 0 || member(calendar, "bob") != nil {
+		t.Errorf("after unfollow: followerCount = %d, users = %+v", calendar.FollowerCount, calendar.Users)
+	}
+}
+
+func TestEditCalendarChecksVersion(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+	alice := signedIn("alice")
+
+	updated, err := u.Calendar().EditCalendar(alice, calendar, &models.EditCalendar{Name: "Renamed"}, calendar.Version)
+	if err != nil {
+		t.Fatalf("EditCalendar: %v", err)
+	}
+	if updated.Name != "Renamed" || updated.Version != calendar.Version+1 {
+		t.Errorf("updated = %s (version %d)", updated.Name, updated.Version)
+	}
+
+	_, err = u.Calendar().EditCalendar(alice, calendar, &models.EditCalendar{Name: "Stale"}, calendar.Version)
+	assertErrorIs(t, err, usecase.ErrPreconditionFailed)
+
+	_, err = u.Calendar().EditCalendar(signedIn("bob"), calendar, &models.EditCalendar{Name: "Bob's"}, updated.Version)
+	assertErrorIs(t, err, usecase.ErrForbidden)
+}
+
+func TestFindCalendarsPages(t *testing.T) {
+	u := newUsecase()
+	for _, name := range []string{"A", "B", "C"} {
+		createCalendar(t, u, "alice", name, false)
+	}
+	alice := signedIn("alice")
+
+	first, err := u.Calendar().FindCalendars(alice, models.PageRequest{Limit: 2})
+	if err != nil {
+		t.Fatalf("FindCalendars: %v", err)
+	}
+	if len(first.Items) != 2 || first.NextCursor == "" {
+		t.Fatalf("first page = %d items, cursor %q", len(first.Items), first.NextCursor)
+	}
+	second, err := u.Calendar().FindCalendars(alice, models.PageRequest{Limit: 2, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatalf("FindCalendars: %v", err)
+	}
+	if len(second.Items) != 1 || second.NextCursor != "" {
+		t.Fatalf("second page = %d items, cursor %q", len(second.Items), second.NextCursor)
+	}
+	for _, calendar := range first.Items {
+		if calendar.CalendarID == second.Items[0].CalendarID {
+			t.Errorf("calendar %s appears on both pages", calendar.CalendarID)
+		}
+	}
+
+	_, err = u.Calendar().FindCalendars(alice, models.PageRequest{Limit: 2, Cursor: "!"})
+	assertErrorIs(t, err, usecase.ErrInvalidInput)
+}
+
+func TestDeleteRestoreAndPurgeCalendar(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Public", true)
+	alice := signedIn("alice")
+
+	report, err := u.Calendar().DeleteCalendar(alice, calendar.CalendarID, true, 0)
+	if err != nil {
+		t.Fatalf("DeleteCalendar dry run: %v", err)
+	}
+	if !report.DryRun || report.Members != 1 || report.Relations != 1 || report.Total != 3 {
+		t.Errorf("dry run report = %+v", report)
+	}
+	_, err = u.Calendar().DeleteCalendar(alice, calendar.CalendarID, false, calendar.Version+1)
+	assertErrorIs(t, err, usecase.ErrPreconditionFailed)
+
+	_, err = u.Calendar().DeleteCalendar(alice, calendar.CalendarID, false, calendar.Version)
+	if err != nil {
+		t.Fatalf("DeleteCalendar: %v", err)
+	}
+	_, err = u.Calendar().FindCalendar(alice, calendar.CalendarID)
+	assertErrorIs(t, err, usecase.ErrNotFound)
+	listed, err := u.Calendar().FindPublicCalendars(context.Background(), models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("FindPublicCalendars: %v", err)
+	}
+	if len(listed.Items) != 0 {
+		t.Errorf("public calendars = %+v, want none while in the trash", listed.Items)
+	}
+	trashed, err := u.Calendar().FindTrashedCalendars(alice, models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("FindTrashedCalendars: %v", err)
+	}
+	if len(trashed.Items) != 1 || trashed.Items[0].DeletedAt == nil {
+		t.Fatalf("trashed calendars = %+v", trashed.Items)
+	}
+
+	err = u.Calendar().RestoreCalendar(alice, calendar.CalendarID)
+	if err != nil {
+		t.Fatalf("RestoreCalendar: %v", err)
+	}
+	// ゴミ箱に移すのも変更なので版数が進む
+	restored := findCalendar(t, u, "alice", calendar.CalendarID)
+	if restored.Version != calendar.Version+1 {
+		t.Errorf("version = %d, want %d", restored.Version, calendar.Version+1)
+	}
+	listed, err = u.Calendar().FindPublicCalendars(context.Background(), models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("FindPublicCalendars: %v", err)
+	}
+	if len(listed.Items) != 1 {
+		t.Errorf("public calendars = %+v, want the restored calendar", listed.Items)
+	}
+
+	_, err = u.Calendar().DeleteCalendar(alice, calendar.CalendarID, false, restored.Version)
+	if err != nil {
+		t.Fatalf("DeleteCalendar: %v", err)
+	}
+	_, err = u.Calendar().PurgeCalendar(alice, calendar.CalendarID)
+	if err != nil {
+		t.Fatalf("PurgeCalendar: %v", err)
+	}
+	assertErrorIs(t, u.Calendar().RestoreCalendar(alice, calendar.CalendarID), usecase.ErrNotFound)
+}
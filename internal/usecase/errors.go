@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"bonded/internal/repository"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidInput は入力値が不正な場合に返される
+var ErrInvalidInput = errors.New("invalid input")
+
+// ErrConflict は他の操作と競合して変更できなかった場合に返される
+var ErrConflict = repository.ErrConflict
+
+// ErrNotFound は対象が存在しない場合に返される
+var ErrNotFound = repository.ErrNotFound
+
+// ErrPreconditionFailed は呼び出し元が読み込んだ版から対象が更新されていた場合に返される
+var ErrPreconditionFailed = repository.ErrPreconditionFailed
+
+// NotFoundError は対象が存在しないことを表す。errors.Is で ErrNotFound と一致する
+type NotFoundError struct {
+	Detail string
+}
+
+func (e *NotFoundError) Error() string {
+	return ErrNotFound.Error() + ": " + e.Detail
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ForbiddenError は呼び出し元に操作の権限がないことを表す。errors.Is で ErrForbidden と一致する
+type ForbiddenError struct {
+	Detail string
+}
+
+func (e *ForbiddenError) Error() string {
+	return ErrForbidden.Error() + ": " + e.Detail
+}
+
+func (e *ForbiddenError) Is(target error) bool {
+	return target == ErrForbidden
+}
+
+// ConflictError は対象の現在の状態と矛盾するため操作できないことを表す。errors.Is で ErrConflict と一致する
+type ConflictError struct {
+	Detail string
+}
+
+func (e *ConflictError) Error() string {
+	return ErrConflict.Error() + ": " + e.Detail
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// ValidationError は入力値が不正なことを表す。errors.Is で ErrInvalidInput と一致する
+type ValidationError struct {
+	Field  string // 不正なフィールドの JSON のキー名。特定のフィールドによらない場合は空
+	Detail string
+}
+
+func (e *ValidationError) Error() string {
+	return ErrInvalidInput.Error() + ": " + e.Detail
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+func notFoundf(format string, args ...interface{}) error {
+	return &NotFoundError{Detail: fmt.Sprintf(format, args...)}
+}
+
+func forbiddenf(format string, args ...interface{}) error {
+	return &ForbiddenError{Detail: fmt.Sprintf(format, args...)}
+}
+
+func conflictf(format string, args ...interface{}) error {
+	return &ConflictError{Detail: fmt.Sprintf(format, args...)}
+}
+
+func invalidf(format string, args ...interface{}) error {
+	return &ValidationError{Detail: fmt.Sprintf(format, args...)}
+}
+
+// invalidFieldf は field の値が不正なことを表す ValidationError を返す
+func invalidFieldf(field string, format string, args ...interface{}) error {
+	return &ValidationError{Field: field, Detail: fmt.Sprintf(format, args...)}
+}
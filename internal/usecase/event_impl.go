@@ -2,24 +2,111 @@ package usecase
 
 import (
 	"bonded/internal/models"
+	"bonded/internal/recurrence"
 	"context"
-	"errors"
+	"fmt"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 func (u *eventUsecase) CreateEvent(ctx context.Context, calendar *models.Calendar, event *models.Event) error {
+	_, err := u.authorizer.authorize(ctx, calendar, PermissionCreateEvent)
+	if err != nil {
+		return err
+	}
+
+	err = normalizeEventTime(event, calendar)
+	if err != nil {
+		return err
+	}
+	err = validateRecurrence(event)
+	if err != nil {
+		return err
+	}
+	event.RecurringEventID = ""
+	event.RecurrenceID = ""
+	event.DeletedAt = nil
+	event.ExpiresAt = nil
+	event.Version = 1
+	event.Attendees, err = u.mergeAttendees(ctx, calendar, nil, event.Attendees)
+	if err != nil {
+		return err
+	}
+
 	event.EventID = uuid.New().String()
 	return u.eventRepo.CreateEvent(ctx, calendar, event)
 }
 
-func (u *eventUsecase) FindEvents(ctx context.Context, calendarID string) ([]*models.Event, error) {
-	return u.eventRepo.FindEvents(ctx, calendarID)
+func (u *eventUsecase) FindEvents(ctx context.Context, calendarID string, window *models.TimeRange, page models.PageRequest) (*models.Page[*models.Event], error) {
+	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = u.authorizer.authorize(ctx, calendar, PermissionViewCalendar)
+	if err != nil {
+		return nil, err
+	}
+
+	// 期間の指定がなければ保存されているイベントをそのまま返す
+	var result *models.Page[*models.Event]
+	if window == nil {
+		result, err = u.eventRepo.FindEventsPage(ctx, calendarID, page)
+		if err != nil {
+			return nil, pageError(err)
+		}
+	} else {
+		// 期間内の発生は展開して並べ替えてからページに分ける
+		candidates, err := u.eventRepo.FindEventsBetween(ctx, calendarID, *window)
+		if err != nil {
+			return nil, err
+		}
+		events, err := expandEvents(candidates, *window)
+		if err != nil {
+			return nil, err
+		}
+		result, err = slicePage(events, page)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, event := range result.Items {
+		localizeEvent(event)
+	}
+	return result, nil
+}
+
+// FindEvent はイベントを1件返す
+func (u *eventUsecase) FindEvent(ctx context.Context, calendarID string, eventID string) (*models.Event, error) {
+	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = u.authorizer.authorize(ctx, calendar, PermissionViewCalendar)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := u.eventRepo.FindEvent(ctx, calendarID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, notFoundf("event %s", eventID)
+	}
+	localizeEvent(event)
+	return event, nil
 }
 
-func (u *eventUsecase) EditEvent(ctx context.Context, calendarID string, event *models.Event) (*models.Event, error) {
-	if event.EventID == "" {
-		return nil, errors.New("eventID is required")
+// EditEvent はイベントに JSON Merge Patch を適用する。version は呼び出し元が読み込んだ繰り返し元（または単発のイベント）の版数
+func (u *eventUsecase) EditEvent(ctx context.Context, calendarID string, patch *models.EventPatch, scope string, version int64) (*models.Event, error) {
+	if patch.EventID == "" {
+		return nil, invalidFieldf("eventId", "eventId is required")
+	}
+	if patch.Title.Null || patch.StartTime.Null || patch.EndTime.Null || patch.AllDay.Null {
+		return nil, invalidf("title, startTime, endTime and allDay cannot be null")
 	}
 
 	res, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
@@ -27,20 +114,195 @@ func (u *eventUsecase) EditEvent(ctx context.Context, calendarID string, event *
 		return nil, err
 	}
 	if res == nil {
-		return nil, errors.New("calendar not found")
+		return nil, notFoundf("calendar %s", calendarID)
+	}
+
+	_, err = u.authorizer.authorize(ctx, res, PermissionEditEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	master, err := u.eventRepo.FindEvent(ctx, calendarID, patch.EventID)
+	if err != nil {
+		return nil, err
+	}
+	if master == nil {
+		return nil, notFoundf("event %s", patch.EventID)
+	}
+	if master.Version != version {
+		return nil, fmt.Errorf("%w: event %s has been modified", ErrPreconditionFailed, master.EventID)
+	}
+
+	if !isRecurring(master) || scope == models.RecurrenceScopeAll || (scope == "" && patch.RecurrenceID == "") {
+		return u.editAll(ctx, res, master, patch)
+	}
+
+	set, duration, err := recurrenceSet(master)
+	if err != nil {
+		return nil, err
+	}
+	recurrenceID, err := occurrenceTime(set, patch.RecurrenceID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch scope {
+	case "", models.RecurrenceScopeThis:
+		return u.editOccurrence(ctx, res, master, recurrenceID, duration, patch)
+	case models.RecurrenceScopeThisAndFollowing:
+		if recurrenceID.Equal(set.Start) {
+			return u.editAll(ctx, res, master, patch)
+		}
+
+		// 以降の発生は、この発生を起点に元の追加日・除外日のうちこの発生以降のものを引き継ぐ
+		following := occurrenceOf(master, recurrenceID, duration, "")
+		following.EventID = uuid.New().String()
+		following.UID = ""
+		following.RecurringEventID = ""
+		following.Version = 1
+		following.RDates = filterTimes(master.RDates, func(t time.Time) bool { return !t.Before(recurrenceID) })
+		following.ExDates = filterTimes(master.ExDates, func(t time.Time) bool { return !t.Before(recurrenceID) })
+
+		// 元の繰り返しをこの発生の直前で終了させ、以降を新しい繰り返しイベントとして作成する
+		following.RRule = truncateBefore(master, set, recurrenceID)
+		patch.Apply(following)
+		err = normalizeEventTime(following, res)
+		if err != nil {
+			return nil, err
+		}
+		err = validateRecurrence(following)
+		if err != nil {
+			return nil, err
+		}
+
+		// 繰り返し元の終了・以降の個別の編集の削除・新しい繰り返しの作成はまとめて書き込む
+		removed, err := u.overridesFrom(ctx, calendarID, master, recurrenceID)
+		if err != nil {
+			return nil, err
+		}
+		err = u.eventRepo.EditSeries(ctx, res, master, recurrenceFields, master.Version, removed, following)
+		if err != nil {
+			return nil, err
+		}
+		localizeEvent(following)
+		return following, nil
+	default:
+		return nil, invalidFieldf("scope", "unknown scope %q", scope)
+	}
+}
+
+// editAll は繰り返しイベント全体（または単発のイベント）にパッチを適用する
+func (u *eventUsecase) editAll(ctx context.Context, calendar *models.Calendar, master *models.Event, patch *models.EventPatch) (*models.Event, error) {
+	event := *master
+	patch.Apply(&event)
+	err := normalizeEventTime(&event, calendar)
+	if err != nil {
+		return nil, err
+	}
+	err = validateRecurrence(&event)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isRecurring(master) || isRecurring(&event) {
+		updated, err := u.eventRepo.EditEvent(ctx, calendar.CalendarID, &event, updatedFields(patch), master.Version)
+		if err != nil {
+			return nil, err
+		}
+		localizeEvent(updated)
+		return updated, nil
+	}
+
+	// 繰り返しをやめた場合は個別の発生の編集も同じ書き込みで削除する
+	removed, err := u.overridesFrom(ctx, calendar.CalendarID, master, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	err = u.eventRepo.EditSeries(ctx, calendar, &event, updatedFields(patch), master.Version, removed, nil)
+	if err != nil {
+		return nil, err
+	}
+	event.Version = master.Version + 1
+	localizeEvent(&event)
+	return &event, nil
+}
+
+// editOccurrence は繰り返しイベントの1つの発生にパッチを適用する。
+// すでに個別に編集されている発生はその内容に、それ以外は繰り返しから生成した発生に適用する
+func (u *eventUsecase) editOccurrence(ctx context.Context, calendar *models.Calendar, master *models.Event, recurrenceID time.Time, duration time.Duration, patch *models.EventPatch) (*models.Event, error) {
+	normalized := formatEventTime(recurrenceID, master.AllDay)
+	overrides, err := u.eventRepo.FindOverrides(ctx, calendar.CalendarID, master.EventID)
+	if err != nil {
+		return nil, err
+	}
+	override := occurrenceOf(master, recurrenceID, duration, normalized)
+	for _, existing := range overrides {
+		if existing.RecurrenceID == normalized {
+			override = existing
+			break
+		}
+	}
+
+	patch.Apply(override)
+	// 個別の発生は繰り返し情報を持たない
+	override.EventID = master.EventID
+	override.RRule = ""
+	override.RDates = nil
+	override.ExDates = nil
+	override.RecurringEventID = master.EventID
+	override.RecurrenceID = normalized
+	override.DeletedAt = nil
+	override.ExpiresAt = nil
+	err = normalizeEventTime(override, calendar)
+	if err != nil {
+		return nil, err
 	}
 
-	exists := u.eventRepo.EventExists(ctx, calendarID, event.EventID)
-	if !exists {
-		return nil, errors.New("event not found")
+	err = u.eventRepo.SaveOverride(ctx, calendar.CalendarID, override, master.Version)
+	if err != nil {
+		return nil, err
 	}
+	override.Version = master.Version + 1
+	override.Attendees = master.Attendees
+	localizeEvent(override)
+	return override, nil
+}
 
-	return u.eventRepo.EditEvent(ctx, calendarID, event)
+// recurrenceFields は繰り返しの範囲を変更したときに保存するフィールド
+var recurrenceFields = []models.EventField{models.EventFieldRRule, models.EventFieldRDates, models.EventFieldExDates}
+
+// eventTimeFields は日時の正規化で互いに影響し合うフィールド
+var eventTimeFields = []models.EventField{
+	models.EventFieldStartTime,
+	models.EventFieldEndTime,
+	models.EventFieldTimeZone,
+	models.EventFieldAllDay,
+	models.EventFieldRDates,
+	models.EventFieldExDates,
+}
+
+// updatedFields はパッチを適用したイベントのうち保存するフィールドを返す。
+// 日時に関わるフィールドを1つでも変更した場合は、正規化の結果が変わるためまとめて保存する
+func updatedFields(patch *models.EventPatch) []models.EventField {
+	fields := patch.Fields()
+	for _, field := range fields {
+		if !slices.Contains(eventTimeFields, field) {
+			continue
+		}
+		for _, timeField := range eventTimeFields {
+			if !slices.Contains(fields, timeField) {
+				fields = append(fields, timeField)
+			}
+		}
+		break
+	}
+	return fields
 }
 
-func (u *eventUsecase) DeleteEvent(ctx context.Context, calendarID string, eventID string) error {
+// DeleteEvent はイベントを削除する。version は呼び出し元が読み込んだ繰り返し元（または単発のイベント）の版数
+func (u *eventUsecase) DeleteEvent(ctx context.Context, calendarID string, eventID string, recurrenceID string, scope string, version int64) error {
 	if eventID == "" {
-		return errors.New("eventID is required")
+		return invalidFieldf("eventId", "eventId is required")
 	}
 
 	res, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
@@ -48,13 +310,91 @@ func (u *eventUsecase) DeleteEvent(ctx context.Context, calendarID string, event
 		return err
 	}
 	if res == nil {
-		return errors.New("calendar not found")
+		return notFoundf("calendar %s", calendarID)
 	}
 
-	exists := u.eventRepo.EventExists(ctx, calendarID, eventID)
-	if !exists {
-		return errors.New("event not found")
+	_, err = u.authorizer.authorize(ctx, res, PermissionDeleteEvent)
+	if err != nil {
+		return err
+	}
+
+	master, err := u.eventRepo.FindEvent(ctx, calendarID, eventID)
+	if err != nil {
+		return err
+	}
+	if master == nil {
+		return notFoundf("event %s", eventID)
+	}
+	if master.Version != version {
+		return fmt.Errorf("%w: event %s has been modified", ErrPreconditionFailed, master.EventID)
+	}
+
+	if !isRecurring(master) || scope == models.RecurrenceScopeAll || (scope == "" && recurrenceID == "") {
+		return u.deleteAll(ctx, calendarID, master)
+	}
+
+	set, _, err := recurrenceSet(master)
+	if err != nil {
+		return err
+	}
+	occurrence, err := occurrenceTime(set, recurrenceID)
+	if err != nil {
+		return err
+	}
+
+	switch scope {
+	case "", models.RecurrenceScopeThis:
+		normalized := formatEventTime(occurrence, master.AllDay)
+		master.ExDates = append(master.ExDates, normalized)
+		return u.eventRepo.EditSeries(ctx, res, master, []models.EventField{models.EventFieldExDates}, master.Version, []string{normalized}, nil)
+	case models.RecurrenceScopeThisAndFollowing:
+		if occurrence.Equal(set.Start) {
+			return u.deleteAll(ctx, calendarID, master)
+		}
+		truncateBefore(master, set, occurrence)
+		removed, err := u.overridesFrom(ctx, calendarID, master, occurrence)
+		if err != nil {
+			return err
+		}
+		return u.eventRepo.EditSeries(ctx, res, master, recurrenceFields, master.Version, removed, nil)
+	default:
+		return invalidFieldf("scope", "unknown scope %q", scope)
+	}
+}
+
+// deleteAll はイベントを個別の編集とともにゴミ箱に移す
+func (u *eventUsecase) deleteAll(ctx context.Context, calendarID string, master *models.Event) error {
+	return u.eventRepo.TrashEvent(ctx, calendarID, master.EventID, time.Now(), master.Version)
+}
+
+// overridesFrom は from 以降の発生に対する個別の編集の recurrenceId を返す
+func (u *eventUsecase) overridesFrom(ctx context.Context, calendarID string, master *models.Event, from time.Time) ([]string, error) {
+	overrides, err := u.eventRepo.FindOverrides(ctx, calendarID, master.EventID)
+	if err != nil {
+		return nil, err
 	}
+	var recurrenceIDs []string
+	for _, override := range overrides {
+		recurrenceID, err := parseEventTime(override.RecurrenceID)
+		if err == nil && recurrenceID.Before(from) {
+			continue
+		}
+		recurrenceIDs = append(recurrenceIDs, override.RecurrenceID)
+	}
+	return recurrenceIDs, nil
+}
 
-	return u.eventRepo.DeleteEvent(ctx, calendarID, eventID)
+// occurrenceTime は recurrenceId を解析し、繰り返しの発生に含まれるかを確認する
+func occurrenceTime(set *recurrence.Set, recurrenceID string) (time.Time, error) {
+	if recurrenceID == "" {
+		return time.Time{}, invalidFieldf("recurrenceId", "recurrenceId is required")
+	}
+	t, err := parseEventTime(recurrenceID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !set.Contains(t) {
+		return time.Time{}, invalidFieldf("recurrenceId", "recurrenceId %s is not an occurrence of the event", recurrenceID)
+	}
+	return t, nil
 }
@@ -0,0 +1,330 @@
+package usecase_test
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/usecase"
+	"testing"
+	"time"
+)
+
+var eventStart = time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+
+// createEvent は userID としてカレンダーに1時間のイベントを作成する
+func createEvent(t *testing.T, u usecase.Usecase, userID string, calendar *models.Calendar, title string, rrule string) *models.Event {
+	t.Helper()
+	event := &models.Event{
+		Title:     title,
+		StartTime: models.DateTime{Time: eventStart},
+		EndTime:   models.DateTime{Time: eventStart.Add(time.Hour)},
+		TimeZone:  "UTC",
+		RRule:     rrule,
+	}
+	err := u.Event().CreateEvent(signedIn(userID), calendar, event)
+	if err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	return event
+}
+
+func TestCreateAndEditEvent(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+	alice := signedIn("alice")
+	event := createEvent(t, u, "alice", calendar, "Standup", "")
+
+	found, err := u.Event().FindEvent(alice, calendar.CalendarID, event.EventID)
+	if err != nil {
+		t.Fatalf("FindEvent: %v", err)
+	}
+	if found.Title != "Standup" || found.Version != 1 || !found.StartTime.Equal(eventStart) {
+		t.Errorf("found = %+v", found)
+	}
+
+	patch := &models.EventPatch{EventID: event.EventID, Title: models.SetField("Daily standup")}
+	edited, err := u.Event().EditEvent(alice, calendar.CalendarID, patch, "", found.Version)
+	if err != nil {
+		t.Fatalf("EditEvent: %v", err)
+	}
+	if edited.Title != "Daily standup" || edited.Version != 2 || !edited.EndTime.Equal(eventStart.Add(time.Hour)) {
+		t.Errorf("edited = %+v", edited)
+	}
+	_, err = u.Event().EditEvent(alice, calendar.CalendarID, patch, "", found.Version)
+	assertErrorIs(t, err, usecase.ErrPreconditionFailed)
+
+	_, err = u.Event().FindEvent(alice, calendar.CalendarID, "missing")
+	assertErrorIs(t, err, usecase.ErrNotFound)
+	_, err = u.Event().FindEvent(signedIn("bob"), calendar.CalendarID, event.EventID)
+	assertErrorIs(t, err, usecase.ErrForbidden)
+}
+
+func TestViewerCannotCreateEvent(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Public", true)
+	bob := signedIn("bob")
+	err := u.Calendar().FollowCalendar(bob, calendar)
+	if err != nil {
+		t.Fatalf("FollowCalendar: %v", err)
+	}
+
+	event := &models.Event{
+		Title:     "Party",
+		StartTime: models.DateTime{Time: eventStart},
+		EndTime:   models.DateTime{Time: eventStart.Add(time.Hour)},
+	}
+	assertErrorIs(t, u.Event().CreateEvent(bob, calendar, event), usecase.ErrForbidden)
+}
+
+func TestCreateEventNormalizesRRule(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+	event := createEvent(t, u, "alice", calendar, "Weekly", "RRULE:freq=weekly;interval=1;byday=MO")
+
+	found, err := u.Event().FindEvent(signedIn("alice"), calendar.CalendarID, event.EventID)
+	if err != nil {
+		t.Fatalf("FindEvent: %v", err)
+	}
+	if found.RRule != "FREQ=WEEKLY;BYDAY=MO" {
+		t.Errorf("rrule = %q, want FREQ=WEEKLY;BYDAY=MO", found.RRule)
+	}
+}
+
+func TestFindEventsExpandsRecurrence(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+	alice := signedIn("alice")
+	master := createEvent(t, u, "alice", calendar, "Standup", "FREQ=DAILY;COUNT=5")
+	createEvent(t, u, "alice", calendar, "Review", "")
+
+	window := &models.TimeRange{From: eventStart.Add(24 * time.Hour), To: eventStart.Add(3 * 24 * time.Hour)}
+	page, err := u.Event().FindEvents(alice, calendar.CalendarID, window, models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("FindEvents: %v", err)
+	}
+	if len(page.Items) != 2 {
+		t.Fatalf("occurrences = %d, want 2", len(page.Items))
+	}
+	for i, occurrence := range page.Items {
+		want := eventStart.Add(time.Duration(i+1) * 24 * time.Hour)
+		if occurrence.RecurringEventID != master.EventID || !occurrence.StartTime.Equal(want) {
+			t.Errorf("occurrence %d = %s at %s, want %s", i, occurrence.RecurringEventID, occurrence.StartTime, want)
+		}
+	}
+
+	// 1つの発生だけを変更すると、その発生だけが置き換わる
+	patch := &models.EventPatch{EventID: master.EventID, RecurrenceID: page.Items[0].RecurrenceID, Title: models.SetField("Moved standup")}
+	_, err = u.Event().EditEvent(alice, calendar.CalendarID, patch, models.RecurrenceScopeThis, master.Version)
+	if err != nil {
+		t.Fatalf("EditEvent: %v", err)
+	}
+	page, err = u.Event().FindEvents(alice, calendar.CalendarID, window, models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("FindEvents: %v", err)
+	}
+	if len(page.Items) != 2 || page.Items[0].Title != "Moved standup" || page.Items[1].Title != "Standup" {
+		t.Errorf("occurrences = %+v", page.Items)
+	}
+
+	all, err := u.Event().FindEvents(alice, calendar.CalendarID, nil, models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("FindEvents: %v", err)
+	}
+	// 期間を指定しない一覧では、個別の発生は繰り返し元にまとめて返す
+	if len(all.Items) != 2 {
+		t.Fatalf("stored events = %d, want 2", len(all.Items))
+	}
+	for _, event := range all.Items {
+		if event.RecurringEventID != "" {
+			t.Errorf("override %s is listed as an event", event.RecurrenceID)
+		}
+		if event.EventID == master.EventID && (len(event.Overrides) != 1 || event.Overrides[0].Title != "Moved standup") {
+			t.Errorf("overrides of the master = %+v", event.Overrides)
+		}
+	}
+	calendar = findCalendar(t, u, "alice", calendar.CalendarID)
+	if len(calendar.Events) != 2 {
+		t.Errorf("calendar events = %d, want 2", len(calendar.Events))
+	}
+}
+
+func TestFindEventsPages(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+	alice := signedIn("alice")
+	createEvent(t, u, "alice", calendar, "Standup", "FREQ=DAILY;COUNT=5")
+	createEvent(t, u, "alice", calendar, "Review", "")
+	createEvent(t, u, "alice", calendar, "Retro", "")
+
+	window := &models.TimeRange{From: eventStart, To: eventStart.Add(7 * 24 * time.Hour)}
+	for _, tt := range []struct {
+		name   string
+		window *models.TimeRange
+		total  int
+	}{
+		{"stored events", nil, 3},
+		{"occurrences in a window", window, 7},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := map[string]bool{}
+			page := models.PageRequest{Limit: 2}
+			for pages := 0; ; pages++ {
+				if pages > tt.total {
+					t.Fatal("cursor never ends")
+				}
+				result, err := u.Event().FindEvents(alice, calendar.CalendarID, tt.window, page)
+				if err != nil {
+					t.Fatalf("FindEvents: %v", err)
+				}
+				if len(result.Items) > page.Limit {
+					t.Fatalf("page has %d items, limit %d", len(result.Items), page.Limit)
+				}
+				for _, event := range result.Items {
+					key := event.EventID + "/" + event.RecurrenceID
+					if seen[key] {
+						t.Errorf("%s appears on more than one page", key)
+					}
+					seen[key] = true
+				}
+				if result.NextCursor == "" {
+					break
+				}
+				page.Cursor = result.NextCursor
+			}
+			if len(seen) != tt.total {
+				t.Errorf("listed %d items, want %d", len(seen), tt.total)
+			}
+
+			_, err := u.Event().FindEvents(alice, calendar.CalendarID, tt.window, models.PageRequest{Limit: 2, Cursor: "!"})
+			assertErrorIs(t, err, usecase.ErrInvalidInput)
+		})
+	}
+}
+
+func TestDeleteAndRestoreEvent(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+	alice := signedIn("alice")
+	event := createEvent(t, u, "alice", calendar, "Standup", "")
+
+	assertErrorIs(t, u.Event().DeleteEvent(alice, calendar.CalendarID, event.EventID, "", "", event.Version+1), usecase.ErrPreconditionFailed)
+	err := u.Event().DeleteEvent(alice, calendar.CalendarID, event.EventID, "", "", event.Version)
+	if err != nil {
+		t.Fatalf("DeleteEvent: %v", err)
+	}
+	_, err = u.Event().FindEvent(alice, calendar.CalendarID, event.EventID)
+	assertErrorIs(t, err, usecase.ErrNotFound)
+	calendar = findCalendar(t, u, "alice", calendar.CalendarID)
+	if len(calendar.Events) != 0 {
+		t.Errorf("calendar events = %+v, want none while in the trash", calendar.Events)
+	}
+
+	trashed, err := u.Event().FindTrashedEvents(alice, calendar.CalendarID, models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("FindTrashedEvents: %v", err)
+	}
+	if len(trashed.Items) != 1 || trashed.Items[0].EventID != event.EventID || trashed.Items[0].ExpiresAt == nil {
+		t.Fatalf("trashed events = %+v", trashed.Items)
+	}
+
+	err = u.Event().RestoreEvent(alice, calendar.CalendarID, event.EventID)
+	if err != nil {
+		t.Fatalf("RestoreEvent: %v", err)
+	}
+	assertErrorIs(t, u.Event().RestoreEvent(alice, calendar.CalendarID, event.EventID), usecase.ErrNotFound)
+	restored, err := u.Event().FindEvent(alice, calendar.CalendarID, event.EventID)
+	if err != nil {
+		t.Fatalf("FindEvent: %v", err)
+	}
+	// ゴミ箱に移すのも変更なので版数が進む
+	if restored.DeletedAt != nil || restored.ExpiresAt != nil || restored.Version != event.Version+1 {
+		t.Errorf("restored = %+v", restored)
+	}
+}
+
+func TestEditFollowingSplitsSeries(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+	alice := signedIn("alice")
+	master := createEvent(t, u, "alice", calendar, "Standup", "FREQ=DAILY;COUNT=5")
+
+	third := eventStart.Add(2 * 24 * time.Hour).Format(time.RFC3339)
+	fourth := eventStart.Add(3 * 24 * time.Hour).Format(time.RFC3339)
+	patch := &models.EventPatch{EventID: master.EventID, RecurrenceID: fourth, Title: models.SetField("Moved standup")}
+	_, err := u.Event().EditEvent(alice, calendar.CalendarID, patch, models.RecurrenceScopeThis, master.Version)
+	if err != nil {
+		t.Fatalf("EditEvent: %v", err)
+	}
+
+	// 古い版数での分割は何も書き込まない
+	patch = &models.EventPatch{EventID: master.EventID, RecurrenceID: third, Title: models.SetField("Sync")}
+	_, err = u.Event().EditEvent(alice, calendar.CalendarID, patch, models.RecurrenceScopeThisAndFollowing, master.Version)
+	assertErrorIs(t, err, usecase.ErrPreconditionFailed)
+	all, err := u.Event().FindEvents(alice, calendar.CalendarID, nil, models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("FindEvents: %v", err)
+	}
+	if len(all.Items) != 1 || len(all.Items[0].Overrides) != 1 {
+		t.Fatalf("events after a failed split = %+v", all.Items)
+	}
+
+	following, err := u.Event().EditEvent(alice, calendar.CalendarID, patch, models.RecurrenceScopeThisAndFollowing, all.Items[0].Version)
+	if err != nil {
+		t.Fatalf("EditEvent: %v", err)
+	}
+	if following.EventID == master.EventID || following.Title != "Sync" || following.RRule != "FREQ=DAILY;COUNT=3" {
+		t.Errorf("following = %+v", following)
+	}
+	found, err := u.Event().FindEvent(alice, calendar.CalendarID, master.EventID)
+	if err != nil {
+		t.Fatalf("FindEvent: %v", err)
+	}
+	if found.RRule != "FREQ=DAILY;COUNT=2" || found.Version != master.Version+2 {
+		t.Errorf("master = rrule %q, version %d", found.RRule, found.Version)
+	}
+	// 分割した以降の発生への個別の編集は削除される
+	all, err = u.Event().FindEvents(alice, calendar.CalendarID, nil, models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("FindEvents: %v", err)
+	}
+	if len(all.Items) != 2 {
+		t.Fatalf("events = %d, want 2", len(all.Items))
+	}
+	for _, event := range all.Items {
+		if len(event.Overrides) != 0 {
+			t.Errorf("overrides of %s = %+v", event.EventID, event.Overrides)
+		}
+	}
+}
+
+func TestDeleteOccurrence(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+	alice := signedIn("alice")
+	master := createEvent(t, u, "alice", calendar, "Standup", "FREQ=DAILY;COUNT=3")
+	second := eventStart.Add(24 * time.Hour).Format(time.RFC3339)
+
+	patch := &models.EventPatch{EventID: master.EventID, RecurrenceID: second, Title: models.SetField("Moved standup")}
+	_, err := u.Event().EditEvent(alice, calendar.CalendarID, patch, models.RecurrenceScopeThis, master.Version)
+	if err != nil {
+		t.Fatalf("EditEvent: %v", err)
+	}
+	err = u.Event().DeleteEvent(alice, calendar.CalendarID, master.EventID, second, models.RecurrenceScopeThis, master.Version+1)
+	if err != nil {
+		t.Fatalf("DeleteEvent: %v", err)
+	}
+
+	window := &models.TimeRange{From: eventStart, To: eventStart.Add(7 * 24 * time.Hour)}
+	page, err := u.Event().FindEvents(alice, calendar.CalendarID, window, models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("FindEvents: %v", err)
+	}
+	if len(page.Items) != 2 || page.Items[0].Title != "Standup" || page.Items[1].Title != "Standup" {
+		t.Errorf("occurrences = %+v", page.Items)
+	}
+	all, err := u.Event().FindEvents(alice, calendar.CalendarID, nil, models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("FindEvents: %v", err)
+	}
+	if len(all.Items) != 1 || len(all.Items[0].ExDates) != 1 || len(all.Items[0].Overrides) != 0 {
+		t.Errorf("events = %+v", all.Items)
+	}
+}
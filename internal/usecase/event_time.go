@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"bonded/internal/models"
+	"time"
+)
+
+// calendarLocation はカレンダーの既定のタイムゾーンを返す
+func calendarLocation(calendar *models.Calendar) *time.Location {
+	loc, err := models.LoadTimeZone(calendar.TimeZone)
+	if err != nil {
+		loc, _ = models.LoadTimeZone("")
+	}
+	return loc
+}
+
+// validTimeZone は IANA タイムゾーン名を検証して正規化する。空の場合は DefaultTimeZone を返す
+func validTimeZone(name string) (string, error) {
+	loc, err := models.LoadTimeZone(name)
+	if err != nil {
+		return "", invalidFieldf("timeZone", "%s", err.Error())
+	}
+	return loc.String(), nil
+}
+
+// eventZone はイベントのタイムゾーンを返す。未設定のイベントは DefaultTimeZone とみなす
+func eventZone(event *models.Event) *time.Location {
+	loc, err := models.LoadTimeZone(event.TimeZone)
+	if err != nil {
+		loc, _ = models.LoadTimeZone("")
+	}
+	return loc
+}
+
+// eventLocation は繰り返しの展開や期間の判定に使うタイムゾーンを返す。
+// 終日イベントの日付は floating なので UTC のまま扱う。
+func eventLocation(event *models.Event) *time.Location {
+	if event.AllDay {
+		return time.UTC
+	}
+	return eventZone(event)
+}
+
+// normalizeEventTime はイベントのタイムゾーンを補完し、開始・終了日時を検証して正規化する。
+// 終日イベントは floating な日付に、それ以外はイベントのタイムゾーンでの日時に揃える。
+func normalizeEventTime(event *models.Event, calendar *models.Calendar) error {
+	if event.TimeZone == "" {
+		event.TimeZone = calendarLocation(calendar).String()
+	}
+	timeZone, err := validTimeZone(event.TimeZone)
+	if err != nil {
+		return err
+	}
+	event.TimeZone = timeZone
+	loc, _ := models.LoadTimeZone(timeZone)
+
+	if event.StartTime.IsZero() || event.EndTime.IsZero() {
+		return invalidf("startTime and endTime are required")
+	}
+
+	if event.AllDay {
+		event.StartTime = floatingDate(event.StartTime, loc, false)
+		event.EndTime = floatingDate(event.EndTime, loc, true)
+	} else {
+		event.StartTime = models.DateTime{Time: event.StartTime.At(loc)}
+		event.EndTime = models.DateTime{Time: event.EndTime.At(loc)}
+	}
+
+	if !event.EndTime.After(event.StartTime.Time) {
+		return invalidFieldf("endTime", "endTime must be after startTime")
+	}
+	return nil
+}
+
+// floatingDate は日時を loc での日付に変換する。
+// 終了日時は排他的な日付にするため、0 時ちょうどでなければ翌日に切り上げる。
+func floatingDate(d models.DateTime, loc *time.Location, roundUp bool) models.DateTime {
+	if d.DateOnly {
+		return d
+	}
+	t := d.Time.In(loc)
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if hour, min, sec := t.Clock(); roundUp && (hour != 0 || min != 0 || sec != 0 || t.Nanosecond() != 0) {
+		date = date.AddDate(0, 0, 1)
+	}
+	return models.DateTime{Time: date, DateOnly: true}
+}
+
+// localizeEvent は保存時に UTC にした日時をイベントのタイムゾーンで表す
+func localizeEvent(event *models.Event) {
+	for i := range event.Overrides {
+		localizeEvent(&event.Overrides[i])
+	}
+	if event.AllDay {
+		return
+	}
+	loc := eventLocation(event)
+	if !event.StartTime.IsZero() {
+		event.StartTime = models.DateTime{Time: event.StartTime.At(loc)}
+	}
+	if !event.EndTime.IsZero() {
+		event.EndTime = models.DateTime{Time: event.EndTime.At(loc)}
+	}
+}
+
+// eventWindow はイベントと比較するための期間を返す。終日イベントはイベントのタイムゾーンでの日付で比較する
+func eventWindow(event *models.Event, window models.TimeRange) models.TimeRange {
+	if event.AllDay {
+		return floatingRange(window, eventZone(event))
+	}
+	return window
+}
+
+// eventStart はイベントの開始を瞬間として返す
+func eventStart(event *models.Event) time.Time {
+	return event.StartTime.At(eventZone(event))
+}
+
+// floatingRange は期間を loc での壁時計の日時（UTC 表記）に変換し、floating な日付と比較できるようにする
+func floatingRange(window models.TimeRange, loc *time.Location) models.TimeRange {
+	floating := func(t time.Time) time.Time {
+		t = t.In(loc)
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	}
+	return models.TimeRange{From: floating(window.From), To: floating(window.To)}
+}
@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"bonded/internal/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateFeedToken はメンバーがカレンダーアプリから購読するためのフィードトークンを発行する
+func (u *calendarUsecase) CreateFeedToken(ctx context.Context, calendarID string) (*models.FeedToken, error) {
+	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := u.authorizer.authorize(ctx, calendar, PermissionViewCalendar)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrForbidden
+	}
+
+	secret, err := newSecretToken()
+	if err != nil {
+		return nil, err
+	}
+	token := &models.FeedToken{
+		TokenID:    uuid.New().String(),
+		CalendarID: calendarID,
+		UserID:     member.UserID,
+		TokenHash:  hashToken(secret),
+		CreatedAt:  time.Now().UTC(),
+	}
+	err = u.calendarRepo.CreateFeedToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Token = secret
+	return token, nil
+}
+
+// FindFeedTokens は呼び出し元が発行したフィードトークンの一覧を返す（トークン本体は含まない）
+func (u *calendarUsecase) FindFeedTokens(ctx context.Context, calendarID string) ([]*models.FeedToken, error) {
+	accessUserID, err := accessUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return u.calendarRepo.FindFeedTokens(ctx, calendarID, accessUserID)
+}
+
+// RevokeFeedToken は呼び出し元が発行したフィードトークンを失効させる
+func (u *calendarUsecase) RevokeFeedToken(ctx context.Context, calendarID string, tokenID string) error {
+	tokens, err := u.FindFeedTokens(ctx, calendarID)
+	if err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		if token.TokenID == tokenID {
+			return u.calendarRepo.DeleteFeedToken(ctx, calendarID, token.TokenHash)
+		}
+	}
+	return notFoundf("feed token %s", tokenID)
+}
+
+// FindFeedCalendar はフィード用にカレンダーを取得する。
+// 公開カレンダーはトークンなしで取得でき、非公開カレンダーは有効なフィードトークンを必要とする。
+func (u *calendarUsecase) FindFeedCalendar(ctx context.Context, calendarID string, secret string) (*models.Calendar, error) {
+	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	if calendar.IsPublic != nil && *calendar.IsPublic {
+		return calendar, nil
+	}
+	if secret == "" {
+		return nil, ErrForbidden
+	}
+
+	token, err := u.calendarRepo.FindFeedToken(ctx, calendarID, hashToken(secret))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, ErrForbidden
+	}
+
+	// 発行したユーザーがメンバーでなくなった場合はトークンも無効とする
+	member, err := u.calendarRepo.FindMember(ctx, calendarID, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil || !Allows(member.AccessLevel, PermissionViewCalendar) {
+		return nil, ErrForbidden
+	}
+	return calendar, nil
+}
@@ -0,0 +1,99 @@
+package usecase_test
+
+import (
+	"bonded/internal/usecase"
+	"context"
+	"testing"
+)
+
+func TestFeedTokens(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+	alice, bob := signedIn("alice"), signedIn("bob")
+	signUp(t, u, "bob")
+	_, err := u.Calendar().InviteUser(alice, calendar.CalendarID, "bob", usecase.AccessLevelViewer)
+	if err != nil {
+		t.Fatalf("InviteUser: %v", err)
+	}
+	err = u.Calendar().AcceptInvitation(bob, calendar.CalendarID)
+	if err != nil {
+		t.Fatalf("AcceptInvitation: %v", err)
+	}
+
+	_, err = u.Calendar().CreateFeedToken(signedIn("carol"), calendar.CalendarID)
+	assertErrorIs(t, err, usecase.ErrForbidden)
+
+	token, err := u.Calendar().CreateFeedToken(bob, calendar.CalendarID)
+	if err != nil {
+		t.Fatalf("CreateFeedToken: %v", err)
+	}
+	if token.Token == "" || token.UserID != "bob" || token.CreatedAt.IsZero() {
+		t.Fatalf("token = %+v", token)
+	}
+
+	// 一覧はトークン本体を含まず、発行したユーザーのものだけを返す
+	tokens, err := u.Calendar().FindFeedTokens(bob, calendar.CalendarID)
+	if err != nil {
+		t.Fatalf("FindFeedTokens: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].TokenID != token.TokenID || tokens[0].Token != "" {
+		t.Errorf("tokens = %+v", tokens)
+	}
+	tokens, err = u.Calendar().FindFeedTokens(alice, calendar.CalendarID)
+	if err != nil {
+		t.Fatalf("FindFeedTokens: %v", err)
+	}
+	if len(tokens) != 0 {
+		t.Errorf("alice's tokens = %+v", tokens)
+	}
+
+	feed, err := u.Calendar().FindFeedCalendar(context.Background(), calendar.CalendarID, token.Token)
+	if err != nil {
+		t.Fatalf("FindFeedCalendar: %v", err)
+	}
+	if feed.CalendarID != calendar.CalendarID {
+		t.Errorf("feed = %+v", feed)
+	}
+	_, err = u.Calendar().FindFeedCalendar(context.Background(), calendar.CalendarID, "")
+	assertErrorIs(t, err, usecase.ErrForbidden)
+	_, err = u.Calendar().FindFeedCalendar(context.Background(), calendar.CalendarID, "wrong")
+	assertErrorIs(t, err, usecase.ErrForbidden)
+
+	// 発行したユーザーがメンバーでなくなるとトークンも使えない
+	err = u.Calendar().RemoveMember(alice, calendar.CalendarID, "bob")
+	if err != nil {
+		t.Fatalf("RemoveMember: %v", err)
+	}
+	_, err = u.Calendar().FindFeedCalendar(context.Background(), calendar.CalendarID, token.Token)
+	assertErrorIs(t, err, usecase.ErrForbidden)
+}
+
+func TestRevokeFeedToken(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+	alice := signedIn("alice")
+	token, err := u.Calendar().CreateFeedToken(alice, calendar.CalendarID)
+	if err != nil {
+		t.Fatalf("CreateFeedToken: %v", err)
+	}
+
+	assertErrorIs(t, u.Calendar().RevokeFeedToken(alice, calendar.CalendarID, "missing"), usecase.ErrNotFound)
+	err = u.Calendar().RevokeFeedToken(alice, calendar.CalendarID, token.TokenID)
+	if err != nil {
+		t.Fatalf("RevokeFeedToken: %v", err)
+	}
+	_, err = u.Calendar().FindFeedCalendar(context.Background(), calendar.CalendarID, token.Token)
+	assertErrorIs(t, err, usecase.ErrForbidden)
+}
+
+func TestPublicFeedNeedsNoToken(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Public", true)
+	feed, err := u.Calendar().FindFeedCalendar(context.Background(), calendar.CalendarID, "")
+	if err != nil {
+		t.Fatalf("FindFeedCalendar: %v", err)
+	}
+	if feed.CalendarID != calendar.CalendarID {
+		t.Errorf("feed = %+v", feed)
+	}
+}
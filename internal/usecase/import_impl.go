@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"bonded/internal/ical"
+	"bonded/internal/models"
+	"context"
+	"errors"
+	"io"
+	"sort"
+)
+
+// ImportEvents は .ics を解析してイベントを取り込む。
+// UID が一致する既存のイベントは重複して作成せずに更新する。
+func (u *eventUsecase) ImportEvents(ctx context.Context, calendar *models.Calendar, r io.Reader) (*models.ImportReport, error) {
+	_, err := u.authorizer.authorize(ctx, calendar, PermissionCreateEvent)
+	if err != nil {
+		return nil, err
+	}
+	_, err = u.authorizer.authorize(ctx, calendar, PermissionEditEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := ical.Decode(r, calendarLocation(calendar))
+	if err != nil {
+		return nil, invalidf("%s", err.Error())
+	}
+
+	existing, err := u.eventRepo.FindEvents(ctx, calendar.CalendarID)
+	if err != nil {
+		return nil, err
+	}
+	byUID := map[string]*models.Event{}
+	for _, event := range existing {
+		if event.UID != "" && event.RecurringEventID == "" {
+			byUID[event.UID] = event
+		}
+	}
+
+	report := &models.ImportReport{Results: make([]models.ImportResult, 0, len(entries))}
+
+	// 個別の発生は繰り返し元に紐づけるため、繰り返し元を先に取り込む
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return !isOverrideEntry(entries[order[a]]) && isOverrideEntry(entries[order[b]])
+	})
+
+	for _, i := range order {
+		entry := entries[i]
+		result := models.ImportResult{Index: i, UID: entry.UID}
+		if entry.Event != nil {
+			result.Title = entry.Event.Title
+		}
+
+		err := entry.Err
+		if err == nil {
+			result.Status, err = u.importEntry(ctx, calendar, entry.Event, byUID)
+		}
+		if err == nil {
+			result.EventID = entry.Event.EventID
+		}
+		if err != nil {
+			if errors.Is(err, ErrForbidden) {
+				return nil, err
+			}
+			result.Status = models.ImportStatusFailed
+			result.Error = err.Error()
+		}
+
+		switch result.Status {
+		case models.ImportStatusCreated:
+			report.Created++
+		case models.ImportStatusUpdated:
+			report.Updated++
+		default:
+			report.Failed++
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	sort.Slice(report.Results, func(a, b int) bool {
+		return report.Results[a].Index < report.Results[b].Index
+	})
+	return report, nil
+}
+
+func (u *eventUsecase) importEntry(ctx context.Context, calendar *models.Calendar, event *models.Event, byUID map[string]*models.Event) (string, error) {
+	// 個別の発生は繰り返し元の該当する発生を編集する
+	if event.RecurrenceID != "" {
+		master, ok := byUID[event.UID]
+		if !ok {
+			return "", errors.New("recurring event for RECURRENCE-ID not found")
+		}
+		event.EventID = master.EventID
+		override, err := u.EditEvent(ctx, calendar.CalendarID, models.EventPatchOf(event), models.RecurrenceScopeThis, master.Version)
+		if err != nil {
+			return "", err
+		}
+		// 個別の発生を保存すると繰り返し元の版数が進む
+		master.Version = override.Version
+		return models.ImportStatusUpdated, nil
+	}
+
+	if current, ok := byUID[event.UID]; ok {
+		event.EventID = current.EventID
+		// インポート元の内容を正とするため、すべてのフィールドを置き換える（除外日がなければ既存の除外日も消す）
+		updated, err := u.EditEvent(ctx, calendar.CalendarID, models.EventPatchOf(event), models.RecurrenceScopeAll, current.Version)
+		if err != nil {
+			return "", err
+		}
+		byUID[event.UID] = updated
+		return models.ImportStatusUpdated, nil
+	}
+
+	err := u.CreateEvent(ctx, calendar, event)
+	if err != nil {
+		return "", err
+	}
+	byUID[event.UID] = event
+	return models.ImportStatusCreated, nil
+}
+
+func isOverrideEntry(entry *ical.Entry) bool {
+	return entry.Event != nil && entry.Event.RecurrenceID != ""
+}
@@ -0,0 +1,94 @@
+package usecase_test
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/usecase"
+	"strings"
+	"testing"
+	"time"
+)
+
+const importBody = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\nUID:override@example.com\r\nRECURRENCE-ID:20240402T100000Z\r\nDTSTART:20240402T110000Z\r\nDTEND:20240402T120000Z\r\nSUMMARY:Moved standup\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:override@example.com\r\nDTSTART:20240401T100000Z\r\nDTEND:20240401T110000Z\r\nRRULE:FREQ=DAILY;COUNT=3\r\nSUMMARY:Standup\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:review@example.com\r\nDTSTART:20240405T100000Z\r\nSUMMARY:Review\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:broken@example.com\r\nSUMMARY:No start\r\nEND:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func importEvents(t *testing.T, u usecase.Usecase, userID string, calendar *models.Calendar, body string) *models.ImportReport {
+	t.Helper()
+	report, err := u.Event().ImportEvents(signedIn(userID), calendar, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("ImportEvents: %v", err)
+	}
+	return report
+}
+
+func TestImportEvents(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+	alice := signedIn("alice")
+
+	report := importEvents(t, u, "alice", calendar, importBody)
+	if report.Created != 2 || report.Updated != 1 || report.Failed != 1 || len(report.Results) != 4 {
+		t.Fatalf("report = %+v", report)
+	}
+	// 結果は取り込んだ順ではなく VEVENT の順に並ぶ
+	wantStatuses := []string{models.ImportStatusUpdated, models.ImportStatusCreated, models.ImportStatusCreated, models.ImportStatusFailed}
+	for i, result := range report.Results {
+		if result.Index != i || result.Status != wantStatuses[i] {
+			t.Errorf("result %d = %+v, want %s", i, result, wantStatuses[i])
+		}
+	}
+	if report.Results[3].Error == "" {
+		t.Error("failed result has no error")
+	}
+
+	window := &models.TimeRange{From: eventStart, To: eventStart.Add(5 * 24 * time.Hour)}
+	page, err := u.Event().FindEvents(alice, calendar.CalendarID, window, models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("FindEvents: %v", err)
+	}
+	titles := make([]string, len(page.Items))
+	for i, event := range page.Items {
+		titles[i] = event.Title
+	}
+	if strings.Join(titles, ",") != "Standup,Moved standup,Standup,Review" {
+		t.Errorf("events = %v", titles)
+	}
+	// DTEND がない時刻付きのイベントは最小の長さにする
+	review := page.Items[3]
+	if review.UID != "review@example.com" || !review.EndTime.After(review.StartTime.Time) {
+		t.Errorf("review = %+v", review)
+	}
+
+	// 同じ UID を取り込み直すと既存のイベントを更新する
+	again := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:review@example.com\r\nDTSTART:20240405T100000Z\r\nDTEND:20240405T113000Z\r\nSUMMARY:Weekly review\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	report = importEvents(t, u, "alice", calendar, again)
+	if report.Created != 0 || report.Updated != 1 || report.Results[0].EventID != review.EventID {
+		t.Fatalf("report = %+v", report)
+	}
+	updated, err := u.Event().FindEvent(alice, calendar.CalendarID, review.EventID)
+	if err != nil {
+		t.Fatalf("FindEvent: %v", err)
+	}
+	if updated.Title != "Weekly review" || updated.Version != review.Version+1 {
+		t.Errorf("updated = %+v", updated)
+	}
+}
+
+func TestImportEventsErrors(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Public", true)
+	bob := signedIn("bob")
+	err := u.Calendar().FollowCalendar(bob, calendar)
+	if err != nil {
+		t.Fatalf("FollowCalendar: %v", err)
+	}
+
+	_, err = u.Event().ImportEvents(bob, calendar, strings.NewReader(importBody))
+	assertErrorIs(t, err, usecase.ErrForbidden)
+
+	_, err = u.Event().ImportEvents(signedIn("alice"), calendar, strings.NewReader("BEGIN:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assertErrorIs(t, err, usecase.ErrInvalidInput)
+}
@@ -4,17 +4,27 @@ import (
 	"bonded/internal/models"
 	"bonded/internal/repository"
 	"context"
+	"io"
 )
 
 func CalendarUsecaseRequest(calendarRepo repository.CalendarRepository, eventRepo repository.EventRepository, userRepo repository.UserRepository) Usecase {
+	authorizer := &authorizer{calendarRepo: calendarRepo}
+	profiles := &profiles{userRepo: userRepo}
 	return &usecase{
 		calendarUsecase: &calendarUsecase{
 			calendarRepo: calendarRepo,
 			userRepo:     userRepo,
+			authorizer:   authorizer,
+			profiles:     profiles,
 		},
 		eventUsecase: &eventUsecase{
 			eventRepo:    eventRepo,
 			calendarRepo: calendarRepo,
+			authorizer:   authorizer,
+		},
+		userUsecase: &userUsecase{
+			userRepo: userRepo,
+			profiles: profiles,
 		},
 	}
 }
@@ -22,21 +32,31 @@ func CalendarUsecaseRequest(calendarRepo repository.CalendarRepository, eventRep
 type usecase struct {
 	calendarUsecase CalendarUsecase
 	eventUsecase    EventUsecase
+	userUsecase     UserUsecase
 }
 
 type calendarUsecase struct {
 	calendarRepo repository.CalendarRepository
 	userRepo     repository.UserRepository
+	authorizer   *authorizer
+	profiles     *profiles
 }
 
 type eventUsecase struct {
 	eventRepo    repository.EventRepository
 	calendarRepo repository.CalendarRepository
+	authorizer   *authorizer
+}
+
+type userUsecase struct {
+	userRepo repository.UserRepository
+	profiles *profiles
 }
 
 type Usecase interface {
 	Calendar() CalendarUsecase
 	Event() EventUsecase
+	User() UserUsecase
 }
 
 func (u *usecase) Calendar() CalendarUsecase {
@@ -47,21 +67,58 @@ func (u *usecase) Event() EventUsecase {
 	return u.eventUsecase
 }
 
+func (u *usecase) User() UserUsecase {
+	return u.userUsecase
+}
+
 type CalendarUsecase interface {
 	CreateCalendar(ctx context.Context, calendar *models.CreateCalendar) error
-	EditCalendar(ctx context.Context, calendar *models.Calendar, input *models.Calendar) error
-	DeleteCalendar(ctx context.Context, calendarID string) error
-	FindPublicCalendars(ctx context.Context) ([]*models.Calendar, error)
-	FindCalendars(ctx context.Context) ([]*models.Calendar, error)
+	EditCalendar(ctx context.Context, calendar *models.Calendar, input *models.EditCalendar, version int64) (*models.Calendar, error)
+	DeleteCalendar(ctx context.Context, calendarID string, dryRun bool, version int64) (*models.DeletionReport, error)
+	FindTrashedCalendars(ctx context.Context, page models.PageRequest) (*models.Page[*models.Calendar], error)
+	RestoreCalendar(ctx context.Context, calendarID string) error
+	PurgeCalendar(ctx context.Context, calendarID string) (*models.DeletionReport, error)
+	FindPublicCalendars(ctx context.Context, page models.PageRequest) (*models.Page[*models.CalendarSummary], error)
+	FindCalendars(ctx context.Context, page models.PageRequest) (*models.Page[*models.CalendarSummary], error)
 	FindCalendar(ctx context.Context, calendarID string) (*models.Calendar, error)
 	FollowCalendar(ctx context.Context, calendar *models.Calendar) error
 	UnfollowCalendar(ctx context.Context, calendar *models.Calendar) error
-	InviteUser(ctx context.Context, calendarID string, inviteUserID string, accessLevel string) error
+	ChangeMemberAccessLevel(ctx context.Context, calendarID string, userID string, accessLevel string) error
+	RemoveMember(ctx context.Context, calendarID string, userID string) error
+	TransferOwnership(ctx context.Context, calendarID string, newOwnerID string, version int64) (*models.Calendar, error)
+	InviteUser(ctx context.Context, calendarID string, inviteUserID string, accessLevel string) (*models.Invitation, error)
+	InviteUserByEmail(ctx context.Context, calendarID string, email string, accessLevel string) (*models.Invitation, error)
+	FindInvitations(ctx context.Context, calendarID string) ([]*models.Invitation, error)
+	RevokeInvitation(ctx context.Context, calendarID string, userID string) error
+	FindReceivedInvitations(ctx context.Context, page models.PageRequest) (*models.Page[*models.Invitation], error)
+	AcceptInvitation(ctx context.Context, calendarID string) error
+	DeclineInvitation(ctx context.Context, calendarID string) error
+	CreateShareLink(ctx context.Context, calendarID string, accessLevel string, maxUses int, validDays int) (*models.ShareLink, error)
+	FindShareLinks(ctx context.Context, calendarID string) ([]*models.ShareLink, error)
+	RevokeShareLink(ctx context.Context, calendarID string, linkID string) error
+	RedeemShareLink(ctx context.Context, calendarID string, secret string) (*models.ShareLink, error)
+	CreateFeedToken(ctx context.Context, calendarID string) (*models.FeedToken, error)
+	FindFeedTokens(ctx context.Context, calendarID string) ([]*models.FeedToken, error)
+	RevokeFeedToken(ctx context.Context, calendarID string, tokenID string) error
+	FindFeedCalendar(ctx context.Context, calendarID string, secret string) (*models.Calendar, error)
 }
 
 type EventUsecase interface {
 	CreateEvent(ctx context.Context, calendar *models.Calendar, event *models.Event) error
-	FindEvents(ctx context.Context, calendarID string) ([]*models.Event, error)
-	EditEvent(ctx context.Context, calendarID string, event *models.Event) (*models.Event, error)
-	DeleteEvent(ctx context.Context, calendarID string, eventID string) error
+	FindEvents(ctx context.Context, calendarID string, window *models.TimeRange, page models.PageRequest) (*models.Page[*models.Event], error)
+	FindEvent(ctx context.Context, calendarID string, eventID string) (*models.Event, error)
+	EditEvent(ctx context.Context, calendarID string, patch *models.EventPatch, scope string, version int64) (*models.Event, error)
+	DeleteEvent(ctx context.Context, calendarID string, eventID string, recurrenceID string, scope string, version int64) error
+	InviteAttendees(ctx context.Context, calendarID string, eventID string, attendees []models.Attendee, version int64) (*models.Event, error)
+	RespondToEvent(ctx context.Context, calendarID string, eventID string, rsvp *models.RSVP) (*models.Event, error)
+	ImportEvents(ctx context.Context, calendar *models.Calendar, r io.Reader) (*models.ImportReport, error)
+	FindTrashedEvents(ctx context.Context, calendarID string, page models.PageRequest) (*models.Page[*models.Event], error)
+	RestoreEvent(ctx context.Context, calendarID string, eventID string) error
+}
+
+type UserUsecase interface {
+	FindMe(ctx context.Context) (*models.Profile, error)
+	EditMe(ctx context.Context, input *models.EditProfile) (*models.Profile, error)
+	FindUserByEmail(ctx context.Context, email string) (*models.UserSummary, error)
+	SearchUsers(ctx context.Context, prefix string, page models.PageRequest) (*models.Page[*models.UserSummary], error)
 }
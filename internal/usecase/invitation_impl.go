@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"bonded/internal/models"
+	"context"
+	"time"
+)
+
+// InviteUser はユーザーをカレンダーに招待する。招待されたユーザーが承諾するまでメンバーにはならない
+func (u *calendarUsecase) InviteUser(ctx context.Context, calendarID string, inviteUserID string, accessLevel string) (*models.Invitation, error) {
+	return u.invite(ctx, calendarID, accessLevel, func() (*models.User, error) {
+		inviteUser, err := u.userRepo.FindByUserID(ctx, inviteUserID)
+		if err != nil {
+			return nil, err
+		}
+		if inviteUser == nil {
+			return nil, notFoundf("user %s", inviteUserID)
+		}
+		return inviteUser, nil
+	})
+}
+
+// InviteUserByEmail はメールアドレスで指定したユーザーをカレンダーに招待する。
+// オーナーの確認より前にユーザーを探さないので、オーナー以外はメールアドレスが登録されているかを知ることができない
+func (u *calendarUsecase) InviteUserByEmail(ctx context.Context, calendarID string, email string, accessLevel string) (*models.Invitation, error) {
+	return u.invite(ctx, calendarID, accessLevel, func() (*models.User, error) {
+		address, err := parseEmail(email)
+		if err != nil {
+			return nil, err
+		}
+		profile, err := u.userRepo.FindProfileByEmail(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+		if profile == nil {
+			return nil, notFoundf("user with email %s", address)
+		}
+		return &models.User{UserID: profile.UserID, DisplayName: profile.DisplayName}, nil
+	})
+}
+
+// invite はオーナーであることを確かめてから findInvitee で招待するユーザーを探し、招待を作成する
+func (u *calendarUsecase) invite(ctx context.Context, calendarID string, accessLevel string, findInvitee func() (*models.User, error)) (*models.Invitation, error) {
+	if accessLevel != AccessLevelEditor && accessLevel != AccessLevelViewer {
+		return nil, invalidFieldf("accessLevel", "access level must be either EDITOR or VIEWER")
+	}
+
+	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	// オーナーのチェック
+	owner, err := u.authorizer.authorize(ctx, calendar, PermissionManageMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	// 招待するユーザーの存在確認
+	inviteUser, err := findInvitee()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	invitation := &models.Invitation{
+		CalendarID:   calendar.CalendarID,
+		CalendarName: calendar.Name,
+		UserID:       inviteUser.UserID,
+		DisplayName:  inviteUser.DisplayName,
+		AccessLevel:  accessLevel,
+		InvitedBy:    owner.UserID,
+		Status:       models.InvitationStatusPending,
+		CreatedAt:    now,
+		ValidUntil:   models.NewInvitationValidUntil(now),
+	}
+	err = u.calendarRepo.CreateInvitation(ctx, invitation)
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// FindInvitations はカレンダーの招待を返す。オーナーだけが参照できる
+func (u *calendarUsecase) FindInvitations(ctx context.Context, calendarID string) ([]*models.Invitation, error) {
+	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = u.authorizer.authorize(ctx, calendar, PermissionManageMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	invitations, err := u.calendarRepo.FindInvitations(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, invitation := range invitations {
+		invitation.ResolveStatus(now)
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation は回答待ちの招待を取り消す
+func (u *calendarUsecase) RevokeInvitation(ctx context.Context, calendarID string, userID string) error {
+	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	if err != nil {
+		return err
+	}
+	_, err = u.authorizer.authorize(ctx, calendar, PermissionManageMembers)
+	if err != nil {
+		return err
+	}
+
+	invitation, err := u.pendingInvitation(ctx, calendarID, userID)
+	if err != nil {
+		return err
+	}
+	return u.calendarRepo.RespondInvitation(ctx, invitation.CalendarID, invitation.UserID, models.InvitationStatusRevoked, time.Now().UTC())
+}
+
+// FindReceivedInvitations は呼び出し元のユーザーが受け取った招待を返す
+func (u *calendarUsecase) FindReceivedInvitations(ctx context.Context, page models.PageRequest) (*models.Page[*models.Invitation], error) {
+	accessUserID, err := accessUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	invitations, err := u.calendarRepo.FindInvitationsByUserID(ctx, accessUserID, page)
+	if err != nil {
+		return nil, pageError(err)
+	}
+	now := time.Now()
+	for _, invitation := range invitations.Items {
+		invitation.ResolveStatus(now)
+	}
+	return invitations, nil
+}
+
+// AcceptInvitation は呼び出し元のユーザーへの招待を承諾し、招待された権限のメンバーになる
+func (u *calendarUsecase) AcceptInvitation(ctx context.Context, calendarID string) error {
+	accessUserID, err := accessUserIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	invitation, err := u.pendingInvitation(ctx, calendarID, accessUserID)
+	if err != nil {
+		return err
+	}
+	return u.calendarRepo.AcceptInvitation(ctx, invitation, time.Now().UTC())
+}
+
+// DeclineInvitation は呼び出し元のユーザーへの招待を辞退する
+func (u *calendarUsecase) DeclineInvitation(ctx context.Context, calendarID string) error {
+	accessUserID, err := accessUserIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	invitation, err := u.pendingInvitation(ctx, calendarID, accessUserID)
+	if err != nil {
+		return err
+	}
+	return u.calendarRepo.RespondInvitation(ctx, invitation.CalendarID, invitation.UserID, models.InvitationStatusDeclined, time.Now().UTC())
+}
+
+// pendingInvitation は回答待ちの招待を返す。存在しない場合は ErrNotFound を、回答済み・期限切れの場合は ErrConflict を返す
+func (u *calendarUsecase) pendingInvitation(ctx context.Context, calendarID string, userID string) (*models.Invitation, error) {
+	invitation, err := u.calendarRepo.FindInvitation(ctx, calendarID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil {
+		return nil, notFoundf("invitation for user %s", userID)
+	}
+	invitation.ResolveStatus(time.Now())
+	if invitation.Status != models.InvitationStatusPending {
+		return nil, conflictf("invitation is already %s", invitation.Status)
+	}
+	return invitation, nil
+}
@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"bonded/internal/models"
+	"context"
+	"fmt"
+)
+
+// ChangeMemberAccessLevel はメンバーの権限を EDITOR・VIEWER の間で変更する。オーナーだけが実行できる。
+// オーナーの権限はオーナーの移譲でだけ変更できる
+func (u *calendarUsecase) ChangeMemberAccessLevel(ctx context.Context, calendarID string, userID string, accessLevel string) error {
+	if accessLevel != AccessLevelEditor && accessLevel != AccessLevelViewer {
+		return invalidFieldf("accessLevel", "access level must be either EDITOR or VIEWER")
+	}
+	member, err := u.managedMember(ctx, calendarID, userID)
+	if err != nil {
+		return err
+	}
+	if member.AccessLevel == accessLevel {
+		return nil
+	}
+	return u.calendarRepo.UpdateMemberAccessLevel(ctx, calendarID, userID, accessLevel)
+}
+
+// RemoveMember はメンバーをカレンダーから外す。オーナーだけが実行でき、オーナー自身は外せない
+func (u *calendarUsecase) RemoveMember(ctx context.Context, calendarID string, userID string) error {
+	_, err := u.managedMember(ctx, calendarID, userID)
+	if err != nil {
+		return err
+	}
+	return u.calendarRepo.RemoveMember(ctx, calendarID, userID)
+}
+
+// TransferOwnership はカレンダーのオーナーを他のメンバーに移す。今のオーナーは EDITOR になる。
+// version は呼び出し元が読み込んだカレンダーの版数
+func (u *calendarUsecase) TransferOwnership(ctx context.Context, calendarID string, newOwnerID string, version int64) (*models.Calendar, error) {
+	newOwner, err := u.managedMember(ctx, calendarID, newOwnerID)
+	if err != nil {
+		return nil, err
+	}
+	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	if calendar.Version != version {
+		return nil, fmt.Errorf("%w: calendar %s has been modified", ErrPreconditionFailed, calendarID)
+	}
+	return u.calendarRepo.TransferOwnership(ctx, calendar, newOwner, version)
+}
+
+// managedMember は呼び出し元がオーナーであることを確認し、操作の対象になるオーナー以外のメンバーを返す
+func (u *calendarUsecase) managedMember(ctx context.Context, calendarID string, userID string) (*models.User, error) {
+	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = u.authorizer.authorize(ctx, calendar, PermissionManageMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := u.calendarRepo.FindMember(ctx, calendarID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, notFoundf("user %s is not a member of this calendar", userID)
+	}
+	if member.AccessLevel == AccessLevelOwner {
+		return nil, invalidf("the owner's access level can only be changed by transferring ownership")
+	}
+	return member, nil
+}
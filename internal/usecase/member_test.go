@@ -0,0 +1,210 @@
+package usecase_test
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/usecase"
+	"testing"
+)
+
+func TestInviteAndAcceptInvitation(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+	alice, bob := signedIn("alice"), signedIn("bob")
+
+	_, err := u.Calendar().InviteUser(alice, calendar.CalendarID, "bob", usecase.AccessLevelEditor)
+	assertErrorIs(t, err, usecase.ErrNotFound)
+
+	signUp(t, u, "bob")
+	invitation, err := u.Calendar().InviteUser(alice, calendar.CalendarID, "bob", usecase.AccessLevelEditor)
+	if err != nil {
+		t.Fatalf("InviteUser: %v", err)
+	}
+	if invitation.Status != models.InvitationStatusPending || invitation.DisplayName != "bob name" {
+		t.Errorf("invitation = %+v", invitation)
+	}
+	_, err = u.Calendar().InviteUser(alice, calendar.CalendarID, "bob", usecase.AccessLevelViewer)
+	assertErrorIs(t, err, usecase.ErrConflict)
+
+	received, err := u.Calendar().FindReceivedInvitations(bob, models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("FindReceivedInvitations: %v", err)
+	}
+	if len(received.Items) != 1 || received.Items[0].CalendarID != calendar.CalendarID {
+		t.Fatalf("received invitations = %+v", received.Items)
+	}
+
+	err = u.Calendar().AcceptInvitation(bob, calendar.CalendarID)
+	if err != nil {
+		t.Fatalf("AcceptInvitation: %v", err)
+	}
+	assertErrorIs(t, u.Calendar().AcceptInvitation(bob, calendar.CalendarID), usecase.ErrConflict)
+
+	calendar = findCalendar(t, u, "bob", calendar.CalendarID)
+	if editor := member(calendar, "bob"); editor == nil || editor.AccessLevel != usecase.AccessLevelEditor {
+		t.Errorf("bob's membership = %+v", editor)
+	}
+	if calendar.FollowerCount != 1 {
+		t.Errorf("followerCount = %d, want 1", calendar.FollowerCount)
+	}
+	invitations, err := u.Calendar().FindInvitations(alice, calendar.CalendarID)
+	if err != nil {
+		t.Fatalf("FindInvitations: %v", err)
+	}
+	if len(invitations) != 1 || invitations[0].Status != models.InvitationStatusAccepted || invitations[0].RespondedAt == nil {
+		t.Errorf("invitations = %+v", invitations)
+	}
+
+	// メンバーになったユーザーはもう招待できず、オーナー以外は招待できない
+	_, err = u.Calendar().InviteUser(alice, calendar.CalendarID, "bob", usecase.AccessLevelViewer)
+	assertErrorIs(t, err, usecase.ErrConflict)
+	signUp(t, u, "carol")
+	_, err = u.Calendar().InviteUser(bob, calendar.CalendarID, "carol", usecase.AccessLevelViewer)
+	assertErrorIs(t, err, usecase.ErrForbidden)
+}
+
+func TestDeclineAndRevokeInvitation(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+	alice := signedIn("alice")
+	signUp(t, u, "bob")
+	signUp(t, u, "carol")
+
+	for _, userID := range []string{"bob", "carol"} {
+		_, err := u.Calendar().InviteUser(alice, calendar.CalendarID, userID, usecase.AccessLevelViewer)
+		if err != nil {
+			t.Fatalf("InviteUser(%s): %v", userID, err)
+		}
+	}
+	err := u.Calendar().DeclineInvitation(signedIn("bob"), calendar.CalendarID)
+	if err != nil {
+		t.Fatalf("DeclineInvitation: %v", err)
+	}
+	err = u.Calendar().RevokeInvitation(alice, calendar.CalendarID, "carol")
+	if err != nil {
+		t.Fatalf("RevokeInvitation: %v", err)
+	}
+	assertErrorIs(t, u.Calendar().AcceptInvitation(signedIn("carol"), calendar.CalendarID), usecase.ErrConflict)
+	assertErrorIs(t, u.Calendar().AcceptInvitation(signedIn("dave"), calendar.CalendarID), usecase.ErrNotFound)
+
+	invitations, err := u.Calendar().FindInvitations(alice, calendar.CalendarID)
+	if err != nil {
+		t.Fatalf("FindInvitations: %v", err)
+	}
+	statuses := map[string]string{}
+	for _, invitation := range invitations {
+		statuses[invitation.UserID] = invitation.Status
+	}
+	if statuses["bob"] != models.InvitationStatusDeclined || statuses["carol"] != models.InvitationStatusRevoked {
+		t.Errorf("statuses = %v", statuses)
+	}
+
+	// 辞退した招待は新しい招待で置き換えられる
+	_, err = u.Calendar().InviteUser(alice, calendar.CalendarID, "bob", usecase.AccessLevelEditor)
+	if err != nil {
+		t.Fatalf("InviteUser after decline: %v", err)
+	}
+}
+
+func TestRedeemShareLink(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+
+	link, err := u.Calendar().CreateShareLink(signedIn("alice"), calendar.CalendarID, usecase.AccessLevelViewer, 1, 0)
+	if err != nil {
+		t.Fatalf("CreateShareLink: %v", err)
+	}
+	if link.Token == "" {
+		t.Fatal("the token is only returned when the link is created")
+	}
+	_, err = u.Calendar().CreateShareLink(signedIn("bob"), calendar.CalendarID, usecase.AccessLevelViewer, 1, 0)
+	assertErrorIs(t, err, usecase.ErrForbidden)
+
+	_, err = u.Calendar().RedeemShareLink(signedIn("bob"), calendar.CalendarID, "wrong")
+	assertErrorIs(t, err, usecase.ErrNotFound)
+	redeemed, err := u.Calendar().RedeemShareLink(signedIn("bob"), calendar.CalendarID, link.Token)
+	if err != nil {
+		t.Fatalf("RedeemShareLink: %v", err)
+	}
+	if redeemed.UseCount != 1 {
+		t.Errorf("useCount = %d, want 1", redeemed.UseCount)
+	}
+	calendar = findCalendar(t, u, "bob", calendar.CalendarID)
+	if viewer := member(calendar, "bob"); viewer == nil || viewer.AccessLevel != usecase.AccessLevelViewer || viewer.DisplayName != "bob name" {
+		t.Errorf("bob's membership = %+v", viewer)
+	}
+
+	// 利用回数の上限に達したリンクでは参加できない
+	_, err = u.Calendar().RedeemShareLink(signedIn("carol"), calendar.CalendarID, link.Token)
+	assertErrorIs(t, err, usecase.ErrConflict)
+}
+
+func TestManageMembers(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Public", true)
+	alice := signedIn("alice")
+	err := u.Calendar().FollowCalendar(signedIn("bob"), calendar)
+	if err != nil {
+		t.Fatalf("FollowCalendar: %v", err)
+	}
+
+	assertErrorIs(t, u.Calendar().ChangeMemberAccessLevel(signedIn("bob"), calendar.CalendarID, "bob", usecase.AccessLevelEditor), usecase.ErrForbidden)
+	assertErrorIs(t, u.Calendar().ChangeMemberAccessLevel(alice, calendar.CalendarID, "carol", usecase.AccessLevelEditor), usecase.ErrNotFound)
+	assertErrorIs(t, u.Calendar().ChangeMemberAccessLevel(alice, calendar.CalendarID, "alice", usecase.AccessLevelEditor), usecase.ErrInvalidInput)
+	err = u.Calendar().ChangeMemberAccessLevel(alice, calendar.CalendarID, "bob", usecase.AccessLevelEditor)
+	if err != nil {
+		t.Fatalf("ChangeMemberAccessLevel: %v", err)
+	}
+
+	_, err = u.Calendar().TransferOwnership(alice, calendar.CalendarID, "bob", calendar.Version+1)
+	assertErrorIs(t, err, usecase.ErrPreconditionFailed)
+	transferred, err := u.Calendar().TransferOwnership(alice, calendar.CalendarID, "bob", calendar.Version)
+	if err != nil {
+		t.Fatalf("TransferOwnership: %v", err)
+	}
+	if transferred.OwnerUserID != "bob" || transferred.Version != calendar.Version+1 {
+		t.Errorf("transferred = owner %s, version %d", transferred.OwnerUserID, transferred.Version)
+	}
+	calendar = findCalendar(t, u, "alice", calendar.CalendarID)
+	if m := member(calendar, "alice"); m == nil || m.AccessLevel != usecase.AccessLevelEditor {
+		t.Errorf("alice's membership = %+v", m)
+	}
+	if m := member(calendar, "bob"); m == nil || m.AccessLevel != usecase.AccessLevelOwner {
+		t.Errorf("bob's membership = %+v", m)
+	}
+
+	// 新しいオーナーは前のオーナーを外せる
+	err = u.Calendar().RemoveMember(signedIn("bob"), calendar.CalendarID, "alice")
+	if err != nil {
+		t.Fatalf("RemoveMember: %v", err)
+	}
+	calendar = findCalendar(t, u, "bob", calendar.CalendarID)
+	if member(calendar, "alice") != nil || calendar.FollowerCount != 0 {
+		t.Errorf("after removal: users = %+v, followerCount = %d", calendar.Users, calendar.FollowerCount)
+	}
+}
+
+func TestEditMeSyncsDisplayName(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+	name := "Alice Liddell"
+
+	profile, err := u.User().EditMe(signedIn("alice"), &models.EditProfile{DisplayName: &name})
+	if err != nil {
+		t.Fatalf("EditMe: %v", err)
+	}
+	if profile.DisplayName != name {
+		t.Errorf("displayName = %q, want %q", profile.DisplayName, name)
+	}
+	calendar = findCalendar(t, u, "alice", calendar.CalendarID)
+	if calendar.OwnerName != name || member(calendar, "alice").DisplayName != name {
+		t.Errorf("ownerName = %q, membership = %+v", calendar.OwnerName, calendar.Users)
+	}
+
+	results, err := u.User().SearchUsers(signedIn("bob"), "alice l", models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("SearchUsers: %v", err)
+	}
+	if len(results.Items) != 1 || results.Items[0].UserID != "alice" {
+		t.Errorf("search results = %+v", results.Items)
+	}
+}
@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/repository"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// pageError はリポジトリが解釈できなかったカーソルを入力エラーとして返す
+func pageError(err error) error {
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return invalidf("%s", err.Error())
+	}
+	return err
+}
+
+// offsetCursor は期間を指定した一覧のように、メモリ上で並べた結果をページに分けるためのカーソル
+type offsetCursor struct {
+	Offset int `json:"o"`
+}
+
+// slicePage は並べ替え済みの items から page の範囲を切り出す
+func slicePage[T any](items []T, page models.PageRequest) (*models.Page[T], error) {
+	offset := 0
+	if page.Cursor != "" {
+		data, err := base64.RawURLEncoding.DecodeString(page.Cursor)
+		var cursor offsetCursor
+		if err == nil {
+			err = json.Unmarshal(data, &cursor)
+		}
+		if err != nil || cursor.Offset < 0 {
+			return nil, invalidFieldf("cursor", "%s", repository.ErrInvalidCursor.Error())
+		}
+		offset = cursor.Offset
+	}
+
+	result := &models.Page[T]{Items: []T{}}
+	if offset >= len(items) {
+		return result, nil
+	}
+	end := min(offset+page.Limit, len(items))
+	result.Items = items[offset:end]
+	if end < len(items) {
+		data, err := json.Marshal(offsetCursor{Offset: end})
+		if err != nil {
+			return nil, err
+		}
+		result.NextCursor = base64.RawURLEncoding.EncodeToString(data)
+	}
+	return result, nil
+}
@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"bonded/internal/contextKey"
+	"bonded/internal/models"
+	"bonded/internal/repository"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+type profiles struct {
+	userRepo repository.UserRepository
+}
+
+// current は呼び出し元のプロフィールを返す。初めてのサインインでまだない場合は Cognito のクレームから作成する。
+// ID トークンの確認済みのメールアドレスがプロフィールと違う場合は、メールアドレスで検索できるようにプロフィールに反映する
+func (p *profiles) current(ctx context.Context) (*models.Profile, error) {
+	accessUserID, err := accessUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims := claimsFromContext(ctx)
+	profile, err := p.userRepo.FindProfile(ctx, accessUserID)
+	if err != nil {
+		return nil, err
+	}
+	if profile != nil {
+		return p.syncEmail(ctx, profile, verifiedEmail(claims))
+	}
+
+	profile = profileFromClaims(accessUserID, claims, time.Now().UTC())
+	// プロフィール導入前から使っているユーザーはメンバーシップの表示名を引き継ぐ
+	user, err := p.userRepo.FindByUserID(ctx, accessUserID)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		profile.DisplayName = user.DisplayName
+	}
+
+	err = p.userRepo.CreateProfile(ctx, profile)
+	if errors.Is(err, ErrConflict) {
+		// 同時に届いた別のリクエストが先に作成した
+		return p.userRepo.FindProfile(ctx, accessUserID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// syncEmail はプロフィールのメールアドレスを email に合わせる。email が空の場合は何もしない
+func (p *profiles) syncEmail(ctx context.Context, profile *models.Profile, email string) (*models.Profile, error) {
+	if email == "" || email == profile.Email {
+		return profile, nil
+	}
+	profile.Email = email
+	profile.UpdatedAt = time.Now().UTC()
+	err := p.userRepo.UpdateProfile(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// profileFromClaims は Cognito のトークンのクレームから初期のプロフィールを組み立てる。
+// アクセストークンだけの場合は name・email などが含まれないため、ユーザー名やユーザーIDで補う
+func profileFromClaims(userID string, claims jwt.MapClaims, now time.Time) *models.Profile {
+	email := verifiedEmail(claims)
+	displayName := firstNonEmpty(
+		stringClaim(claims, "name"),
+		stringClaim(claims, "preferred_username"),
+		stringClaim(claims, "cognito:username"),
+		stringClaim(claims, "username"),
+		strings.Split(email, "@")[0],
+		userID,
+	)
+
+	timeZone, err := validTimeZone(stringClaim(claims, "zoneinfo"))
+	if err != nil {
+		timeZone = models.DefaultTimeZone
+	}
+	locale := stringClaim(claims, "locale")
+	if !validLocale(locale) {
+		locale = models.DefaultLocale
+	}
+	avatarURL := stringClaim(claims, "picture")
+	if !validAvatarURL(avatarURL) {
+		avatarURL = ""
+	}
+
+	return &models.Profile{
+		UserID:      userID,
+		DisplayName: truncateDisplayName(displayName),
+		Email:       email,
+		TimeZone:    timeZone,
+		Locale:      locale,
+		AvatarURL:   avatarURL,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// claimsFromContext は検証済みのトークンのクレームを返す。ID トークンがあれば、そのクレーム（name・email など）で補う
+func claimsFromContext(ctx context.Context) jwt.MapClaims {
+	claims := jwt.MapClaims{}
+	for _, key := range []interface{}{contextKey.JwtDataKey, contextKey.IDTokenKey} {
+		token, ok := ctx.Value(key).(*jwt.Token)
+		if !ok {
+			continue
+		}
+		tokenClaims, _ := token.Claims.(jwt.MapClaims)
+		for name, value := range tokenClaims {
+			claims[name] = value
+		}
+	}
+	return claims
+}
+
+// verifiedEmail は Cognito が確認済みのメールアドレスを返す。確認されていない場合は、他人のメールアドレスで招待を受けられないよう空にする
+func verifiedEmail(claims jwt.MapClaims) string {
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		if !verified {
+			return ""
+		}
+	case string:
+		if verified != "true" {
+			return ""
+		}
+	default:
+		return ""
+	}
+	return stringClaim(claims, "email")
+}
+
+func stringClaim(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return strings.TrimSpace(value)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
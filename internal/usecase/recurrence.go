@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/recurrence"
+	"sort"
+	"time"
+)
+
+func parseEventTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(models.DateLayout, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, invalidf("invalid time %q", value)
+}
+
+func formatEventTime(t time.Time, allDay bool) string {
+	if allDay {
+		return t.Format(models.DateLayout)
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func isRecurring(event *models.Event) bool {
+	return event.RRule != "" || len(event.RDates) > 0
+}
+
+// recurrenceSet はイベントの繰り返し情報から発生日時の集合と1回あたりの長さを組み立てる
+func recurrenceSet(event *models.Event) (*recurrence.Set, time.Duration, error) {
+	// 繰り返しはイベントのタイムゾーンの壁時計で展開する（夏時間の切り替わりをまたいでも同じ時刻になる）
+	loc := eventLocation(event)
+	start := event.StartTime.At(loc)
+	end := event.EndTime.At(loc)
+
+	var err error
+	set := &recurrence.Set{Start: start}
+	if event.RRule != "" {
+		set.Rule, err = recurrence.Parse(event.RRule)
+		if err != nil {
+			return nil, 0, invalidf("%s", err.Error())
+		}
+	}
+	for _, value := range event.RDates {
+		t, err := parseEventTime(value)
+		if err != nil {
+			return nil, 0, err
+		}
+		set.RDates = append(set.RDates, t)
+	}
+	for _, value := range event.ExDates {
+		t, err := parseEventTime(value)
+		if err != nil {
+			return nil, 0, err
+		}
+		set.ExDates = append(set.ExDates, t)
+	}
+	return set, end.Sub(start), nil
+}
+
+// validateRecurrence は繰り返し情報が解析可能かを確認し、RRULE と RDATE/EXDATE を正規化する
+func validateRecurrence(event *models.Event) error {
+	if !isRecurring(event) && len(event.ExDates) == 0 {
+		return nil
+	}
+	set, _, err := recurrenceSet(event)
+	if err != nil {
+		return err
+	}
+	if set.Rule != nil {
+		// "RRULE:" 接頭辞や小文字の指定を除いた形で保存する（書き出し時に "RRULE:" が重複しないように）
+		event.RRule = set.Rule.String()
+	}
+	event.RDates = formatTimes(set.RDates, event.AllDay)
+	event.ExDates = formatTimes(set.ExDates, event.AllDay)
+	return nil
+}
+
+func formatTimes(times []time.Time, allDay bool) []string {
+	if len(times) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(times))
+	for _, t := range times {
+		values = append(values, formatEventTime(t, allDay))
+	}
+	return values
+}
+
+func overlaps(start time.Time, duration time.Duration, window models.TimeRange) bool {
+	if duration <= 0 {
+		return !start.Before(window.From) && start.Before(window.To)
+	}
+	return start.Before(window.To) && start.Add(duration).After(window.From)
+}
+
+// expandEvents は繰り返しイベントを期間内の発生に展開し、期間と重なるイベントだけを開始時間順に返す。
+// 個別に編集された発生（オーバーライド）は展開結果の該当する発生を置き換える。
+func expandEvents(events []*models.Event, window models.TimeRange) ([]*models.Event, error) {
+	overrides := map[string]map[string]*models.Event{}
+	var masters []*models.Event
+	for _, event := range events {
+		if event.RecurringEventID == "" {
+			masters = append(masters, event)
+			continue
+		}
+		if overrides[event.RecurringEventID] == nil {
+			overrides[event.RecurringEventID] = map[string]*models.Event{}
+		}
+		overrides[event.RecurringEventID][event.RecurrenceID] = event
+	}
+
+	var result []*models.Event
+	for _, master := range masters {
+		set, duration, err := recurrenceSet(master)
+		if err != nil {
+			return nil, err
+		}
+
+		window := eventWindow(master, window)
+		if !isRecurring(master) {
+			if overlaps(set.Start, duration, window) {
+				result = append(result, master)
+			}
+			continue
+		}
+
+		for _, occurrence := range set.Between(window.From.Add(-duration), window.To) {
+			if !overlaps(occurrence, duration, window) {
+				continue
+			}
+			recurrenceID := formatEventTime(occurrence, master.AllDay)
+			if _, ok := overrides[master.EventID][recurrenceID]; ok {
+				continue
+			}
+			result = append(result, occurrenceOf(master, occurrence, duration, recurrenceID))
+		}
+
+		for _, override := range overrides[master.EventID] {
+			// 個別の発生の編集には繰り返し元の版数を使い、参加者も繰り返し元のものを示す
+			override.Version = master.Version
+			override.Attendees = master.Attendees
+			loc := eventLocation(override)
+			start := override.StartTime.At(loc)
+			end := override.EndTime.At(loc)
+			if overlaps(start, end.Sub(start), eventWindow(override, window)) {
+				result = append(result, override)
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return eventStart(result[i]).Before(eventStart(result[j]))
+	})
+	return result, nil
+}
+
+func occurrenceOf(master *models.Event, start time.Time, duration time.Duration, recurrenceID string) *models.Event {
+	occurrence := *master
+	occurrence.StartTime = models.DateTime{Time: start, DateOnly: master.AllDay}
+	occurrence.EndTime = models.DateTime{Time: start.Add(duration), DateOnly: master.AllDay}
+	occurrence.RRule = ""
+	occurrence.RDates = nil
+	occurrence.ExDates = nil
+	occurrence.RecurringEventID = master.EventID
+	occurrence.RecurrenceID = recurrenceID
+	return &occurrence
+}
+
+// truncateBefore は繰り返しを recurrenceID の直前で終了させ、以降の発生に使う RRULE を返す
+func truncateBefore(master *models.Event, set *recurrence.Set, recurrenceID time.Time) string {
+	master.RDates = filterTimes(master.RDates, func(t time.Time) bool { return t.Before(recurrenceID) })
+	master.ExDates = filterTimes(master.ExDates, func(t time.Time) bool { return t.Before(recurrenceID) })
+	if set.Rule == nil {
+		return ""
+	}
+
+	following := *set.Rule
+	truncated := *set.Rule
+	if set.Rule.Count > 0 {
+		before := set.CountBefore(recurrenceID)
+		truncated.Count = before
+		following.Count = set.Rule.Count - before
+	} else if master.AllDay {
+		truncated.Until = recurrenceID.AddDate(0, 0, -1)
+		truncated.UntilDate = true
+	} else {
+		truncated.Until = recurrenceID.Add(-time.Second).UTC()
+		truncated.UntilDate = false
+	}
+
+	master.RRule = truncated.String()
+	return following.String()
+}
+
+func filterTimes(values []string, keep func(time.Time) bool) []string {
+	var result []string
+	for _, value := range values {
+		t, err := parseEventTime(value)
+		if err != nil || keep(t) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"bonded/internal/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateShareLink はカレンダーに参加するための共有リンクを発行する。オーナーだけが発行できる。
+// validDays が 0 の場合は ShareLinkDefaultValidDays 日有効にする
+func (u *calendarUsecase) CreateShareLink(ctx context.Context, calendarID string, accessLevel string, maxUses int, validDays int) (*models.ShareLink, error) {
+	if accessLevel != AccessLevelEditor && accessLevel != AccessLevelViewer {
+		return nil, invalidFieldf("accessLevel", "access level must be either EDITOR or VIEWER")
+	}
+	if maxUses < 1 || maxUses > models.ShareLinkMaxUses {
+		return nil, invalidFieldf("maxUses", "maxUses must be between 1 and %d", models.ShareLinkMaxUses)
+	}
+	if validDays == 0 {
+		validDays = models.ShareLinkDefaultValidDays
+	}
+	if validDays < 1 || validDays > models.ShareLinkMaxValidDays {
+		return nil, invalidFieldf("validDays", "validDays must be between 1 and %d", models.ShareLinkMaxValidDays)
+	}
+
+	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := u.authorizer.authorize(ctx, calendar, PermissionManageMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := newSecretToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	link := &models.ShareLink{
+		LinkID:      uuid.New().String(),
+		CalendarID:  calendar.CalendarID,
+		AccessLevel: accessLevel,
+		CreatedBy:   owner.UserID,
+		TokenHash:   hashToken(secret),
+		CreatedAt:   now,
+		ValidUntil:  now.AddDate(0, 0, validDays),
+		MaxUses:     maxUses,
+	}
+	err = u.calendarRepo.CreateShareLink(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+
+	link.Token = secret
+	return link, nil
+}
+
+// FindShareLinks はカレンダーの使える共有リンクを返す（トークン本体は含まない）。オーナーだけが参照できる
+func (u *calendarUsecase) FindShareLinks(ctx context.Context, calendarID string) ([]*models.ShareLink, error) {
+	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = u.authorizer.authorize(ctx, calendar, PermissionManageMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := u.calendarRepo.FindShareLinks(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	active := make([]*models.ShareLink, 0, len(links))
+	for _, link := range links {
+		if link.Active(now) {
+			active = append(active, link)
+		}
+	}
+	return active, nil
+}
+
+// RevokeShareLink は共有リンクを取り消す。取り消したリンクではそれ以降参加できない
+func (u *calendarUsecase) RevokeShareLink(ctx context.Context, calendarID string, linkID string) error {
+	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	if err != nil {
+		return err
+	}
+	_, err = u.authorizer.authorize(ctx, calendar, PermissionManageMembers)
+	if err != nil {
+		return err
+	}
+
+	links, err := u.calendarRepo.FindShareLinks(ctx, calendarID)
+	if err != nil {
+		return err
+	}
+	for _, link := range links {
+		if link.LinkID == linkID {
+			return u.calendarRepo.DeleteShareLink(ctx, calendarID, link.TokenHash)
+		}
+	}
+	return notFoundf("share link %s", linkID)
+}
+
+// RedeemShareLink は共有リンクを使って呼び出し元のユーザーをリンクの権限のメンバーにする。
+// メンバーの表示名にはプロフィールの表示名を使う
+func (u *calendarUsecase) RedeemShareLink(ctx context.Context, calendarID string, secret string) (*models.ShareLink, error) {
+	if secret == "" {
+		return nil, invalidFieldf("token", "token is required")
+	}
+	accessUserID, err := accessUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := u.calendarRepo.FindShareLink(ctx, calendarID, hashToken(secret))
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, notFoundf("share link")
+	}
+	if !link.Active(time.Now()) {
+		return nil, conflictf("share link has expired or has reached its usage limit")
+	}
+
+	member, err := u.calendarRepo.FindMember(ctx, calendarID, accessUserID)
+	if err != nil {
+		return nil, err
+	}
+	if member != nil {
+		return nil, conflictf("user %s is already a member of this calendar", accessUserID)
+	}
+	profile, err := u.profiles.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = u.calendarRepo.RedeemShareLink(ctx, link, profile.User(), time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	link.UseCount++
+	return link, nil
+}
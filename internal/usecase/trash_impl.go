@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"bonded/internal/models"
+	"context"
+	"time"
+)
+
+// FindTrashedCalendars は呼び出し元がオーナーのゴミ箱のカレンダーを返す
+func (u *calendarUsecase) FindTrashedCalendars(ctx context.Context, page models.PageRequest) (*models.Page[*models.Calendar], error) {
+	accessUserID, err := accessUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	calendars, err := u.calendarRepo.FindTrashedCalendars(ctx, accessUserID, page)
+	if err != nil {
+		return nil, pageError(err)
+	}
+	return calendars, nil
+}
+
+// RestoreCalendar はゴミ箱のカレンダーを元に戻す
+func (u *calendarUsecase) RestoreCalendar(ctx context.Context, calendarID string) error {
+	calendar, err := u.trashedCalendar(ctx, calendarID)
+	if err != nil {
+		return err
+	}
+	return u.calendarRepo.Restore(ctx, calendar)
+}
+
+// PurgeCalendar はゴミ箱のカレンダーを保存期間を待たずに完全に削除する
+func (u *calendarUsecase) PurgeCalendar(ctx context.Context, calendarID string) (*models.DeletionReport, error) {
+	calendar, err := u.trashedCalendar(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	return u.calendarRepo.Purge(ctx, calendar.CalendarID)
+}
+
+// trashedCalendar はゴミ箱のカレンダーを取得し、呼び出し元がカレンダーを削除できるかを確認する
+func (u *calendarUsecase) trashedCalendar(ctx context.Context, calendarID string) (*models.Calendar, error) {
+	calendar, err := u.calendarRepo.FindTrashedCalendar(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = u.authorizer.authorize(ctx, calendar, PermissionDeleteCalendar)
+	if err != nil {
+		return nil, err
+	}
+	// TTL による削除は遅れることがあるため、保存期間を過ぎたものはすでに削除されたものとして扱う
+	if expired(calendar.ExpiresAt) {
+		return nil, notFoundf("calendar %s has expired from the trash", calendarID)
+	}
+	return calendar, nil
+}
+
+// FindTrashedEvents はカレンダーのゴミ箱にあるイベントを返す
+func (u *eventUsecase) FindTrashedEvents(ctx context.Context, calendarID string, page models.PageRequest) (*models.Page[*models.Event], error) {
+	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = u.authorizer.authorize(ctx, calendar, PermissionDeleteEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := u.eventRepo.FindTrashedEvents(ctx, calendarID, page)
+	if err != nil {
+		return nil, pageError(err)
+	}
+	for _, event := range events.Items {
+		localizeEvent(event)
+	}
+	return events, nil
+}
+
+// RestoreEvent はゴミ箱のイベントを繰り返しの個別の発生とともに元に戻す
+func (u *eventUsecase) RestoreEvent(ctx context.Context, calendarID string, eventID string) error {
+	calendar, err := u.calendarRepo.FindByCalendarID(ctx, calendarID)
+	if err != nil {
+		return err
+	}
+	_, err = u.authorizer.authorize(ctx, calendar, PermissionDeleteEvent)
+	if err != nil {
+		return err
+	}
+
+	event, err := u.eventRepo.FindTrashedEvent(ctx, calendarID, eventID)
+	if err != nil {
+		return err
+	}
+	if event == nil || expired(event.ExpiresAt) {
+		return notFoundf("event %s is not in the trash", eventID)
+	}
+	return u.eventRepo.RestoreEvent(ctx, calendarID, eventID)
+}
+
+func expired(expiresAt *time.Time) bool {
+	return expiresAt != nil && !expiresAt.After(time.Now())
+}
@@ -0,0 +1,85 @@
+package usecase_test
+
+import (
+	"bonded/internal/contextKey"
+	"bonded/internal/models"
+	"bonded/internal/repository/memory"
+	"bonded/internal/usecase"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// newUsecase はメモリ上のリポジトリでユースケースを組み立てる
+func newUsecase() usecase.Usecase {
+	store := memory.NewStore()
+	return usecase.CalendarUsecaseRequest(
+		memory.CalendarRepositoryRequest(store),
+		memory.EventRepositoryRequest(store),
+		memory.UserRepositoryRequest(store),
+	)
+}
+
+// signedIn は認証ミドルウェアが検証したトークンを持つコンテキストを返す
+func signedIn(userID string) context.Context {
+	token := &jwt.Token{Claims: jwt.MapClaims{"sub": userID, "name": userID + " name"}}
+	return context.WithValue(context.Background(), contextKey.JwtDataKey, token)
+}
+
+// withIDToken は ID トークンのクレーム（email・email_verified）を ctx に加える
+func withIDToken(ctx context.Context, userID string, email string, verified bool) context.Context {
+	token := &jwt.Token{Claims: jwt.MapClaims{"sub": userID, "token_use": "id", "email": email, "email_verified": verified}}
+	return context.WithValue(ctx, contextKey.IDTokenKey, token)
+}
+
+// createCalendar は userID をオーナーとするカレンダーを作成し、作成したカレンダーを返す
+func createCalendar(t *testing.T, u usecase.Usecase, userID string, name string, public bool) *models.Calendar {
+	t.Helper()
+	ctx := signedIn(userID)
+	input := &models.CreateCalendar{Name: name, IsPublic: &public, TimeZone: "Asia/Tokyo"}
+	err := u.Calendar().CreateCalendar(ctx, input)
+	if err != nil {
+		t.Fatalf("CreateCalendar: %v", err)
+	}
+	calendar, err := u.Calendar().FindCalendar(ctx, input.CalendarID)
+	if err != nil {
+		t.Fatalf("FindCalendar: %v", err)
+	}
+	return calendar
+}
+
+// signUp はユーザーのプロフィールを作成する（初めてのサインインに当たる）
+func signUp(t *testing.T, u usecase.Usecase, userID string) {
+	t.Helper()
+	_, err := u.User().FindMe(signedIn(userID))
+	if err != nil {
+		t.Fatalf("FindMe: %v", err)
+	}
+}
+
+func findCalendar(t *testing.T, u usecase.Usecase, userID string, calendarID string) *models.Calendar {
+	t.Helper()
+	calendar, err := u.Calendar().FindCalendar(signedIn(userID), calendarID)
+	if err != nil {
+		t.Fatalf("FindCalendar: %v", err)
+	}
+	return calendar
+}
+
+func member(calendar *models.Calendar, userID string) *models.User {
+	for i := range calendar.Users {
+		if calendar.Users[i].UserID == userID {
+			return &calendar.Users[i]
+		}
+	}
+	return nil
+}
+
+func assertErrorIs(t *testing.T, err error, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("error = %v, want %v", err, target)
+	}
+}
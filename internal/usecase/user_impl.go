@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"bonded/internal/models"
+	"context"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// minNamePrefixLength は表示名で検索する場合の最小文字数（ユーザーの一覧を取得できないようにする）
+const minNamePrefixLength = 2
+
+// FindMe は呼び出し元のプロフィールを返す。初めてのサインインの場合は作成する
+func (u *userUsecase) FindMe(ctx context.Context) (*models.Profile, error) {
+	return u.profiles.current(ctx)
+}
+
+// EditMe は呼び出し元のプロフィールを変更する。表示名を変えた場合はメンバーシップの表示名も合わせる
+func (u *userUsecase) EditMe(ctx context.Context, input *models.EditProfile) (*models.Profile, error) {
+	profile, err := u.profiles.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	previousName := profile.DisplayName
+
+	if input.DisplayName != nil {
+		displayName := strings.TrimSpace(*input.DisplayName)
+		if displayName == "" || utf8.RuneCountInString(displayName) > models.MaxDisplayNameLength {
+			return nil, invalidFieldf("displayName", "displayName must be 1 to %d characters", models.MaxDisplayNameLength)
+		}
+		profile.DisplayName = displayName
+	}
+	if input.TimeZone != nil {
+		if *input.TimeZone == "" {
+			return nil, invalidFieldf("timeZone", "timeZone must not be empty")
+		}
+		profile.TimeZone, err = validTimeZone(*input.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if input.Locale != nil {
+		if !validLocale(*input.Locale) {
+			return nil, invalidFieldf("locale", "locale must be a BCP 47 language tag")
+		}
+		profile.Locale = *input.Locale
+	}
+	if input.AvatarURL != nil {
+		if *input.AvatarURL != "" && !validAvatarURL(*input.AvatarURL) {
+			return nil, invalidFieldf("avatarUrl", "avatarUrl must be an https URL")
+		}
+		profile.AvatarURL = *input.AvatarURL
+	}
+	profile.UpdatedAt = time.Now().UTC()
+
+	err = u.userRepo.UpdateProfile(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	if profile.DisplayName != previousName {
+		err = u.userRepo.SyncDisplayName(ctx, profile.UserID, profile.DisplayName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return profile, nil
+}
+
+// FindUserByEmail はメールアドレスが完全に一致するユーザーを返す。見つからない場合は ErrNotFound を返す
+func (u *userUsecase) FindUserByEmail(ctx context.Context, email string) (*models.UserSummary, error) {
+	_, err := accessUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	address, err := parseEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := u.userRepo.FindProfileByEmail(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, notFoundf("user with email %s", address)
+	}
+	return userSummary(profile), nil
+}
+
+// parseEmail は名前を含まないメールアドレスだけを受け付け、アドレスの部分を返す
+func parseEmail(email string) (string, error) {
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Name != "" {
+		return "", invalidFieldf("email", "invalid email %q", email)
+	}
+	return address.Address, nil
+}
+
+// SearchUsers は表示名が prefix で始まるユーザーを表示名順に返す
+func (u *userUsecase) SearchUsers(ctx context.Context, prefix string, page models.PageRequest) (*models.Page[*models.UserSummary], error) {
+	_, err := accessUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prefix = strings.TrimSpace(prefix)
+	if utf8.RuneCountInString(prefix) < minNamePrefixLength {
+		return nil, invalidFieldf("name", "name must be at least %d characters", minNamePrefixLength)
+	}
+
+	profiles, err := u.userRepo.FindProfilesByNamePrefix(ctx, prefix, page)
+	if err != nil {
+		return nil, pageError(err)
+	}
+	users := make([]*models.UserSummary, 0, len(profiles.Items))
+	for _, profile := range profiles.Items {
+		users = append(users, userSummary(profile))
+	}
+	return &models.Page[*models.UserSummary]{Items: users, NextCursor: profiles.NextCursor}, nil
+}
+
+// userSummary は検索結果として公開する項目だけを取り出す
+func userSummary(profile *models.Profile) *models.UserSummary {
+	return &models.UserSummary{UserID: profile.UserID, DisplayName: profile.DisplayName}
+}
+
+func validLocale(locale string) bool {
+	return models.LocalePattern.MatchString(locale)
+}
+
+func validAvatarURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && parsed.Scheme == "https" && parsed.Host != ""
+}
+
+// truncateDisplayName はクレームから作った表示名を最大文字数に収める
+func truncateDisplayName(displayName string) string {
+	runes := []rune(displayName)
+	if len(runes) > models.MaxDisplayNameLength {
+		return string(runes[:models.MaxDisplayNameLength])
+	}
+	return displayName
+}
@@ -0,0 +1,106 @@
+package usecase_test
+
+import (
+	"bonded/internal/models"
+	"bonded/internal/usecase"
+	"context"
+	"testing"
+)
+
+func TestProfileEmailComesFromVerifiedIDToken(t *testing.T) {
+	u := newUsecase()
+
+	profile, err := u.User().FindMe(withIDToken(signedIn("carol"), "carol", "Carol@Example.com", true))
+	if err != nil {
+		t.Fatalf("FindMe: %v", err)
+	}
+	if profile.Email != "Carol@Example.com" {
+		t.Errorf("email = %q, want the ID token's email", profile.Email)
+	}
+	found, err := u.User().FindUserByEmail(signedIn("alice"), "carol@example.com")
+	if err != nil {
+		t.Fatalf("FindUserByEmail: %v", err)
+	}
+	if found.UserID != "carol" {
+		t.Errorf("found = %+v", found)
+	}
+
+	// 確認されていないメールアドレスでは検索できない
+	profile, err = u.User().FindMe(withIDToken(signedIn("dave"), "dave", "dave@example.com", false))
+	if err != nil {
+		t.Fatalf("FindMe: %v", err)
+	}
+	if profile.Email != "" {
+		t.Errorf("email = %q, want none for an unverified address", profile.Email)
+	}
+	_, err = u.User().FindUserByEmail(signedIn("alice"), "dave@example.com")
+	assertErrorIs(t, err, usecase.ErrNotFound)
+
+	// アクセストークンだけで作られたプロフィールにも、ID トークンが届いたときにメールアドレスを設定する
+	signUp(t, u, "erin")
+	profile, err = u.User().FindMe(withIDToken(signedIn("erin"), "erin", "erin@example.com", true))
+	if err != nil {
+		t.Fatalf("FindMe: %v", err)
+	}
+	if profile.Email != "erin@example.com" {
+		t.Errorf("email = %q, want it to be filled in later", profile.Email)
+	}
+}
+
+func TestInviteUserByEmail(t *testing.T) {
+	u := newUsecase()
+	calendar := createCalendar(t, u, "alice", "Team", false)
+	_, err := u.User().FindMe(withIDToken(signedIn("carol"), "carol", "carol@example.com", true))
+	if err != nil {
+		t.Fatalf("FindMe: %v", err)
+	}
+
+	// オーナー以外には、メールアドレスが登録されているかどうかに関わらず同じエラーを返す
+	for _, email := range []string{"carol@example.com", "nobody@example.com"} {
+		_, err = u.Calendar().InviteUserByEmail(signedIn("bob"), calendar.CalendarID, email, usecase.AccessLevelViewer)
+		assertErrorIs(t, err, usecase.ErrForbidden)
+	}
+
+	_, err = u.Calendar().InviteUserByEmail(signedIn("alice"), calendar.CalendarID, "nobody@example.com", usecase.AccessLevelViewer)
+	assertErrorIs(t, err, usecase.ErrNotFound)
+	_, err = u.Calendar().InviteUserByEmail(signedIn("alice"), calendar.CalendarID, "Carol <carol@example.com>", usecase.AccessLevelViewer)
+	assertErrorIs(t, err, usecase.ErrInvalidInput)
+
+	invitation, err := u.Calendar().InviteUserByEmail(signedIn("alice"), calendar.CalendarID, "CAROL@example.com", usecase.AccessLevelEditor)
+	if err != nil {
+		t.Fatalf("InviteUserByEmail: %v", err)
+	}
+	if invitation.UserID != "carol" || invitation.Status != models.InvitationStatusPending {
+		t.Errorf("invitation = %+v", invitation)
+	}
+}
+
+func TestSearchUsers(t *testing.T) {
+	u := newUsecase()
+	for _, userID := range []string{"alice", "albert", "alex", "bob"} {
+		signUp(t, u, userID)
+	}
+	bob := signedIn("bob")
+
+	first, err := u.User().SearchUsers(bob, " AL ", models.PageRequest{Limit: 2})
+	if err != nil {
+		t.Fatalf("SearchUsers: %v", err)
+	}
+	if len(first.Items) != 2 || first.Items[0].UserID != "albert" || first.Items[1].UserID != "alex" || first.NextCursor == "" {
+		t.Fatalf("first page = %+v, cursor %q", first.Items, first.NextCursor)
+	}
+	second, err := u.User().SearchUsers(bob, "al", models.PageRequest{Limit: 2, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatalf("SearchUsers: %v", err)
+	}
+	if len(second.Items) != 1 || second.Items[0].UserID != "alice" || second.Items[0].DisplayName != "alice name" || second.NextCursor != "" {
+		t.Errorf("second page = %+v, cursor %q", second.Items, second.NextCursor)
+	}
+
+	_, err = u.User().SearchUsers(bob, "a", models.PageRequest{Limit: 2})
+	assertErrorIs(t, err, usecase.ErrInvalidInput)
+	_, err = u.User().SearchUsers(bob, "al", models.PageRequest{Limit: 2, Cursor: "not a cursor"})
+	assertErrorIs(t, err, usecase.ErrInvalidInput)
+	_, err = u.User().SearchUsers(context.Background(), "al", models.PageRequest{Limit: 2})
+	assertErrorIs(t, err, usecase.ErrForbidden)
+}
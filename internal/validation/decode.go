@@ -0,0 +1,129 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrMalformed は本文を JSON として読み取れない場合に返される
+var ErrMalformed = errors.New("malformed request body")
+
+// Decode は JSON の本文を v に読み込み、v が Validator であれば検証する。
+// モデルにないキーや型の違う値はフィールドごとの Errors として返す
+func Decode(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	if err != nil {
+		return decodeError(data, v, err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("%w: unexpected data after the JSON value", ErrMalformed)
+	}
+
+	if validator, ok := v.(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+func decodeError(data []byte, v interface{}, err error) error {
+	var syntaxError *json.SyntaxError
+	if errors.As(err, &syntaxError) {
+		return fmt.Errorf("%w: %s", ErrMalformed, err.Error())
+	}
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: request body is required", ErrMalformed)
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: unexpected end of JSON input", ErrMalformed)
+	}
+	// DisallowUnknownFields のエラーは型を持たないため、メッセージからキー名を取り出す
+	if name, ok := strings.CutPrefix(err.Error(), `json: unknown field "`); ok {
+		return Errors{{Field: strings.TrimSuffix(name, `"`), Message: "is not a known field"}}
+	}
+
+	message := err.Error()
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		message = "must be " + describeType(typeError.Type)
+		if typeError.Field != "" {
+			return Errors{{Field: fieldPath(typeError.Field), Message: message}}
+		}
+	}
+	// UnmarshalJSON を実装した型のエラーにはキー名が付かないため、どのキーで失敗したかを探す
+	if name := locateField(data, v); name != "" {
+		return Errors{{Field: name, Message: message}}
+	}
+	return fmt.Errorf("%w: %s", ErrMalformed, err.Error())
+}
+
+// fieldPath は encoding/json のフィールドのパス（attendees.0.email）を Nested と同じ形（attendees[0].email）にする
+func fieldPath(path string) string {
+	segments := strings.Split(path, ".")
+	var b strings.Builder
+	for i, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil && i > 0 {
+			b.WriteString("[" + segment + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(segment)
+	}
+	return b.String()
+}
+
+// locateField は最上位のキーを1つずつ読み込み直し、読み込みに失敗するキーを返す
+func locateField(data []byte, v interface{}) string {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil {
+		return ""
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	typ := reflect.TypeOf(v).Elem()
+	for _, name := range names {
+		single, err := json.Marshal(map[string]json.RawMessage{name: fields[name]})
+		if err != nil {
+			continue
+		}
+		if json.Unmarshal(single, reflect.New(typ).Interface()) != nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// describeType は JSON での型の名前を返す
+func describeType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
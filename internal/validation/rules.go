@@ -0,0 +1,132 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Required は値がゼロ値（空文字列や nil）でないことを求める
+func Required[T comparable]() Rule[T] {
+	return func(value T) string {
+		var zero T
+		if value == zero {
+			return "is required"
+		}
+		return ""
+	}
+}
+
+// NotBlank は空白以外の文字を含むことを求める
+func NotBlank(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "must not be blank"
+	}
+	return ""
+}
+
+// MaxLength は文字数（バイト数ではない）が max 以下であることを求める
+func MaxLength(max int) Rule[string] {
+	return func(value string) string {
+		if utf8.RuneCountInString(value) > max {
+			return fmt.Sprintf("must be at most %d characters", max)
+		}
+		return ""
+	}
+}
+
+// NotEmpty は要素が1つ以上あることを求める
+func NotEmpty[T any]() Rule[[]T] {
+	return func(value []T) string {
+		if len(value) == 0 {
+			return "must not be empty"
+		}
+		return ""
+	}
+}
+
+// MaxItems は要素数が max 以下であることを求める
+func MaxItems[T any](max int) Rule[[]T] {
+	return func(value []T) string {
+		if len(value) > max {
+			return fmt.Sprintf("must have at most %d items", max)
+		}
+		return ""
+	}
+}
+
+// Range は min 以上 max 以下であることを求める
+func Range(min int, max int) Rule[int] {
+	return func(value int) string {
+		if value != 0 && (value < min || value > max) {
+			return fmt.Sprintf("must be between %d and %d", min, max)
+		}
+		return ""
+	}
+}
+
+// OneOf は値が allowed のいずれかであることを求める
+func OneOf(allowed ...string) Rule[string] {
+	return func(value string) string {
+		if value == "" {
+			return ""
+		}
+		for _, candidate := range allowed {
+			if value == candidate {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(allowed, ", ")
+	}
+}
+
+// Match は値が pattern に一致することを求める。description は違反したときに期待する形として示す
+func Match(pattern *regexp.Regexp, description string) Rule[string] {
+	return func(value string) string {
+		if value != "" && !pattern.MatchString(value) {
+			return "must be " + description
+		}
+		return ""
+	}
+}
+
+// Email はメールアドレスとして解釈できることを求める
+func Email(value string) string {
+	if value == "" {
+		return ""
+	}
+	if _, err := mail.ParseAddress(value); err != nil {
+		return "must be a valid email address"
+	}
+	return ""
+}
+
+// HTTPSURL は https の絶対 URL であることを求める
+func HTTPSURL(value string) string {
+	if value == "" {
+		return ""
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return "must be an https URL"
+	}
+	return ""
+}
+
+// After は other より後の日時であることを求める。otherName は比較するフィールドの名前。
+// どちらかがゼロ値の場合は検証しない（必須かどうかは Required で検証する）
+func After(otherName string, other time.Time) Rule[time.Time] {
+	return func(value time.Time) string {
+		if value.IsZero() || other.IsZero() {
+			return ""
+		}
+		if !value.After(other) {
+			return "must be after " + otherName
+		}
+		return ""
+	}
+}
@@ -0,0 +1,100 @@
+// Package validation はリクエストのモデルを宣言的に検証する。
+// モデルは Validate で各フィールドの規則を並べ、違反はフィールドごとの FieldError として返す。
+package validation
+
+import (
+	"strings"
+)
+
+// FieldError は1つのフィールドの検証エラー。Field は JSON のキー名（ネストは "attendees[0].email" の形）
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors はフィールドごとの検証エラー
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldError := range e {
+		messages[i] = fieldError.Field + ": " + fieldError.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// Validator は自身を検証できるモデル
+type Validator interface {
+	Validate() error
+}
+
+// Rule は値に対する規則。違反していればメッセージを返し、問題がなければ空文字列を返す。
+// Required 以外の規則はゼロ値を検証しない（省略可能なフィールドにそのまま使える）
+type Rule[T any] func(value T) string
+
+// Check はフィールドを検証し、違反を返す
+type Check func() Errors
+
+// Validate はすべての Check を実行し、違反があれば Errors を返す
+func Validate(checks ...Check) error {
+	var errs Errors
+	for _, check := range checks {
+		errs = append(errs, check()...)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Field は value に rules を順に適用する。最初に違反した規則だけを報告する
+func Field[T any](name string, value T, rules ...Rule[T]) Check {
+	return func() Errors {
+		for _, rule := range rules {
+			if message := rule(value); message != "" {
+				return Errors{{Field: name, Message: message}}
+			}
+		}
+		return nil
+	}
+}
+
+// When は condition が true の場合だけ checks を実行する（JSON Merge Patch で指定されたフィールドなど）
+func When(condition bool, checks ...Check) Check {
+	return func() Errors {
+		if !condition {
+			return nil
+		}
+		var errs Errors
+		for _, check := range checks {
+			errs = append(errs, check()...)
+		}
+		return errs
+	}
+}
+
+// Nested はネストしたモデルを検証し、フィールド名に name を前置する
+func Nested(name string, v Validator) Check {
+	return func() Errors {
+		err := v.Validate()
+		if err == nil {
+			return nil
+		}
+		errs, ok := err.(Errors)
+		if !ok {
+			return Errors{{Field: name, Message: err.Error()}}
+		}
+		nested := make(Errors, len(errs))
+		for i, fieldError := range errs {
+			nested[i] = FieldError{Field: name + "." + fieldError.Field, Message: fieldError.Message}
+		}
+		return nested
+	}
+}
+
+// Fail は常に違反を報告する。フィールドをまたぐ条件（どちらか一方が必須など）に使う
+func Fail(name string, message string) Check {
+	return func() Errors {
+		return Errors{{Field: name, Message: message}}
+	}
+}
@@ -0,0 +1,145 @@
+package validation_test
+
+import (
+	"bonded/internal/validation"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type guest struct {
+	Email string `json:"email"`
+}
+
+func (g *guest) Validate() error {
+	return validation.Validate(
+		validation.Field("email", g.Email, validation.Required[string](), validation.Email),
+	)
+}
+
+type party struct {
+	Name   string  `json:"name"`
+	Seats  int     `json:"seats"`
+	Guests []guest `json:"guests"`
+}
+
+func (p *party) Validate() error {
+	checks := []validation.Check{
+		validation.Field("name", p.Name, validation.Required[string](), validation.NotBlank, validation.MaxLength(5)),
+		validation.Field("seats", p.Seats, validation.Range(1, 10)),
+		validation.Field("guests", p.Guests, validation.MaxItems[guest](2)),
+	}
+	for i := range p.Guests {
+		checks = append(checks, validation.Nested(fmt.Sprintf("guests[%d]", i), &p.Guests[i]))
+	}
+	return validation.Validate(checks...)
+}
+
+func TestRules(t *testing.T) {
+	start := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		message string
+		want    bool // 違反を報告するか
+	}{
+		{"Required empty", validation.Required[string]()(""), true},
+		{"Required set", validation.Required[string]()("x"), false},
+		{"NotBlank spaces", validation.NotBlank("  "), true},
+		{"MaxLength counts runes", validation.MaxLength(3)("あいう"), false},
+		{"MaxLength over", validation.MaxLength(3)("abcd"), true},
+		{"NotEmpty", validation.NotEmpty[int]()(nil), true},
+		{"MaxItems", validation.MaxItems[int](1)([]int{1, 2}), true},
+		{"Range zero is unset", validation.Range(1, 10)(0), false},
+		{"Range over", validation.Range(1, 10)(11), true},
+		{"OneOf empty is unset", validation.OneOf("A", "B")(""), false},
+		{"OneOf other", validation.OneOf("A", "B")("C"), true},
+		{"Match", validation.Match(regexp.MustCompile(`^\d+$`), "digits")("12a"), true},
+		{"Email", validation.Email("not an address"), true},
+		{"Email valid", validation.Email("alice@example.com"), false},
+		{"HTTPSURL http", validation.HTTPSURL("http://example.com"), true},
+		{"HTTPSURL https", validation.HTTPSURL("https://example.com/feed"), false},
+		{"After equal", validation.After("startTime", start)(start), true},
+		{"After later", validation.After("startTime", start)(start.Add(time.Minute)), false},
+		{"After unset", validation.After("startTime", time.Time{})(start), false},
+	}
+	for _, tt := range tests {
+		if got := tt.message != ""; got != tt.want {
+			t.Errorf("%s: message = %q, want violation %v", tt.name, tt.message, tt.want)
+		}
+	}
+}
+
+func TestValidateReportsEachField(t *testing.T) {
+	p := &party{Name: "  ", Seats: 20, Guests: []guest{{Email: "alice@example.com"}, {Email: "bob"}}}
+	err := p.Validate()
+
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Validate = %v, want validation.Errors", err)
+	}
+	want := validation.Errors{
+		{Field: "name", Message: "must not be blank"},
+		{Field: "seats", Message: "must be between 1 and 10"},
+		{Field: "guests[1].email", Message: "must be a valid email address"},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("errors = %+v, want %+v", errs, want)
+	}
+
+	ok := &party{Name: "Tea", Guests: []guest{{Email: "alice@example.com"}}}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("Validate = %v, want nil", err)
+	}
+}
+
+func TestWhen(t *testing.T) {
+	check := validation.Field("title", "", validation.Required[string]())
+	if err := validation.Validate(validation.When(false, check)); err != nil {
+		t.Errorf("When(false) = %v, want nil", err)
+	}
+	if err := validation.Validate(validation.When(true, check)); err == nil {
+		t.Error("When(true) = nil, want an error")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		field     string // 期待するフィールドのエラー（空なら ErrMalformed か nil）
+		malformed bool
+	}{
+		{name: "valid", body: `{"name":"Tea","seats":2}`},
+		{name: "unknown field", body: `{"name":"Tea","owner":"bob"}`, field: "owner"},
+		{name: "wrong type", body: `{"name":"Tea","seats":"two"}`, field: "seats"},
+		{name: "wrong nested type", body: `{"name":"Tea","guests":[{"email":1}]}`, field: "guests[0].email"},
+		{name: "validation", body: `{"name":"Banquet"}`, field: "name"},
+		{name: "syntax", body: `{"name":`, malformed: true},
+		{name: "empty", body: ``, malformed: true},
+		{name: "trailing data", body: `{"name":"Tea"} {}`, malformed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validation.Decode([]byte(tt.body), &party{})
+			if tt.malformed {
+				if !errors.Is(err, validation.ErrMalformed) {
+					t.Errorf("Decode = %v, want ErrMalformed", err)
+				}
+				return
+			}
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("Decode = %v, want nil", err)
+				}
+				return
+			}
+			var errs validation.Errors
+			if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field != tt.field {
+				t.Errorf("Decode = %v, want an error for %s", err, tt.field)
+			}
+		})
+	}
+}
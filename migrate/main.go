@@ -0,0 +1,81 @@
+// migrate は既存のテーブルに新しい GSI を作成し、既存のアイテムに GSI のキーを設定する。
+//   - CalendarID-StartKey-index: 日付範囲でのイベント検索。EVENT# アイテムに StartKey を設定する
+//   - PublicListing-index: 公開カレンダーの一覧。CALENDAR アイテムに PublicListing などを設定する
+//   - EmailKey-index・Directory-NameKey-index: ユーザー検索。PROFILE アイテムに EmailKey などを設定する
+//
+// また、ゴミ箱のアイテムを削除する TTL（ExpiresAt）を有効にする。
+//
+// 繰り返し実行しても問題ない。
+//
+//	DYNAMODB_ENDPOINT=http://localhost:8000 go run ./migrate
+package main
+
+import (
+	"bonded/internal/infra/db"
+	"bonded/internal/repository"
+	"context"
+	"log"
+)
+
+func main() {
+	ctx := context.Background()
+	dynamoClient := db.DynamoDBClientRequest()
+
+	created, err := repository.EnsureStartKeyIndex(ctx, dynamoClient)
+	if err != nil {
+		log.Fatalf("Failed to create index %s: %v", repository.StartKeyIndexName, err)
+	}
+	if created {
+		log.Printf("Creating index %s", repository.StartKeyIndexName)
+	}
+
+	migrated, err := repository.MigrateEventStartKeys(ctx, dynamoClient)
+	if err != nil {
+		log.Fatalf("Failed to migrate events after %d items: %v", migrated, err)
+	}
+	log.Printf("Set StartKey on %d events", migrated)
+
+	created, err = repository.EnsurePublicListingIndex(ctx, dynamoClient)
+	if err != nil {
+		log.Fatalf("Failed to create index %s: %v", repository.PublicListingIndexName, err)
+	}
+	if created {
+		log.Printf("Creating index %s", repository.PublicListingIndexName)
+	}
+
+	migrated, err = repository.MigratePublicListings(ctx, dynamoClient)
+	if err != nil {
+		log.Fatalf("Failed to migrate calendars after %d items: %v", migrated, err)
+	}
+	log.Printf("Updated listing attributes on %d calendars", migrated)
+
+	created, err = repository.EnsureProfileEmailIndex(ctx, dynamoClient)
+	if err != nil {
+		log.Fatalf("Failed to create index %s: %v", repository.ProfileEmailIndexName, err)
+	}
+	if created {
+		log.Printf("Creating index %s", repository.ProfileEmailIndexName)
+	}
+
+	created, err = repository.EnsureProfileNameIndex(ctx, dynamoClient)
+	if err != nil {
+		log.Fatalf("Failed to create index %s: %v", repository.ProfileNameIndexName, err)
+	}
+	if created {
+		log.Printf("Creating index %s", repository.ProfileNameIndexName)
+	}
+
+	migrated, err = repository.MigrateProfileDirectory(ctx, dynamoClient)
+	if err != nil {
+		log.Fatalf("Failed to migrate profiles after %d items: %v", migrated, err)
+	}
+	log.Printf("Set directory attributes on %d profiles", migrated)
+
+	enabled, err := repository.EnsureTrashTTL(ctx, dynamoClient)
+	if err != nil {
+		log.Fatalf("Failed to enable TTL on %s: %v", repository.TrashTTLAttribute, err)
+	}
+	if enabled {
+		log.Printf("Enabled TTL on %s", repository.TrashTTLAttribute)
+	}
+}